@@ -3,17 +3,34 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
+	"github.com/muesli/termenv"
 	"github.com/olliecrow/codex_usage_monitor/internal/tui"
 	"github.com/olliecrow/codex_usage_monitor/internal/usage"
 	"golang.org/x/term"
 )
 
+// Flag defaults that the config command also reports.
+const (
+	defaultTUIInterval        = 60 * time.Second
+	defaultTUITimeout         = 10 * time.Second
+	defaultDoctorTimeout      = 20 * time.Second
+	defaultDoctorCheckTimeout = 8 * time.Second
+)
+
 func main() {
 	os.Exit(run(os.Args[1:]))
 }
@@ -30,6 +47,12 @@ func run(args []string) int {
 		return runDoctor(args[1:])
 	case "completion":
 		return runCompletion(args[1:])
+	case "config":
+		return runConfig(args[1:])
+	case "metrics":
+		return runMetrics(args[1:])
+	case "version", "--version":
+		return runVersion(args[1:])
 	case "-h", "--help", "help":
 		printRootUsage()
 		return 0
@@ -44,9 +67,38 @@ func run(args []string) int {
 	}
 }
 
+// commit is the git revision, set with -ldflags "-X main.commit=<sha>". When
+// empty, the VCS revision recorded by the Go toolchain is used if present.
+var commit = ""
+
+func runVersion(args []string) int {
+	if len(args) > 0 {
+		fmt.Fprintln(os.Stderr, "error: version takes no arguments")
+		return 2
+	}
+	fmt.Printf("codex-usage-monitor %s\n", usage.Version)
+	fmt.Printf("commit: %s\n", buildCommit())
+	fmt.Printf("go: %s\n", runtime.Version())
+	return 0
+}
+
+func buildCommit() string {
+	if commit != "" {
+		return commit
+	}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			if setting.Key == "vcs.revision" && setting.Value != "" {
+				return setting.Value
+			}
+		}
+	}
+	return "unknown"
+}
+
 func runCompletion(args []string) int {
 	if len(args) > 1 {
-		fmt.Fprintln(os.Stderr, "error: completion accepts zero or one shell argument (bash or zsh)")
+		fmt.Fprintln(os.Stderr, "error: completion accepts zero or one shell argument (bash, zsh, fish, or powershell)")
 		return 2
 	}
 	shell := "bash"
@@ -62,11 +114,178 @@ func runCompletion(args []string) int {
 	return 0
 }
 
+type outputFormat string
+
+const (
+	outputFormatHuman outputFormat = "human"
+	outputFormatJSON  outputFormat = "json"
+	outputFormatYAML  outputFormat = "yaml"
+)
+
+// parseOutputFormat keeps --json as an alias for --format json; giving both
+// is an error even when they agree, so scripts do not depend on precedence.
+func parseOutputFormat(value string, jsonAlias bool) (outputFormat, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if jsonAlias {
+		if value != "" {
+			return "", fmt.Errorf("--format and --json cannot be combined")
+		}
+		return outputFormatJSON, nil
+	}
+	switch format := outputFormat(value); format {
+	case "":
+		return outputFormatHuman, nil
+	case outputFormatHuman, outputFormatJSON, outputFormatYAML:
+		return format, nil
+	default:
+		return "", fmt.Errorf("unsupported format %q (expected human, json, or yaml)", value)
+	}
+}
+
+type configReport struct {
+	usage.EnvironmentReport
+	Settings []configSetting `json:"settings"`
+	Warnings []string        `json:"warnings,omitempty"`
+}
+
+// configSetting is one effective flag value and where it came from: default,
+// config, or the min-interval floor.
+type configSetting struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Source string `json:"source"`
+}
+
+func runConfig(args []string) int {
+	fs := flag.NewFlagSet("config", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	jsonOutput := fs.Bool("json", false, "output the resolved configuration as JSON")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(os.Stderr, "error: config takes no arguments, got %q\n", fs.Arg(0))
+		return 2
+	}
+
+	report := configReport{EnvironmentReport: usage.ResolveEnvironment(minIntervalEnvVar, colorsEnvVar, "CI")}
+	var sections map[string]map[string]any
+	if report.ConfigFile != "" {
+		var err error
+		if sections, err = readConfigSections(report.ConfigFile); err != nil {
+			report.Warnings = append(report.Warnings, fmt.Sprintf("ignoring config file: %v", err))
+		}
+	}
+	report.Settings, report.Warnings = effectiveSettings(sections, os.Getenv(minIntervalEnvVar), report.Warnings)
+
+	if *jsonOutput {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			fmt.Fprintf(os.Stderr, "error: failed to encode JSON: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+	printConfigHuman(report)
+	return 0
+}
+
+// effectiveSettings resolves the poll and timeout flags the way tui and doctor
+// would without command-line flags.
+func effectiveSettings(sections map[string]map[string]any, floorEnv string, warnings []string) ([]configSetting, []string) {
+	resolve := func(command, name string, fallback time.Duration) (time.Duration, string) {
+		raw, ok := sections[command][name]
+		if !ok {
+			return fallback, "default"
+		}
+		text, _ := raw.(string)
+		value, err := time.ParseDuration(text)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("config %s.%s: invalid duration %v", command, name, raw))
+			return fallback, "default"
+		}
+		return value, "config"
+	}
+
+	interval, intervalSource := resolve("tui", "interval", defaultTUIInterval)
+	if floored, note := applyIntervalFloor(interval, floorEnv); floored != interval {
+		interval, intervalSource = floored, "min interval floor"
+		warnings = append(warnings, note)
+	}
+	tuiTimeout, tuiTimeoutSource := resolve("tui", "timeout", defaultTUITimeout)
+	doctorTimeout, doctorTimeoutSource := resolve("doctor", "timeout", defaultDoctorTimeout)
+	checkTimeout, checkTimeoutSource := resolve("doctor", "check-timeout", defaultDoctorCheckTimeout)
+	if checkTimeout > doctorTimeout {
+		checkTimeout, checkTimeoutSource = doctorTimeout, "capped at doctor.timeout"
+	}
+	return []configSetting{
+		{Name: "tui.interval", Value: interval.String(), Source: intervalSource},
+		{Name: "tui.timeout", Value: tuiTimeout.String(), Source: tuiTimeoutSource},
+		{Name: "doctor.timeout", Value: doctorTimeout.String(), Source: doctorTimeoutSource},
+		{Name: "doctor.check-timeout", Value: checkTimeout.String(), Source: checkTimeoutSource},
+	}, warnings
+}
+
+func printConfigHuman(report configReport) {
+	fmt.Println("codex usage monitor config")
+	fmt.Println()
+	fmt.Printf("codex home:      %s\n", report.CodexHome)
+	fmt.Printf("accounts file:   %s%s\n", report.AccountsFile, missingSuffix(report.AccountsFile))
+	fmt.Printf("config file:     %s%s\n", report.ConfigFile, missingSuffix(report.ConfigFile))
+	fmt.Printf("codex binary:    %s\n", report.CodexBinary)
+	fmt.Printf("usage endpoint:  %s\n", report.UsageEndpoint)
+	fmt.Println()
+	fmt.Println("accounts:")
+	if len(report.Accounts) == 0 {
+		fmt.Println("  none")
+	}
+	for _, account := range report.Accounts {
+		fmt.Printf("  %s  %s\n", account.Label, account.CodexHome)
+	}
+	if report.AccountsWarning != "" {
+		fmt.Printf("  warning: %s\n", report.AccountsWarning)
+	}
+	fmt.Println()
+	fmt.Println("settings:")
+	for _, setting := range report.Settings {
+		fmt.Printf("  %-21s %s (%s)\n", setting.Name, setting.Value, setting.Source)
+	}
+	fmt.Println()
+	if len(report.EnvVarsSet) == 0 {
+		fmt.Println("env vars set: none")
+	} else {
+		fmt.Printf("env vars set: %s\n", strings.Join(report.EnvVarsSet, ", "))
+	}
+	for _, msg := range report.Errors {
+		fmt.Printf("error: %s\n", msg)
+	}
+	for _, msg := range report.Warnings {
+		fmt.Printf("warning: %s\n", msg)
+	}
+}
+
+func missingSuffix(path string) string {
+	if path == "" {
+		return ""
+	}
+	if _, err := os.Stat(path); err != nil {
+		return " (not present)"
+	}
+	return ""
+}
+
 func runDoctor(args []string) int {
 	fs := flag.NewFlagSet("doctor", flag.ContinueOnError)
 	fs.SetOutput(os.Stderr)
-	jsonOutput := fs.Bool("json", false, "output doctor report as JSON")
-	timeout := fs.Duration("timeout", 20*time.Second, "doctor timeout")
+	jsonOutput := fs.Bool("json", false, "output doctor report as JSON (alias for --format json)")
+	formatFlag := fs.String("format", "", "report format: human, json, or yaml (default human)")
+	timeout := fs.Duration("timeout", defaultDoctorTimeout, "doctor timeout")
+	verbose := fs.Bool("verbose", false, "list scanned session files and per-file token events on stderr")
+	creditsMin := fs.Float64("credits-min", 0, "fail when the credit balance is below this value")
+	checkTimeout := fs.Duration("check-timeout", defaultDoctorCheckTimeout, "per-source fetch timeout, capped at --timeout")
+	allAccounts := fs.Bool("all-accounts", false, "also check app-server and oauth fetches for every discovered account home")
+	applyConfigDefaults(fs, "doctor")
 	if err := fs.Parse(args); err != nil {
 		return 2
 	}
@@ -74,6 +293,29 @@ func runDoctor(args []string) int {
 		fmt.Fprintln(os.Stderr, "error: --timeout must be > 0")
 		return 2
 	}
+	if *checkTimeout <= 0 {
+		fmt.Fprintln(os.Stderr, "error: --check-timeout must be > 0")
+		return 2
+	}
+	format, err := parseOutputFormat(*formatFlag, *jsonOutput)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+	opts := usage.DoctorOptions{AllAccounts: *allAccounts, CheckTimeout: min(*checkTimeout, *timeout)}
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "credits-min" {
+			opts.CreditsMin = creditsMin
+		}
+	})
+	if opts.CreditsMin != nil && *opts.CreditsMin < 0 {
+		fmt.Fprintln(os.Stderr, "error: --credits-min must be >= 0")
+		return 2
+	}
+	if err := usage.CheckHomeEnvironment(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
 	if err := usage.EnsureMonitorDataDir(); err != nil {
 		fmt.Fprintf(os.Stderr, "warning: could not ensure monitor data dir: %v\n", err)
 	}
@@ -81,18 +323,27 @@ func runDoctor(args []string) int {
 	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
 	defer cancel()
 
-	report := usage.RunDoctor(ctx)
+	report := usage.RunDoctor(ctx, opts)
 
-	if *jsonOutput {
+	switch format {
+	case outputFormatJSON:
 		enc := json.NewEncoder(os.Stdout)
 		enc.SetIndent("", "  ")
 		if err := enc.Encode(report); err != nil {
 			fmt.Fprintf(os.Stderr, "error: failed to encode JSON: %v\n", err)
 			return 1
 		}
-	} else {
+	case outputFormatYAML:
+		if err := writeYAML(os.Stdout, report); err != nil {
+			fmt.Fprintf(os.Stderr, "error: failed to encode YAML: %v\n", err)
+			return 1
+		}
+	default:
 		printDoctorHuman(report)
 	}
+	if *verbose {
+		printObservedScans(os.Stderr, usage.ScanObservedTokenFiles(ctx, time.Now()))
+	}
 
 	if !report.Healthy() {
 		return 1
@@ -103,10 +354,35 @@ func runDoctor(args []string) int {
 func runTUI(args []string) int {
 	fs := flag.NewFlagSet("tui", flag.ContinueOnError)
 	fs.SetOutput(os.Stderr)
-	interval := fs.Duration("interval", 60*time.Second, "poll interval")
-	timeout := fs.Duration("timeout", 10*time.Second, "per-poll fetch timeout")
+	interval := fs.Duration("interval", defaultTUIInterval, "poll interval")
+	timeout := fs.Duration("timeout", defaultTUITimeout, "per-poll fetch timeout")
 	noColor := fs.Bool("no-color", false, "disable color styling")
 	noAltScreen := fs.Bool("no-alt-screen", false, "disable alternate screen mode")
+	noSpinner := fs.Bool("no-spinner", false, "disable the fetch spinner animation")
+	asciiOnly := fs.Bool("ascii", false, "draw borders, spinner, and status glyphs with plain ASCII")
+	timeFormatFlag := fs.String("time-format", "", "reset time format: rfc3339, kitchen, unix, or a Go layout")
+	relativeTime := fs.Bool("relative-time", false, "show reset times relative to now")
+	countdownFlag := fs.String("countdown", "relative", "header refresh indicator: relative, absolute, or off")
+	outFile := fs.String("out-file", "", "atomically write the latest summary JSON to this path on each poll")
+	jsonlPath := fs.String("jsonl", "", "append each poll's summary or error as one compact JSON line to this file (- for stderr, which must be redirected)")
+	sortFlag := fs.String("sort", "label", "account ordering: label, usage, or tokens")
+	showStats := fs.Bool("show-stats", false, "show the monitor's own resource footprint in the footer")
+	countFormatFlag := fs.String("count-format", "short", "token count style: short, full, or upper")
+	burstThreshold := fs.Float64("burst-threshold", tui.DefaultBurstThreshold, "five-hour vs pro-rated weekly token ratio shown as bursting (0 hides the badge)")
+	tokenFieldsFlag := fs.String("token-fields", "", "observed breakdown lines to show, e.g. input,output,reasoning (default all)")
+	noWarmStart := fs.Bool("no-warm-start", false, "report observed tokens as warming on the first fetch instead of scanning sessions up front")
+	noPrestart := fs.Bool("no-prestart", false, "start app-server sessions on the first fetch instead of in the background at startup")
+	sessionsScopeFlag := fs.String("sessions-scope", "all", "sessions counted in observed totals: all, live, or archived")
+	showRemaining := fs.Bool("show-remaining", false, "show remaining budget instead of used percent in window panels")
+	showBars := fs.Bool("bars", false, "add an ASCII progress bar to each window panel")
+	refreshOnFocus := fs.Bool("refresh-on-focus", false, "refetch immediately when the terminal regains focus")
+	maxFailures := fs.Int("max-consecutive-failures", 0, "exit non-zero after N consecutive fetch failures (0 never exits)")
+	warnAt := fs.Int("warn-at", tui.DefaultThresholds.WarnAt, "used percent at which windows turn the warning color")
+	badAt := fs.Int("bad-at", tui.DefaultThresholds.BadAt, "used percent at which windows turn the critical color")
+	accountFlag := fs.String("account", "", "account label or email whose windows fill the window cards (default: active CODEX_HOME)")
+	announcePath := fs.String("announce", "", "append a plain line per state change to this file (- for stderr, which must be redirected) for screen readers")
+	observedMergeFlag := fs.String("observed-merge", "", "combine homes sharing an identity: max, sum, or latest (default max)")
+	applyConfigDefaults(fs, "tui")
 	if err := fs.Parse(args); err != nil {
 		return 2
 	}
@@ -118,6 +394,84 @@ func runTUI(args []string) int {
 		fmt.Fprintln(os.Stderr, "error: --timeout must be > 0")
 		return 2
 	}
+	if *burstThreshold < 0 {
+		fmt.Fprintln(os.Stderr, "error: --burst-threshold must be >= 0")
+		return 2
+	}
+	if *maxFailures < 0 {
+		fmt.Fprintln(os.Stderr, "error: --max-consecutive-failures must be >= 0")
+		return 2
+	}
+	if strings.TrimSpace(*jsonlPath) == "-" && term.IsTerminal(int(os.Stderr.Fd())) {
+		fmt.Fprintln(os.Stderr, "error: --jsonl - would draw over the TUI; redirect stderr (2>file) or pass a file path")
+		return 2
+	}
+	if strings.TrimSpace(*announcePath) == "-" {
+		if strings.TrimSpace(*jsonlPath) == "-" {
+			fmt.Fprintln(os.Stderr, "error: --announce - and --jsonl - cannot share stderr; pass a file path for one of them")
+			return 2
+		}
+		if term.IsTerminal(int(os.Stderr.Fd())) {
+			fmt.Fprintln(os.Stderr, "error: --announce - would draw over the TUI; redirect stderr (2>file) or pass a file path")
+			return 2
+		}
+	}
+	thresholds := tui.Thresholds{WarnAt: *warnAt, BadAt: *badAt}
+	if err := thresholds.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+	timeFormat, err := tui.ParseTimeFormat(*timeFormatFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+	if *relativeTime && strings.TrimSpace(*timeFormatFlag) != "" {
+		fmt.Fprintln(os.Stderr, "error: --relative-time and --time-format cannot be combined")
+		return 2
+	}
+	accountSort, err := usage.ParseAccountSortMode(*sortFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+	countdown, err := tui.ParseCountdownMode(*countdownFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+	countFormat, err := tui.ParseCountFormat(*countFormatFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+	tokenFields, err := tui.ParseTokenFields(*tokenFieldsFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+	sessionsScope, err := usage.ParseSessionsScope(*sessionsScopeFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+	observedMerge, err := usage.ParseObservedMergeMode(*observedMergeFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+	pollInterval, intervalWarning := applyIntervalFloor(*interval, os.Getenv(minIntervalEnvVar))
+	if intervalWarning != "" {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", intervalWarning)
+	}
+	basicColors, colorsWarning := useBasicColors(os.Getenv(colorsEnvVar), termenv.EnvColorProfile())
+	if colorsWarning != "" {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", colorsWarning)
+	}
+	if err := usage.CheckHomeEnvironment(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
 	if err := usage.EnsureMonitorDataDir(); err != nil {
 		fmt.Fprintf(os.Stderr, "warning: could not ensure monitor data dir: %v\n", err)
 	}
@@ -127,16 +481,85 @@ func runTUI(args []string) int {
 		return 1
 	}
 
-	fetcher := usage.NewDefaultFetcher()
+	announce, closeAnnounce, err := openAppendWriter("announce", *announcePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	defer closeAnnounce()
+	jsonl, closeJSONL, err := openAppendWriter("jsonl", *jsonlPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	defer closeJSONL()
+
+	fetcher := usage.NewDefaultFetcher(usage.FetcherOptions{
+		AccountSort:   accountSort,
+		SessionsScope: sessionsScope,
+		ObservedMerge: observedMerge,
+		WarmStart:     !*noWarmStart,
+		Account:       *accountFlag,
+		NoPrestart:    *noPrestart,
+	})
 	defer fetcher.Close()
 
-	err := tui.Run(tui.Options{
-		Interval:  *interval,
-		Timeout:   *timeout,
-		NoColor:   *noColor,
-		AltScreen: !*noAltScreen,
+	// Fetcher.Stats is sampled on the fetch goroutine and handed to the UI
+	// through an atomic so rendering never races with account refreshes.
+	var latestStats atomic.Pointer[usage.ResourceStats]
+	var statsFn func() usage.ResourceStats
+	if *showStats {
+		initial := fetcher.Stats()
+		latestStats.Store(&initial)
+		statsFn = func() usage.ResourceStats {
+			return *latestStats.Load()
+		}
+	}
+
+	err = tui.Run(tui.Options{
+		Interval:               pollInterval,
+		Timeout:                *timeout,
+		NoColor:                *noColor,
+		NoSpinner:              *noSpinner,
+		ASCII:                  *asciiOnly,
+		BasicColors:            basicColors,
+		AltScreen:              useAltScreen(*noAltScreen, os.Getenv("CI")),
+		TimeFormat:             timeFormat,
+		RelativeTime:           *relativeTime,
+		CountFormat:            countFormat,
+		Countdown:              countdown,
+		TokenFields:            tokenFields,
+		BurstThreshold:         *burstThreshold,
+		Thresholds:             thresholds,
+		Announce:               announce,
+		RefreshOnFocus:         *refreshOnFocus,
+		ShowRemaining:          *showRemaining,
+		ShowBars:               *showBars,
+		Accounts:               fetcher.Accounts(),
+		Stats:                  statsFn,
+		ClearCache:             fetcher.ClearObservedCache,
+		MaxConsecutiveFailures: *maxFailures,
 		Fetch: func(ctx context.Context) (*usage.Summary, error) {
-			return fetcher.Fetch(ctx)
+			summary, err := fetcher.Fetch(ctx)
+			if *showStats {
+				stats := fetcher.Stats()
+				latestStats.Store(&stats)
+			}
+			if jsonl != nil {
+				if writeErr := appendPollLine(jsonl, summary, err, time.Now().UTC()); writeErr != nil && err == nil {
+					summary.Warnings = append(summary.Warnings, fmt.Sprintf("could not write --jsonl: %v", writeErr))
+				}
+			}
+			if err != nil {
+				return summary, err
+			}
+			if strings.TrimSpace(*outFile) == "" {
+				return summary, nil
+			}
+			if writeErr := writeSummaryFile(*outFile, summary); writeErr != nil {
+				summary.Warnings = append(summary.Warnings, fmt.Sprintf("could not write --out-file: %v", writeErr))
+			}
+			return summary, nil
 		},
 	})
 	if err != nil {
@@ -146,6 +569,205 @@ func runTUI(args []string) int {
 	return 0
 }
 
+// applyConfigDefaults sets flag defaults from the config file section for
+// command before the command line is parsed, so explicit flags still win.
+// Problems are printed as warnings; a broken config never blocks startup.
+func applyConfigDefaults(fs *flag.FlagSet, command string) {
+	path, err := usage.ConfigFilePath()
+	if err != nil {
+		if !errors.Is(err, usage.ErrNoUserHome) {
+			fmt.Fprintf(os.Stderr, "warning: could not locate config file: %v\n", err)
+		}
+		return
+	}
+	for _, warning := range loadConfigDefaults(fs, command, path) {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", warning)
+	}
+}
+
+// readConfigSections returns nil sections and no error when the file is missing.
+func readConfigSections(path string) (map[string]map[string]any, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var sections map[string]map[string]any
+	if err := json.Unmarshal(data, &sections); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return sections, nil
+}
+
+func loadConfigDefaults(fs *flag.FlagSet, command, path string) []string {
+	sections, err := readConfigSections(path)
+	if err != nil {
+		return []string{fmt.Sprintf("ignoring config file: %v", err)}
+	}
+	values := sections[command]
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var warnings []string
+	for _, name := range names {
+		var value string
+		switch v := values[name].(type) {
+		case string:
+			value = v
+		case bool:
+			value = strconv.FormatBool(v)
+		case float64:
+			value = strconv.FormatFloat(v, 'f', -1, 64)
+		default:
+			warnings = append(warnings, fmt.Sprintf("config %s.%s: unsupported value %v", command, name, v))
+			continue
+		}
+		if fs.Lookup(name) == nil {
+			warnings = append(warnings, fmt.Sprintf("config %s.%s: unknown flag", command, name))
+			continue
+		}
+		if err := fs.Set(name, value); err != nil {
+			warnings = append(warnings, fmt.Sprintf("config %s.%s: %v", command, name, err))
+		}
+	}
+	return warnings
+}
+
+const (
+	// defaultMinInterval keeps polling polite to the app-server and OAuth endpoint.
+	defaultMinInterval = 5 * time.Second
+	minIntervalEnvVar  = "CODEX_USAGE_MONITOR_MIN_INTERVAL"
+	colorsEnvVar       = "CODEX_USAGE_MONITOR_COLORS"
+)
+
+// applyIntervalFloor raises intervals below the floor. The floor comes from
+// CODEX_USAGE_MONITOR_MIN_INTERVAL when it parses as a duration >= 0.
+func applyIntervalFloor(interval time.Duration, floorEnv string) (time.Duration, string) {
+	floor := defaultMinInterval
+	var notes []string
+	if raw := strings.TrimSpace(floorEnv); raw != "" {
+		parsed, err := time.ParseDuration(raw)
+		if err != nil || parsed < 0 {
+			notes = append(notes, fmt.Sprintf("ignoring invalid %s=%q", minIntervalEnvVar, raw))
+		} else {
+			floor = parsed
+		}
+	}
+	if interval < floor {
+		notes = append(notes, fmt.Sprintf("--interval %s is below the %s minimum; using %s (set %s to lower the floor)", interval, floor, floor, minIntervalEnvVar))
+		interval = floor
+	}
+	return interval, strings.Join(notes, "; ")
+}
+
+// useAltScreen keeps CI logs readable: a set CI variable implies
+// --no-alt-screen so frames land in scrollback.
+func useAltScreen(noAltScreen bool, ci string) bool {
+	if noAltScreen {
+		return false
+	}
+	switch strings.ToLower(strings.TrimSpace(ci)) {
+	case "", "0", "false":
+		return true
+	default:
+		return false
+	}
+}
+
+// useBasicColors reports whether the TUI should use the 16-color palette:
+// only when the detected profile is plain ANSI (TERM=xterm, linux).
+// CODEX_USAGE_MONITOR_COLORS=16 or 256 overrides detection; anything else is
+// ignored with a warning.
+func useBasicColors(override string, profile termenv.Profile) (bool, string) {
+	switch strings.ToLower(strings.TrimSpace(override)) {
+	case "16":
+		return true, ""
+	case "256":
+		return false, ""
+	case "":
+		return profile == termenv.ANSI, ""
+	default:
+		return profile == termenv.ANSI, fmt.Sprintf("ignoring invalid %s=%q (expected 16 or 256)", colorsEnvVar, override)
+	}
+}
+
+// openAppendWriter opens the file behind an append-only output flag. It
+// returns nil for an empty path and stderr for "-".
+func openAppendWriter(flag, path string) (io.Writer, func(), error) {
+	switch strings.TrimSpace(path) {
+	case "":
+		return nil, func() {}, nil
+	case "-":
+		return os.Stderr, func() {}, nil
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, nil, fmt.Errorf("open --%s file: %w", flag, err)
+	}
+	return f, func() { _ = f.Close() }, nil
+}
+
+// pollFailure is the --jsonl record for a failed poll, so a consumer can tell
+// a broken fetch from a quiet one.
+type pollFailure struct {
+	FetchedAt time.Time `json:"fetched_at"`
+	Error     string    `json:"error"`
+}
+
+// appendPollLine writes the summary, or a pollFailure when fetchErr is set, as
+// one compact JSON line in a single write, so a reader tailing the file sees
+// whole records.
+func appendPollLine(w io.Writer, summary *usage.Summary, fetchErr error, at time.Time) error {
+	var record any = summary
+	if fetchErr != nil {
+		record = pollFailure{FetchedAt: at, Error: fetchErr.Error()}
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("encode summary: %w", err)
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// writeSummaryFile writes via a temp file and rename so readers never observe
+// partially written JSON.
+func writeSummaryFile(path string, summary *usage.Summary) error {
+	data, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode summary: %w", err)
+	}
+	data = append(data, '\n')
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("create temp file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("write temp file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("sync temp file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("close temp file: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("replace %s: %w", path, err)
+	}
+	return nil
+}
+
 func printDoctorHuman(report usage.DoctorReport) {
 	fmt.Println("codex usage monitor doctor")
 	fmt.Println()
@@ -154,9 +776,40 @@ func printDoctorHuman(report usage.DoctorReport) {
 		if c.OK {
 			state = "PASS"
 		}
-		fmt.Printf("[%s] %s\n", state, c.Name)
+		if c.Failure != "" {
+			fmt.Printf("[%s] %s (%s) (%dms)\n", state, c.Name, c.Failure, c.DurationMS)
+		} else {
+			fmt.Printf("[%s] %s (%dms)\n", state, c.Name, c.DurationMS)
+		}
 		fmt.Printf("  %s\n", c.Details)
 	}
+	fmt.Println()
+	fmt.Printf("total: %dms\n", report.TotalDurationMS)
+}
+
+func printObservedScans(w io.Writer, scans []usage.ObservedHomeScan) {
+	for _, scan := range scans {
+		fmt.Fprintf(w, "observed token files for %s (%s):\n", scan.Label, scan.CodexHome)
+		if scan.Error != "" {
+			fmt.Fprintf(w, "  error: %s\n", scan.Error)
+		}
+		for _, warning := range scan.Warnings {
+			fmt.Fprintf(w, "  warning: %s\n", warning)
+		}
+		if len(scan.Files) == 0 && scan.Error == "" {
+			fmt.Fprintln(w, "  no recent session files")
+		}
+		for _, file := range scan.Files {
+			fmt.Fprintf(w, "  %s  5h: %d events, %d tokens  weekly: %d events, %d tokens\n",
+				file.Path, file.Events5h, file.Tokens5h, file.EventsWeekly, file.TokensWeekly)
+			if file.Error != "" {
+				fmt.Fprintf(w, "    error: %s\n", file.Error)
+			}
+			for _, warning := range file.Warnings {
+				fmt.Fprintf(w, "    warning: %s\n", warning)
+			}
+		}
+	}
 }
 
 func printRootUsage() {
@@ -170,20 +823,71 @@ func printRootUsage() {
 	fmt.Println("  codex-usage-monitor tui [flags]           Run terminal user interface explicitly")
 	fmt.Println("  codex-usage-monitor doctor [flags]        Run setup and source checks")
 	fmt.Println("  codex-usage-monitor completion [shell]    Print shell completion script")
+	fmt.Println("  codex-usage-monitor config [--json]       Print the resolved configuration")
+	fmt.Println("  codex-usage-monitor metrics [--timeout]   Print usage in Prometheus text format")
+	fmt.Println("  codex-usage-monitor version               Print version, commit, and Go version")
 	fmt.Println()
 	fmt.Println("Completion:")
 	fmt.Println("  codex-usage-monitor completion bash > ~/.local/share/bash-completion/completions/codex-usage-monitor")
 	fmt.Println("  codex-usage-monitor completion zsh > ~/.zsh/completions/_codex-usage-monitor")
+	fmt.Println("  codex-usage-monitor completion fish > ~/.config/fish/completions/codex-usage-monitor.fish")
+	fmt.Println("  codex-usage-monitor completion powershell | Out-String | Invoke-Expression  # add to $PROFILE")
+	fmt.Println()
+	fmt.Println("Config file:")
+	fmt.Println("  ~/codex-usage-monitor/config.json (or CODEX_USAGE_MONITOR_CONFIG_FILE) sets flag defaults,")
+	fmt.Println("  e.g. {\"tui\": {\"interval\": \"30s\", \"no-color\": true}}. Flags beat config beats built-in defaults.")
+	fmt.Println()
+	fmt.Println("Metrics:")
+	fmt.Println("  codex-usage-monitor metrics > <textfile-dir>/codex.prom.tmp; mv <textfile-dir>/codex.prom.tmp <textfile-dir>/codex.prom")
+	fmt.Println("  Writing to a temp file first keeps node_exporter from reading a half-written file; the mv runs")
+	fmt.Println("  even when metrics exits 1, so a failed fetch still publishes codex_usage_up 0.")
 	fmt.Println()
 	fmt.Println("Doctor flags:")
-	fmt.Println("  --json            Output report as JSON")
+	fmt.Println("  --format human    Report format: human, json, or yaml")
+	fmt.Println("  --json            Output report as JSON (alias for --format json)")
 	fmt.Println("  --timeout 20s     Doctor timeout")
+	fmt.Println("  --verbose         List scanned session files and per-file token events on stderr")
+	fmt.Println("  --credits-min N   Fail when the credit balance is below N (skipped when unlimited)")
+	fmt.Println("  --check-timeout 8s  Per-source fetch timeout (capped at --timeout)")
+	fmt.Println("  --all-accounts    Add a fetch check per discovered account home")
 	fmt.Println()
 	fmt.Println("Terminal user interface flags:")
-	fmt.Println("  --interval 60s    Poll interval")
+	fmt.Println("  --interval 60s    Poll interval (minimum 5s unless CODEX_USAGE_MONITOR_MIN_INTERVAL is set)")
 	fmt.Println("  --timeout 10s     Per-poll fetch timeout")
 	fmt.Println("  --no-color        Disable color styling")
-	fmt.Println("  --no-alt-screen   Disable alternate screen mode")
+	fmt.Println("  --no-alt-screen   Disable alternate screen mode (implied when CI is set)")
+	fmt.Println("  --no-spinner      Disable the fetch spinner animation")
+	fmt.Println("  --ascii           Use ASCII borders and glyphs for terminals without box-drawing characters")
+	fmt.Println("  --time-format X   Reset time format: rfc3339, kitchen, unix, or a Go layout")
+	fmt.Println("  --relative-time   Show reset times relative to now")
+	fmt.Println("  --countdown X     Next-refresh indicator: relative (in 13s), absolute (at 15:04:05), or off")
+	fmt.Println("  --out-file PATH   Atomically write the latest summary JSON on each poll")
+	fmt.Println("  --jsonl PATH      Append each poll's summary or error as one compact JSON line")
+	fmt.Println("                    (- for stderr, which must be redirected away from the terminal)")
+	fmt.Println("  --sort label      Account ordering: label, usage, or tokens")
+	fmt.Println("  --show-stats      Show app-server sessions, goroutines, memory, and cache size")
+	fmt.Println("  --count-format X  Token count style: short (1.2k), full (1234), or upper (1.2K)")
+	fmt.Println("  --token-fields X  Breakdown lines: total, input, cached-input, output, cached-output, reasoning, cost")
+	fmt.Println("  --burst-threshold 3  Flag five-hour tokens above N x the weekly average as bursting (0 = off)")
+	fmt.Println("  --no-warm-start   Skip the first-fetch session scan; observed tokens show as warming")
+	fmt.Println("  --no-prestart     Start app-server sessions on the first fetch, not at startup")
+	fmt.Println("  --sessions-scope  Sessions counted in observed totals: all, live, or archived")
+	fmt.Println("  --observed-merge  Combine homes sharing an identity: max, sum, or latest")
+	fmt.Println("  --show-remaining  Show remaining budget instead of used percent")
+	fmt.Println("  --bars            Add an ASCII progress bar to each window panel")
+	fmt.Println("  --refresh-on-focus  Refetch when the terminal regains focus (if it reports focus)")
+	fmt.Println("  --warn-at 70      Used percent at which windows turn the warning color")
+	fmt.Println("  --bad-at 90       Used percent at which windows turn the critical color")
+	fmt.Println("  --account NAME    Show this account's windows (label or email) instead of the active CODEX_HOME's")
+	fmt.Println("  --announce PATH   Append one line per usage change for screen readers (- for redirected stderr)")
+	fmt.Println("  --max-consecutive-failures N  Exit non-zero after N failed fetches in a row (0 = never)")
+	fmt.Println()
+	fmt.Println("Terminal user interface keys:")
+	fmt.Println("  q, Esc, Ctrl+C    Exit")
+	fmt.Println("  r                 Refresh now and restart the poll timer")
+	fmt.Println("  R                 Clear the observed-token cache and refresh")
+	fmt.Println("  t                 Toggle the multi-account table")
+	fmt.Println("  l                 Toggle the event log")
 }
 
 func completionScript(shell string) (string, error) {
@@ -193,20 +897,26 @@ func completionScript(shell string) (string, error) {
 _codex_usage_monitor_completion() {
   local cur prev words cword
   _init_completion || return
-  local commands="tui doctor completion help"
+  local commands="tui doctor config metrics version completion help"
   if [[ ${cword} -eq 1 ]]; then
     COMPREPLY=( $(compgen -W "${commands}" -- "${cur}") )
     return
   fi
   case "${words[1]}" in
     completion)
-      COMPREPLY=( $(compgen -W "bash zsh" -- "${cur}") )
+      COMPREPLY=( $(compgen -W "bash zsh fish powershell" -- "${cur}") )
+      ;;
+    config)
+      COMPREPLY=( $(compgen -W "--json" -- "${cur}") )
+      ;;
+    metrics)
+      COMPREPLY=( $(compgen -W "--timeout" -- "${cur}") )
       ;;
     doctor)
-      COMPREPLY=( $(compgen -W "--json --timeout" -- "${cur}") )
+      COMPREPLY=( $(compgen -W "--json --format --timeout --verbose --credits-min --check-timeout --all-accounts" -- "${cur}") )
       ;;
     tui)
-      COMPREPLY=( $(compgen -W "--interval --timeout --no-color --no-alt-screen" -- "${cur}") )
+      COMPREPLY=( $(compgen -W "--interval --timeout --no-color --no-alt-screen --no-spinner --time-format --relative-time --out-file --sort --show-stats --count-format --token-fields --burst-threshold --no-warm-start --sessions-scope --observed-merge --refresh-on-focus --show-remaining --bars --max-consecutive-failures --warn-at --bad-at --announce --account --ascii --countdown --no-prestart --jsonl" -- "${cur}") )
       ;;
     *)
       COMPREPLY=( $(compgen -W "${commands}" -- "${cur}") )
@@ -222,6 +932,9 @@ _codex_usage_monitor() {
   commands=(
     'tui:run terminal user interface'
     'doctor:run setup and source checks'
+    'config:print the resolved configuration'
+    'metrics:print usage in Prometheus text format'
+    'version:print version information'
     'completion:print shell completion script'
     'help:show help text'
   )
@@ -231,19 +944,69 @@ _codex_usage_monitor() {
   fi
   case "${words[2]}" in
     completion)
-      _values 'shell' bash zsh
+      _values 'shell' bash zsh fish powershell
+      ;;
+    config)
+      _values 'flag' --json
+      ;;
+    metrics)
+      _values 'flag' --timeout
       ;;
     doctor)
-      _values 'flag' --json --timeout
+      _values 'flag' --json --format --timeout --verbose --credits-min --check-timeout --all-accounts
       ;;
     tui)
-      _values 'flag' --interval --timeout --no-color --no-alt-screen
+      _values 'flag' --interval --timeout --no-color --no-alt-screen --no-spinner --time-format --relative-time --out-file --sort --show-stats --count-format --token-fields --burst-threshold --no-warm-start --sessions-scope --observed-merge --refresh-on-focus --show-remaining --bars --max-consecutive-failures --warn-at --bad-at --announce --account --ascii --countdown --no-prestart --jsonl
       ;;
   esac
 }
 _codex_usage_monitor "$@"
+`, nil
+	case "fish":
+		return `# fish completion for codex-usage-monitor
+complete -c codex-usage-monitor -f
+complete -c codex-usage-monitor -n __fish_use_subcommand -a tui -d 'run terminal user interface'
+complete -c codex-usage-monitor -n __fish_use_subcommand -a doctor -d 'run setup and source checks'
+complete -c codex-usage-monitor -n __fish_use_subcommand -a config -d 'print the resolved configuration'
+complete -c codex-usage-monitor -n __fish_use_subcommand -a metrics -d 'print usage in Prometheus text format'
+complete -c codex-usage-monitor -n __fish_use_subcommand -a version -d 'print version information'
+complete -c codex-usage-monitor -n __fish_use_subcommand -a completion -d 'print shell completion script'
+complete -c codex-usage-monitor -n __fish_use_subcommand -a help -d 'show help text'
+complete -c codex-usage-monitor -n '__fish_seen_subcommand_from completion' -a 'bash zsh fish powershell'
+complete -c codex-usage-monitor -n '__fish_seen_subcommand_from config' -l json
+complete -c codex-usage-monitor -n '__fish_seen_subcommand_from metrics' -r -l timeout
+complete -c codex-usage-monitor -n '__fish_seen_subcommand_from doctor' -l json -l verbose -l all-accounts
+complete -c codex-usage-monitor -n '__fish_seen_subcommand_from doctor' -r -l format -l timeout -l credits-min -l check-timeout
+complete -c codex-usage-monitor -n '__fish_seen_subcommand_from tui' -l no-color -l no-alt-screen -l no-spinner -l ascii -l relative-time -l show-stats -l no-warm-start -l no-prestart -l show-remaining -l bars -l refresh-on-focus
+complete -c codex-usage-monitor -n '__fish_seen_subcommand_from tui' -r -l interval -l timeout -l time-format -l countdown -l sort -l count-format -l burst-threshold -l token-fields -l sessions-scope -l max-consecutive-failures -l warn-at -l bad-at -l account -l observed-merge
+complete -c codex-usage-monitor -n '__fish_seen_subcommand_from tui' -r -F -l out-file -l announce -l jsonl
+`, nil
+	case "powershell":
+		return `# powershell completion for codex-usage-monitor
+Register-ArgumentCompleter -Native -CommandName codex-usage-monitor -ScriptBlock {
+  param($wordToComplete, $commandAst, $cursorPosition)
+  $commands = @('tui', 'doctor', 'config', 'metrics', 'version', 'completion', 'help')
+  $arguments = @{
+    'completion' = @('bash', 'zsh', 'fish', 'powershell')
+    'config'     = @('--json')
+    'metrics'    = @('--timeout')
+    'doctor'     = @('--json', '--format', '--timeout', '--verbose', '--credits-min', '--check-timeout', '--all-accounts')
+    'tui'        = @('--interval', '--timeout', '--no-color', '--no-alt-screen', '--no-spinner', '--time-format', '--relative-time', '--out-file', '--sort', '--show-stats', '--count-format', '--token-fields', '--burst-threshold', '--no-warm-start', '--sessions-scope', '--observed-merge', '--refresh-on-focus', '--show-remaining', '--bars', '--max-consecutive-failures', '--warn-at', '--bad-at', '--announce', '--account', '--ascii', '--countdown', '--no-prestart', '--jsonl')
+  }
+  $words = @($commandAst.CommandElements | Select-Object -Skip 1 | ForEach-Object { $_.ToString() })
+  if ($wordToComplete -ne '' -and $words.Count -gt 0) {
+    $words = @($words | Select-Object -SkipLast 1)
+  }
+  $candidates = $commands
+  if ($words.Count -gt 0 -and $arguments.ContainsKey($words[0])) {
+    $candidates = $arguments[$words[0]]
+  }
+  $candidates | Where-Object { $_ -like "$wordToComplete*" } | ForEach-Object {
+    [System.Management.Automation.CompletionResult]::new($_, $_, 'ParameterValue', $_)
+  }
+}
 `, nil
 	default:
-		return "", fmt.Errorf("unsupported shell %q (expected bash or zsh)", shell)
+		return "", fmt.Errorf("unsupported shell %q (expected bash, zsh, fish, or powershell)", shell)
 	}
 }
@@ -1,10 +1,18 @@
 package main
 
 import (
+	"encoding/json"
+	"errors"
+	"flag"
 	"io"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/muesli/termenv"
+	"github.com/olliecrow/codex_usage_monitor/internal/usage"
 )
 
 func TestRunHelpIncludesCompletionAndTerminalUserInterfaceText(t *testing.T) {
@@ -20,6 +28,22 @@ func TestRunHelpIncludesCompletionAndTerminalUserInterfaceText(t *testing.T) {
 	}
 }
 
+func TestRunVersionPrintsVersionCommitAndGo(t *testing.T) {
+	prevVersion, prevCommit := usage.Version, commit
+	t.Cleanup(func() { usage.Version, commit = prevVersion, prevCommit })
+	usage.Version, commit = "v1.2.3", "abc1234"
+
+	code, stdout, _ := runWithCapturedOutput(t, []string{"version"})
+	if code != 0 {
+		t.Fatalf("expected code 0, got %d", code)
+	}
+	for _, want := range []string{"codex-usage-monitor v1.2.3\n", "commit: abc1234\n", "go: go1."} {
+		if !strings.Contains(stdout, want) {
+			t.Fatalf("expected %q in version output, got:\n%s", want, stdout)
+		}
+	}
+}
+
 func TestRunCompletionDefaultIsBash(t *testing.T) {
 	code, stdout, _ := runWithCapturedOutput(t, []string{"completion"})
 	if code != 0 {
@@ -40,8 +64,34 @@ func TestRunCompletionZsh(t *testing.T) {
 	}
 }
 
+func TestRunCompletionPowerShell(t *testing.T) {
+	code, stdout, _ := runWithCapturedOutput(t, []string{"completion", "powershell"})
+	if code != 0 {
+		t.Fatalf("expected code 0, got %d", code)
+	}
+	if !strings.Contains(stdout, "Register-ArgumentCompleter -Native -CommandName codex-usage-monitor") {
+		t.Fatalf("expected powershell completion output, got:\n%s", stdout)
+	}
+	if !strings.Contains(stdout, "'--check-timeout'") {
+		t.Fatalf("expected doctor flags in powershell completion, got:\n%s", stdout)
+	}
+}
+
+func TestRunCompletionFish(t *testing.T) {
+	code, stdout, _ := runWithCapturedOutput(t, []string{"completion", "fish"})
+	if code != 0 {
+		t.Fatalf("expected code 0, got %d", code)
+	}
+	if !strings.Contains(stdout, "complete -c codex-usage-monitor -n '__fish_seen_subcommand_from doctor' -l json") {
+		t.Fatalf("expected fish completion output, got:\n%s", stdout)
+	}
+	if !strings.Contains(stdout, "-r -F -l out-file -l announce -l jsonl\n") {
+		t.Fatalf("expected path flags to complete file names, got:\n%s", stdout)
+	}
+}
+
 func TestRunCompletionRejectsUnknownShell(t *testing.T) {
-	code, _, stderr := runWithCapturedOutput(t, []string{"completion", "fish"})
+	code, _, stderr := runWithCapturedOutput(t, []string{"completion", "tcsh"})
 	if code != 2 {
 		t.Fatalf("expected code 2 for unsupported shell, got %d", code)
 	}
@@ -60,6 +110,460 @@ func TestRunSnapshotCommandIsNotSupported(t *testing.T) {
 	}
 }
 
+func TestRunTUIRejectsInvalidTimeFormat(t *testing.T) {
+	code, _, stderr := runWithCapturedOutput(t, []string{"tui", "--time-format", "nonsense"})
+	if code != 2 {
+		t.Fatalf("expected code 2 for invalid time format, got %d", code)
+	}
+	if !strings.Contains(stderr, "invalid time format") || !strings.Contains(stderr, "rfc3339") {
+		t.Fatalf("expected time format guidance, got:\n%s", stderr)
+	}
+}
+
+func TestRunTUIRejectsRelativeTimeWithTimeFormat(t *testing.T) {
+	code, _, stderr := runWithCapturedOutput(t, []string{"tui", "--relative-time", "--time-format", "kitchen"})
+	if code != 2 {
+		t.Fatalf("expected code 2 for conflicting time flags, got %d", code)
+	}
+	if !strings.Contains(stderr, "cannot be combined") {
+		t.Fatalf("expected conflict error, got:\n%s", stderr)
+	}
+}
+
+func TestWriteSummaryFileReplacesAtomically(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "summary.json")
+	if err := os.WriteFile(path, []byte("stale"), 0o600); err != nil {
+		t.Fatalf("seed out file: %v", err)
+	}
+
+	if err := writeSummaryFile(path, &usage.Summary{Source: "app-server", PlanType: "pro"}); err != nil {
+		t.Fatalf("unexpected write error: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read out file: %v", err)
+	}
+	var decoded usage.Summary
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("expected valid JSON, got %q: %v", data, err)
+	}
+	if decoded.PlanType != "pro" {
+		t.Fatalf("expected plan type pro, got %q", decoded.PlanType)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("read dir: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected temp files to be cleaned up, found %d entries", len(entries))
+	}
+}
+
+func TestAppendPollLineWritesOneCompactRecordPerPoll(t *testing.T) {
+	var buf strings.Builder
+	at := time.Date(2026, 2, 26, 20, 0, 0, 0, time.UTC)
+	for _, plan := range []string{"pro", "plus"} {
+		if err := appendPollLine(&buf, &usage.Summary{Source: "app-server", PlanType: plan}, nil, at); err != nil {
+			t.Fatalf("unexpected write error: %v", err)
+		}
+	}
+	if err := appendPollLine(&buf, nil, errors.New("app-server timed out"), at); err != nil {
+		t.Fatalf("unexpected write error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected one line per poll, got %q", buf.String())
+	}
+	for i, want := range []string{"pro", "plus"} {
+		var decoded usage.Summary
+		if err := json.Unmarshal([]byte(lines[i]), &decoded); err != nil {
+			t.Fatalf("expected line %d to be JSON, got %q: %v", i, lines[i], err)
+		}
+		if decoded.PlanType != want {
+			t.Fatalf("expected line %d plan %q, got %q", i, want, decoded.PlanType)
+		}
+	}
+	if lines[2] != `{"fetched_at":"2026-02-26T20:00:00Z","error":"app-server timed out"}` {
+		t.Fatalf("expected a failure record for the failed poll, got %q", lines[2])
+	}
+}
+
+func TestRunTUIRejectsUnknownSort(t *testing.T) {
+	code, _, stderr := runWithCapturedOutput(t, []string{"tui", "--sort", "hotness"})
+	if code != 2 {
+		t.Fatalf("expected code 2 for unknown sort, got %d", code)
+	}
+	if !strings.Contains(stderr, "unsupported sort") {
+		t.Fatalf("expected unsupported sort error, got:\n%s", stderr)
+	}
+}
+
+func TestPrintObservedScansListsFilesAndCounts(t *testing.T) {
+	var buf strings.Builder
+	printObservedScans(&buf, []usage.ObservedHomeScan{
+		{
+			Label:     "work",
+			CodexHome: "/tmp/codex-work",
+			Files: []usage.ObservedFileScan{
+				{Path: "/tmp/codex-work/sessions/a.jsonl", Events5h: 2, Tokens5h: 140, EventsWeekly: 3, TokensWeekly: 180},
+				{Path: "/tmp/codex-work/sessions/b.jsonl", Warnings: []string{"skip b.jsonl: permission denied"}},
+			},
+		},
+		{Label: "empty", CodexHome: "/tmp/codex-empty"},
+	})
+	out := buf.String()
+	for _, want := range []string{
+		"observed token files for work (/tmp/codex-work):",
+		"a.jsonl  5h: 2 events, 140 tokens  weekly: 3 events, 180 tokens",
+		"    warning: skip b.jsonl: permission denied",
+		"observed token files for empty (/tmp/codex-empty):\n  no recent session files",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected verbose output to contain %q, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestRunDoctorRejectsNegativeCreditsMin(t *testing.T) {
+	code, _, stderr := runWithCapturedOutput(t, []string{"doctor", "--credits-min", "-1"})
+	if code != 2 {
+		t.Fatalf("expected code 2 for negative --credits-min, got %d", code)
+	}
+	if !strings.Contains(stderr, "--credits-min must be >= 0") {
+		t.Fatalf("expected credits-min error, got:\n%s", stderr)
+	}
+}
+
+func TestRunDoctorRejectsNonPositiveCheckTimeout(t *testing.T) {
+	code, _, stderr := runWithCapturedOutput(t, []string{"doctor", "--check-timeout", "0s"})
+	if code != 2 {
+		t.Fatalf("expected code 2 for zero --check-timeout, got %d", code)
+	}
+	if !strings.Contains(stderr, "--check-timeout must be > 0") {
+		t.Fatalf("expected check-timeout error, got:\n%s", stderr)
+	}
+}
+
+func TestRunDoctorRejectsConflictingOrUnknownFormat(t *testing.T) {
+	code, _, stderr := runWithCapturedOutput(t, []string{"doctor", "--json", "--format", "yaml"})
+	if code != 2 {
+		t.Fatalf("expected code 2 for --json with --format, got %d", code)
+	}
+	if !strings.Contains(stderr, "--format and --json cannot be combined") {
+		t.Fatalf("expected conflict error, got:\n%s", stderr)
+	}
+
+	code, _, stderr = runWithCapturedOutput(t, []string{"doctor", "--format", "toml"})
+	if code != 2 {
+		t.Fatalf("expected code 2 for unknown format, got %d", code)
+	}
+	if !strings.Contains(stderr, `unsupported format "toml"`) {
+		t.Fatalf("expected unsupported format error, got:\n%s", stderr)
+	}
+}
+
+func TestWriteYAMLMirrorsJSONFields(t *testing.T) {
+	count := int64(42)
+	report := struct {
+		Name    string              `json:"name"`
+		OK      bool                `json:"ok"`
+		Count   *int64              `json:"count"`
+		Missing *int64              `json:"missing"`
+		Skipped *int64              `json:"skipped,omitempty"`
+		Checks  []usage.DoctorCheck `json:"checks"`
+		Empty   []string            `json:"empty"`
+		On      string              `json:"on"`
+	}{
+		Name:   "line: one\ntwo",
+		OK:     true,
+		Count:  &count,
+		Checks: []usage.DoctorCheck{{Name: "codex path", OK: true, Details: "resolved", DurationMS: 3}},
+		Empty:  []string{},
+		On:     "yes",
+	}
+	var out strings.Builder
+	if err := writeYAML(&out, report); err != nil {
+		t.Fatalf("writeYAML: %v", err)
+	}
+	want := `name: "line: one\ntwo"
+ok: true
+count: 42
+missing: null
+checks:
+  - name: "codex path"
+    ok: true
+    details: "resolved"
+    duration_ms: 3
+empty: []
+"on": "yes"
+`
+	if out.String() != want {
+		t.Fatalf("unexpected YAML:\n%s\nwant:\n%s", out.String(), want)
+	}
+}
+
+func TestRunTUIExplainsMissingHomeDirectory(t *testing.T) {
+	t.Setenv("HOME", "")
+	t.Setenv("CODEX_HOME", "")
+	code, _, stderr := runWithCapturedOutput(t, []string{"tui"})
+	if code != 1 {
+		t.Fatalf("expected code 1 without a home directory, got %d", code)
+	}
+	if strings.Count(stderr, "error:") != 1 || !strings.Contains(stderr, "set CODEX_HOME") {
+		t.Fatalf("expected one CODEX_HOME hint, got:\n%s", stderr)
+	}
+}
+
+func TestConfigFileSetsDefaultsThatFlagsOverride(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"tui": {"interval": "0s", "no-color": true}}`), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	t.Setenv("CODEX_USAGE_MONITOR_CONFIG_FILE", path)
+
+	code, _, stderr := runWithCapturedOutput(t, []string{"tui"})
+	if code != 2 || !strings.Contains(stderr, "--interval must be > 0") {
+		t.Fatalf("expected the config interval to apply, got code %d:\n%s", code, stderr)
+	}
+	code, _, stderr = runWithCapturedOutput(t, []string{"tui", "--interval", "30s", "--timeout", "0s"})
+	if code != 2 || strings.Contains(stderr, "--interval") || !strings.Contains(stderr, "--timeout must be > 0") {
+		t.Fatalf("expected the command line to win over the config, got code %d:\n%s", code, stderr)
+	}
+}
+
+func TestRunConfigReportsEffectiveSettingsAsJSON(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("CODEX_HOME", "")
+	t.Setenv("CODEX_USAGE_MONITOR_ACCOUNTS_FILE", "")
+	t.Setenv("CODEX_USAGE_MONITOR_COMMAND_SOURCE", "")
+	t.Setenv("CODEX_USAGE_MONITOR_MIN_INTERVAL", "")
+	t.Setenv("CI", "")
+	t.Setenv("CODEX_USAGE_MONITOR_CODEX_ENV_FEATURE", "secret-value")
+	path := filepath.Join(home, "config.json")
+	if err := os.WriteFile(path, []byte(`{"tui": {"interval": "2s"}, "doctor": {"check-timeout": "40s"}}`), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	t.Setenv("CODEX_USAGE_MONITOR_CONFIG_FILE", path)
+
+	code, stdout, stderr := runWithCapturedOutput(t, []string{"config", "--json"})
+	if code != 0 {
+		t.Fatalf("expected code 0, got %d: %s", code, stderr)
+	}
+	var report configReport
+	if err := json.Unmarshal([]byte(stdout), &report); err != nil {
+		t.Fatalf("decode config JSON: %v\n%s", err, stdout)
+	}
+	if report.CodexHome != filepath.Join(home, ".codex") || report.ConfigFile != path {
+		t.Fatalf("unexpected paths: home=%q config=%q", report.CodexHome, report.ConfigFile)
+	}
+	want := map[string]string{
+		"tui.interval":         "5s (min interval floor)",
+		"tui.timeout":          "10s (default)",
+		"doctor.timeout":       "20s (default)",
+		"doctor.check-timeout": "20s (capped at doctor.timeout)",
+	}
+	for _, setting := range report.Settings {
+		if got := setting.Value + " (" + setting.Source + ")"; got != want[setting.Name] {
+			t.Fatalf("%s: expected %q, got %q", setting.Name, want[setting.Name], got)
+		}
+	}
+	if len(report.Settings) != len(want) {
+		t.Fatalf("expected %d settings, got %+v", len(want), report.Settings)
+	}
+	if strings.Join(report.EnvVarsSet, ",") != "CODEX_USAGE_MONITOR_CODEX_ENV_FEATURE,CODEX_USAGE_MONITOR_CONFIG_FILE" {
+		t.Fatalf("unexpected env vars: %v", report.EnvVarsSet)
+	}
+	if strings.Contains(stdout, "secret-value") {
+		t.Fatalf("config output must not include env var values:\n%s", stdout)
+	}
+}
+
+func TestLoadConfigDefaultsWarnsAndFailsOpen(t *testing.T) {
+	dir := t.TempDir()
+	broken := filepath.Join(dir, "broken.json")
+	if err := os.WriteFile(broken, []byte(`{"tui": `), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	fs := flag.NewFlagSet("tui", flag.ContinueOnError)
+	interval := fs.Duration("interval", time.Minute, "")
+	if warnings := loadConfigDefaults(fs, "tui", broken); len(warnings) != 1 || !strings.Contains(warnings[0], "ignoring config file") {
+		t.Fatalf("expected one parse warning, got %v", warnings)
+	}
+	if *interval != time.Minute {
+		t.Fatalf("expected the default to survive a broken config, got %s", *interval)
+	}
+
+	partial := filepath.Join(dir, "partial.json")
+	if err := os.WriteFile(partial, []byte(`{"tui": {"interval": "30s", "theme": "dark", "sort": 3}}`), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	warnings := loadConfigDefaults(fs, "tui", partial)
+	if *interval != 30*time.Second {
+		t.Fatalf("expected interval 30s from config, got %s", *interval)
+	}
+	if len(warnings) != 2 || !strings.Contains(warnings[0], "tui.sort: unknown flag") || !strings.Contains(warnings[1], "tui.theme: unknown flag") {
+		t.Fatalf("expected unknown-flag warnings, got %v", warnings)
+	}
+	if warnings := loadConfigDefaults(fs, "tui", filepath.Join(dir, "missing.json")); len(warnings) != 0 {
+		t.Fatalf("expected a missing config to be silent, got %v", warnings)
+	}
+}
+
+func TestRunTUIRejectsUnknownCountFormat(t *testing.T) {
+	code, _, stderr := runWithCapturedOutput(t, []string{"tui", "--count-format", "si"})
+	if code != 2 {
+		t.Fatalf("expected code 2 for unknown count format, got %d", code)
+	}
+	if !strings.Contains(stderr, "unsupported count format") {
+		t.Fatalf("expected unsupported count format error, got:\n%s", stderr)
+	}
+}
+
+func TestRunTUIRejectsUnknownSessionsScope(t *testing.T) {
+	code, _, stderr := runWithCapturedOutput(t, []string{"tui", "--sessions-scope", "recent"})
+	if code != 2 {
+		t.Fatalf("expected code 2 for unknown sessions scope, got %d", code)
+	}
+	if !strings.Contains(stderr, "unsupported sessions scope") {
+		t.Fatalf("expected unsupported sessions scope error, got:\n%s", stderr)
+	}
+}
+
+func TestRunTUIRejectsUnknownObservedMerge(t *testing.T) {
+	code, _, stderr := runWithCapturedOutput(t, []string{"tui", "--observed-merge", "avg"})
+	if code != 2 {
+		t.Fatalf("expected code 2 for unknown observed merge, got %d", code)
+	}
+	if !strings.Contains(stderr, "unsupported observed merge") {
+		t.Fatalf("expected unsupported observed merge error, got:\n%s", stderr)
+	}
+}
+
+func TestRunTUIRejectsNegativeMaxConsecutiveFailures(t *testing.T) {
+	code, _, stderr := runWithCapturedOutput(t, []string{"tui", "--max-consecutive-failures", "-1"})
+	if code != 2 {
+		t.Fatalf("expected code 2 for negative max failures, got %d", code)
+	}
+	if !strings.Contains(stderr, "--max-consecutive-failures must be >= 0") {
+		t.Fatalf("expected max failures validation error, got:\n%s", stderr)
+	}
+}
+
+func TestRunTUIRejectsAnnounceAndJSONLOnStderr(t *testing.T) {
+	code, _, stderr := runWithCapturedOutput(t, []string{"tui", "--announce", "-", "--jsonl", "-"})
+	if code != 2 {
+		t.Fatalf("expected code 2 for two streams on stderr, got %d", code)
+	}
+	if !strings.Contains(stderr, "cannot share stderr") {
+		t.Fatalf("expected shared stderr error, got:\n%s", stderr)
+	}
+}
+
+func TestRunTUIRejectsInvertedThresholds(t *testing.T) {
+	code, _, stderr := runWithCapturedOutput(t, []string{"tui", "--warn-at", "85", "--bad-at", "60"})
+	if code != 2 {
+		t.Fatalf("expected code 2 for --warn-at above --bad-at, got %d", code)
+	}
+	if !strings.Contains(stderr, "--warn-at (85) must be below --bad-at (60)") {
+		t.Fatalf("expected threshold ordering error, got:\n%s", stderr)
+	}
+}
+
+func TestRunTUIRejectsUnknownTokenField(t *testing.T) {
+	code, _, stderr := runWithCapturedOutput(t, []string{"tui", "--token-fields", "input,latency"})
+	if code != 2 {
+		t.Fatalf("expected code 2 for unknown token field, got %d", code)
+	}
+	if !strings.Contains(stderr, `unsupported token field "latency"`) {
+		t.Fatalf("expected unsupported token field error, got:\n%s", stderr)
+	}
+}
+
+func TestUseAltScreenHonorsCIEnv(t *testing.T) {
+	cases := []struct {
+		noAltScreen bool
+		ci          string
+		want        bool
+	}{
+		{ci: "", want: true},
+		{ci: "false", want: true},
+		{ci: "0", want: true},
+		{ci: "true", want: false},
+		{ci: "1", want: false},
+		{noAltScreen: true, ci: "", want: false},
+	}
+	for _, tc := range cases {
+		if got := useAltScreen(tc.noAltScreen, tc.ci); got != tc.want {
+			t.Fatalf("useAltScreen(%v, %q) = %v, want %v", tc.noAltScreen, tc.ci, got, tc.want)
+		}
+	}
+}
+
+// fakeTermEnv feeds TERM/COLORTERM to termenv's detection.
+type fakeTermEnv map[string]string
+
+func (e fakeTermEnv) Environ() []string {
+	var out []string
+	for k, v := range e {
+		out = append(out, k+"="+v)
+	}
+	return out
+}
+
+func (e fakeTermEnv) Getenv(key string) string { return e[key] }
+
+func TestUseBasicColorsDetectsTerminalCapability(t *testing.T) {
+	cases := []struct {
+		override, term, colorTerm string
+		want                      bool
+		warning                   string
+	}{
+		{term: "xterm-256color", want: false},
+		{term: "xterm", want: true},
+		{term: "linux", want: true},
+		{term: "xterm", colorTerm: "truecolor", want: false},
+		{term: "alacritty", want: false},
+		{term: "xterm-kitty", want: false},
+		{term: "wezterm", want: false},
+		{term: "tmux", want: false},
+		{term: "foot", want: false},
+		{term: "", want: false},
+		{override: "16", term: "xterm-256color", want: true},
+		{override: "256", term: "linux", want: false},
+		{override: "many", term: "linux", want: true, warning: `ignoring invalid CODEX_USAGE_MONITOR_COLORS="many"`},
+	}
+	for _, tc := range cases {
+		env := fakeTermEnv{"TERM": tc.term, "COLORTERM": tc.colorTerm}
+		profile := termenv.NewOutput(io.Discard, termenv.WithEnvironment(env), termenv.WithTTY(true)).EnvColorProfile()
+		got, warning := useBasicColors(tc.override, profile)
+		if got != tc.want {
+			t.Fatalf("useBasicColors(%q) with TERM=%q COLORTERM=%q = %v, want %v", tc.override, tc.term, tc.colorTerm, got, tc.want)
+		}
+		if !strings.Contains(warning, tc.warning) || (tc.warning == "") != (warning == "") {
+			t.Fatalf("useBasicColors(%q, ...) warning = %q, want %q", tc.override, warning, tc.warning)
+		}
+	}
+}
+
+func TestApplyIntervalFloor(t *testing.T) {
+	if got, warning := applyIntervalFloor(time.Minute, ""); got != time.Minute || warning != "" {
+		t.Fatalf("expected interval above floor to pass through, got %s %q", got, warning)
+	}
+	got, warning := applyIntervalFloor(time.Second, "")
+	if got != 5*time.Second || !strings.Contains(warning, "below the 5s minimum") {
+		t.Fatalf("expected interval raised to 5s with a warning, got %s %q", got, warning)
+	}
+	if got, warning := applyIntervalFloor(time.Second, "500ms"); got != time.Second || warning != "" {
+		t.Fatalf("expected env to lower the floor, got %s %q", got, warning)
+	}
+	got, warning = applyIntervalFloor(time.Second, "soon")
+	if got != 5*time.Second || !strings.Contains(warning, "ignoring invalid") {
+		t.Fatalf("expected invalid env to keep the default floor, got %s %q", got, warning)
+	}
+}
+
 func runWithCapturedOutput(t *testing.T, args []string) (int, string, string) {
 	t.Helper()
 	origStdout := os.Stdout
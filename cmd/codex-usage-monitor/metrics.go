@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/olliecrow/codex_usage_monitor/internal/usage"
+)
+
+func runMetrics(args []string) int {
+	fs := flag.NewFlagSet("metrics", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	timeout := fs.Duration("timeout", defaultDoctorTimeout, "fetch timeout")
+	applyConfigDefaults(fs, "metrics")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *timeout <= 0 {
+		fmt.Fprintln(os.Stderr, "error: --timeout must be > 0")
+		return 2
+	}
+	if err := usage.CheckHomeEnvironment(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+
+	fetcher := usage.NewSnapshotFetcher(usage.FetcherOptions{NoPrestart: true})
+	defer fetcher.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	summary, err := fetcher.Fetch(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+	}
+	if writeErr := writePrometheusMetrics(os.Stdout, summary); writeErr != nil {
+		fmt.Fprintf(os.Stderr, "error: failed to write metrics: %v\n", writeErr)
+		return 1
+	}
+	if err != nil {
+		return 1
+	}
+	return 0
+}
+
+type promSample struct {
+	labels [][2]string
+	value  float64
+}
+
+// writePrometheusMetrics renders summary in the Prometheus text exposition
+// format. A nil summary still writes codex_usage_up 0 so a textfile collector
+// sees the failure instead of stale values.
+func writePrometheusMetrics(w io.Writer, summary *usage.Summary) error {
+	var out strings.Builder
+	up := 0.0
+	if summary != nil {
+		up = 1
+	}
+	writePromMetric(&out, "codex_usage_up", "Whether the last usage fetch succeeded.", []promSample{{value: up}})
+	if summary == nil {
+		_, err := io.WriteString(w, out.String())
+		return err
+	}
+
+	var windows []promSample
+	if len(summary.Accounts) > 0 {
+		for _, account := range summary.Accounts {
+			if account.Error != "" {
+				continue
+			}
+			name := account.AccountEmail
+			if name == "" {
+				name = account.Label
+			}
+			windows = append(windows, promWindowSamples(name, account.PrimaryWindow, account.SecondaryWindow)...)
+		}
+	} else if summary.WindowDataAvailable {
+		windows = promWindowSamples(summary.AccountEmail, summary.PrimaryWindow, summary.SecondaryWindow)
+	}
+	writePromMetric(&out, "codex_usage_window_used_percent", "Percent of the subscription window already used.", windows)
+
+	var observed []promSample
+	if summary.ObservedTokens5h != nil {
+		observed = append(observed, promSample{labels: [][2]string{{"window", "5h"}}, value: float64(*summary.ObservedTokens5h)})
+	}
+	if summary.ObservedTokensWeekly != nil {
+		observed = append(observed, promSample{labels: [][2]string{{"window", "weekly"}}, value: float64(*summary.ObservedTokensWeekly)})
+	}
+	writePromMetric(&out, "codex_usage_observed_tokens_total", "Tokens seen in local session logs within the window.", observed)
+
+	total, successful := summary.TotalAccounts, summary.SuccessfulAccounts
+	if len(summary.Accounts) == 0 {
+		// The single-account path leaves the counts unset.
+		total, successful = 1, 1
+	}
+	writePromMetric(&out, "codex_usage_accounts_total", "Accounts the monitor fetched.", []promSample{{value: float64(total)}})
+	writePromMetric(&out, "codex_usage_accounts_successful", "Accounts whose fetch succeeded.", []promSample{{value: float64(successful)}})
+
+	_, err := io.WriteString(w, out.String())
+	return err
+}
+
+func promWindowSamples(account string, primary, secondary usage.WindowSummary) []promSample {
+	var samples []promSample
+	for _, window := range []struct {
+		name    string
+		summary usage.WindowSummary
+	}{{"5h", primary}, {"weekly", secondary}} {
+		labels := [][2]string{{"window", window.name}}
+		if account != "" {
+			labels = append(labels, [2]string{"account", account})
+		}
+		samples = append(samples, promSample{labels: labels, value: float64(window.summary.UsedPercent)})
+	}
+	return samples
+}
+
+// writePromMetric skips metrics without samples so absent data is not
+// reported as zero.
+func writePromMetric(out *strings.Builder, name, help string, samples []promSample) {
+	if len(samples) == 0 {
+		return
+	}
+	fmt.Fprintf(out, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(out, "# TYPE %s gauge\n", name)
+	for _, sample := range samples {
+		out.WriteString(name)
+		if len(sample.labels) > 0 {
+			parts := make([]string, 0, len(sample.labels))
+			for _, label := range sample.labels {
+				parts = append(parts, label[0]+`="`+promEscaper.Replace(label[1])+`"`)
+			}
+			out.WriteString("{" + strings.Join(parts, ",") + "}")
+		}
+		out.WriteString(" " + strconv.FormatFloat(sample.value, 'f', -1, 64) + "\n")
+	}
+}
+
+var promEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
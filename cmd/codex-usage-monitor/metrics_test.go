@@ -0,0 +1,56 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/olliecrow/codex_usage_monitor/internal/usage"
+)
+
+func TestWritePrometheusMetricsLabelsWindowsByAccount(t *testing.T) {
+	tokens5h, tokensWeekly := int64(1500000), int64(9000000)
+	summary := &usage.Summary{
+		TotalAccounts:        2,
+		SuccessfulAccounts:   1,
+		ObservedTokens5h:     &tokens5h,
+		ObservedTokensWeekly: &tokensWeekly,
+		Accounts: []usage.AccountSummary{
+			{Label: "work", AccountEmail: `dev"ops@example.com`, PrimaryWindow: usage.WindowSummary{UsedPercent: 42}, SecondaryWindow: usage.WindowSummary{UsedPercent: 7}},
+			{Label: "personal", Error: "auth required"},
+		},
+	}
+	var out strings.Builder
+	if err := writePrometheusMetrics(&out, summary); err != nil {
+		t.Fatalf("writePrometheusMetrics: %v", err)
+	}
+	got := out.String()
+	for _, want := range []string{
+		"# HELP codex_usage_window_used_percent ",
+		"# TYPE codex_usage_window_used_percent gauge\n",
+		`codex_usage_window_used_percent{window="5h",account="dev\"ops@example.com"} 42` + "\n",
+		`codex_usage_window_used_percent{window="weekly",account="dev\"ops@example.com"} 7` + "\n",
+		`codex_usage_observed_tokens_total{window="5h"} 1500000` + "\n",
+		`codex_usage_observed_tokens_total{window="weekly"} 9000000` + "\n",
+		"codex_usage_accounts_total 2\n",
+		"codex_usage_accounts_successful 1\n",
+		"codex_usage_up 1\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("expected %q in metrics, got:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "personal") {
+		t.Fatalf("did not expect a failed account in window gauges, got:\n%s", got)
+	}
+}
+
+func TestWritePrometheusMetricsReportsFailedFetch(t *testing.T) {
+	var out strings.Builder
+	if err := writePrometheusMetrics(&out, nil); err != nil {
+		t.Fatalf("writePrometheusMetrics: %v", err)
+	}
+	want := "# HELP codex_usage_up Whether the last usage fetch succeeded.\n# TYPE codex_usage_up gauge\ncodex_usage_up 0\n"
+	if out.String() != want {
+		t.Fatalf("unexpected failure output:\n%s", out.String())
+	}
+}
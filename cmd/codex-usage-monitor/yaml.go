@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// writeYAML renders v as block-style YAML. It goes through encoding/json so
+// field names, omitempty, and nil pointers match --format json exactly, and
+// keeps struct field order. Strings stay double-quoted JSON strings, which are
+// valid YAML scalars, so no value can be misread as another type.
+func writeYAML(w io.Writer, v any) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	dec := json.NewDecoder(&buf)
+	dec.UseNumber()
+	node, err := decodeOrderedJSON(dec)
+	if err != nil {
+		return err
+	}
+	var out strings.Builder
+	switch node.(type) {
+	case []yamlField, []any:
+		emitYAMLBlock(&out, node, 0)
+	default:
+		out.WriteString(yamlScalar(node) + "\n")
+	}
+	_, err = io.WriteString(w, out.String())
+	return err
+}
+
+type yamlField struct {
+	key   string
+	value any
+}
+
+// decodeOrderedJSON returns []yamlField for objects, []any for arrays, and
+// string, json.Number, bool, or nil for scalars.
+func decodeOrderedJSON(dec *json.Decoder) (any, error) {
+	token, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch token {
+	case json.Delim('{'):
+		fields := []yamlField{}
+		for dec.More() {
+			keyToken, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			key, _ := keyToken.(string)
+			value, err := decodeOrderedJSON(dec)
+			if err != nil {
+				return nil, err
+			}
+			fields = append(fields, yamlField{key: key, value: value})
+		}
+		_, err = dec.Token()
+		return fields, err
+	case json.Delim('['):
+		items := []any{}
+		for dec.More() {
+			item, err := decodeOrderedJSON(dec)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		_, err = dec.Token()
+		return items, err
+	default:
+		return token, nil
+	}
+}
+
+func emitYAMLBlock(out *strings.Builder, node any, indent int) {
+	pad := strings.Repeat("  ", indent)
+	switch v := node.(type) {
+	case []yamlField:
+		for _, field := range v {
+			out.WriteString(pad + yamlKey(field.key) + ":")
+			emitYAMLValue(out, field.value, indent+1)
+		}
+	case []any:
+		for _, item := range v {
+			if fields, ok := item.([]yamlField); ok && len(fields) > 0 {
+				// The first field shares the dash line; the rest align under it.
+				var nested strings.Builder
+				emitYAMLBlock(&nested, fields, indent+1)
+				out.WriteString(pad + "- " + strings.TrimPrefix(nested.String(), pad+"  "))
+				continue
+			}
+			out.WriteString(pad + "-")
+			emitYAMLValue(out, item, indent+1)
+		}
+	}
+}
+
+func emitYAMLValue(out *strings.Builder, value any, indent int) {
+	switch v := value.(type) {
+	case []yamlField:
+		if len(v) == 0 {
+			out.WriteString(" {}\n")
+			return
+		}
+		out.WriteString("\n")
+		emitYAMLBlock(out, v, indent)
+	case []any:
+		if len(v) == 0 {
+			out.WriteString(" []\n")
+			return
+		}
+		out.WriteString("\n")
+		emitYAMLBlock(out, v, indent)
+	default:
+		out.WriteString(" " + yamlScalar(v) + "\n")
+	}
+}
+
+func yamlScalar(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return fmt.Sprint(v)
+	case json.Number:
+		return v.String()
+	case string:
+		return yamlQuote(v)
+	default:
+		return yamlQuote(fmt.Sprint(v))
+	}
+}
+
+var plainYAMLKey = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+// yamlReservedKeys would read back as booleans or null under YAML 1.1.
+var yamlReservedKeys = map[string]bool{"y": true, "n": true, "yes": true, "no": true, "on": true, "off": true, "true": true, "false": true, "null": true}
+
+func yamlKey(key string) string {
+	if plainYAMLKey.MatchString(key) && !yamlReservedKeys[strings.ToLower(key)] {
+		return key
+	}
+	return yamlQuote(key)
+}
+
+func yamlQuote(s string) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
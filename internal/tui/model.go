@@ -4,6 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"runtime"
+	"strconv"
 	"strings"
 	"time"
 
@@ -17,11 +20,47 @@ import (
 type FetchFunc func(context.Context) (*usage.Summary, error)
 
 type Options struct {
-	Interval  time.Duration
-	Timeout   time.Duration
-	NoColor   bool
-	AltScreen bool
-	Fetch     FetchFunc
+	Interval     time.Duration
+	Timeout      time.Duration
+	NoColor      bool
+	NoSpinner    bool
+	AltScreen    bool
+	TimeFormat   string
+	RelativeTime bool
+	Fetch        FetchFunc
+	Accounts     []usage.MonitorAccount
+	Stats        func() usage.ResourceStats
+	ClearCache   func()
+	CountFormat  CountFormat
+	// RefreshOnFocus fetches immediately when the terminal regains focus.
+	RefreshOnFocus bool
+	// ShowRemaining swaps the window "used" line for the budget left.
+	ShowRemaining bool
+	// ShowBars adds an ASCII progress bar under each window's usage line.
+	ShowBars bool
+	// MaxConsecutiveFailures quits with an error after that many failed
+	// fetches in a row; 0 never gives up.
+	MaxConsecutiveFailures int
+	// TokenFields limits the observed breakdown lines; nil shows them all.
+	TokenFields TokenFields
+	// BurstThreshold is the five-hour to pro-rated weekly token ratio at which
+	// the token section reads "bursting"; 0 hides the badge.
+	BurstThreshold float64
+	// Thresholds sets the used percent colored warn and bad; the zero value
+	// uses DefaultThresholds.
+	Thresholds Thresholds
+	// Announce receives one plain line per state change (new percent, reset,
+	// fetch error or recovery) for screen readers; nil disables it.
+	Announce io.Writer
+	// ASCII draws panel borders, the spinner, and status glyphs with plain
+	// ASCII for terminals or fonts without box-drawing characters.
+	ASCII bool
+	// BasicColors uses the 16-color ANSI palette for terminals without
+	// 256-color support.
+	BasicColors bool
+	// Countdown picks how the header shows the next refresh; empty means
+	// relative.
+	Countdown CountdownMode
 }
 
 type Model struct {
@@ -32,7 +71,8 @@ type Model struct {
 	width  int
 	height int
 
-	now time.Time
+	now      time.Time
+	location *time.Location
 
 	fetching          bool
 	lastAttemptAt     time.Time
@@ -41,8 +81,44 @@ type Model struct {
 	lastError         string
 	nextFetchAt       time.Time
 
-	summary *usage.Summary
-	styles  styles
+	consecutiveFailures    int
+	maxConsecutiveFailures int
+	gaveUp                 bool
+	pollSeq                int
+	pollCount              int
+	failureCount           int
+
+	spinnerEnabled bool
+	spinnerActive  bool
+	spinnerFrame   int
+
+	timeFormat   string
+	relativeTime bool
+	countFormat  CountFormat
+	countdown    CountdownMode
+	tokenFields  TokenFields
+	noColor      bool
+	ascii        bool
+
+	burstThreshold float64
+	thresholds     Thresholds
+
+	showAccountTable bool
+	showEventLog     bool
+	events           []logEvent
+	refreshOnFocus   bool
+	showRemaining    bool
+	showBars         bool
+
+	statsFn   func() usage.ResourceStats
+	statsLine string
+
+	clearCache func()
+	announce   io.Writer
+
+	summary  *usage.Summary
+	accounts []usage.MonitorAccount
+	styles   styles
 }
 
 type styles struct {
@@ -63,12 +139,16 @@ type styles struct {
 
 type pollTickMsg struct {
 	at time.Time
+	// seq lets a rescheduled poll (backoff start or end) retire older ticks.
+	seq int
 }
 
 type clockTickMsg struct {
 	at time.Time
 }
 
+type spinnerTickMsg struct{}
+
 type fetchResultMsg struct {
 	at       time.Time
 	duration time.Duration
@@ -79,6 +159,17 @@ type fetchResultMsg struct {
 const (
 	defaultInterval = 60 * time.Second
 	defaultTimeout  = 10 * time.Second
+
+	spinnerTickInterval = 100 * time.Millisecond
+
+	defaultTimeFormat = "2006-01-02 15:04:05 UTC"
+	// TimeFormatUnix renders timestamps as unix seconds instead of a layout.
+	TimeFormatUnix = "unix"
+)
+
+var (
+	spinnerFrames      = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+	asciiSpinnerFrames = []string{"|", "/", "-", `\`}
 )
 
 func NewModel(opts Options) Model {
@@ -97,20 +188,79 @@ func NewModel(opts Options) Model {
 		}
 	}
 	now := time.Now().UTC()
+	// Animation is pointless (and noisy when output is captured) without color,
+	// and its redraws drown out announcements for screen readers.
+	spinnerEnabled := !opts.NoColor && !opts.NoSpinner && opts.Announce == nil
+	timeFormat := strings.TrimSpace(opts.TimeFormat)
+	if timeFormat == "" {
+		timeFormat = defaultTimeFormat
+	}
+	thresholds := opts.Thresholds
+	if thresholds == (Thresholds{}) {
+		thresholds = DefaultThresholds
+	}
+
+	m := Model{
+		interval:               interval,
+		timeout:                timeout,
+		fetch:                  fetch,
+		now:                    now,
+		location:               time.UTC,
+		fetching:               true,
+		nextFetchAt:            now.Add(interval),
+		spinnerEnabled:         spinnerEnabled,
+		spinnerActive:          spinnerEnabled,
+		timeFormat:             timeFormat,
+		relativeTime:           opts.RelativeTime,
+		countFormat:            opts.CountFormat,
+		countdown:              opts.Countdown,
+		tokenFields:            opts.TokenFields,
+		burstThreshold:         opts.BurstThreshold,
+		thresholds:             thresholds,
+		noColor:                opts.NoColor,
+		refreshOnFocus:         opts.RefreshOnFocus,
+		showRemaining:          opts.ShowRemaining,
+		showBars:               opts.ShowBars,
+		accounts:               opts.Accounts,
+		statsFn:                opts.Stats,
+		clearCache:             opts.ClearCache,
+		announce:               opts.Announce,
+		ascii:                  opts.ASCII,
+		styles:                 defaultStyles(opts.NoColor, opts.BasicColors, opts.ASCII),
+		maxConsecutiveFailures: opts.MaxConsecutiveFailures,
+	}
+	m.statsLine = m.sampleStats()
+	return m
+}
 
-	return Model{
-		interval:    interval,
-		timeout:     timeout,
-		fetch:       fetch,
-		now:         now,
-		fetching:    true,
-		nextFetchAt: now.Add(interval),
-		styles:      defaultStyles(opts.NoColor),
-	}
+// palette holds the foreground colors for each style; title also uses titleBG.
+type palette struct {
+	title, titleBG, dim, border, label, value lipgloss.Color
+	ok, warn, bad, accent, error, help, mono  lipgloss.Color
+	loading                                   lipgloss.Color
 }
 
-func defaultStyles(noColor bool) styles {
-	basePanel := lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).Padding(0, 1)
+var (
+	palette256 = palette{
+		title: "230", titleBG: "24", dim: "245", border: "61", label: "109", value: "255",
+		ok: "42", warn: "214", bad: "196", accent: "81", error: "203", help: "245", mono: "252",
+		loading: "117",
+	}
+	// palette16 sticks to the basic ANSI colors, which every color terminal
+	// renders, instead of letting 256-color codes degrade to near matches.
+	palette16 = palette{
+		title: "15", titleBG: "4", dim: "8", border: "4", label: "6", value: "15",
+		ok: "2", warn: "3", bad: "1", accent: "14", error: "9", help: "8", mono: "7",
+		loading: "12",
+	}
+)
+
+func defaultStyles(noColor, basicColors, ascii bool) styles {
+	border := lipgloss.RoundedBorder()
+	if ascii {
+		border = lipgloss.ASCIIBorder()
+	}
+	basePanel := lipgloss.NewStyle().Border(border).Padding(0, 1)
 	if noColor {
 		return styles{
 			title:   lipgloss.NewStyle().Bold(true),
@@ -128,78 +278,402 @@ func defaultStyles(noColor bool) styles {
 			loading: lipgloss.NewStyle(),
 		}
 	}
+	p := palette256
+	if basicColors {
+		p = palette16
+	}
 	return styles{
-		title:   lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("230")).Background(lipgloss.Color("24")).Padding(0, 1),
-		dim:     lipgloss.NewStyle().Foreground(lipgloss.Color("245")),
-		panel:   basePanel.BorderForeground(lipgloss.Color("61")),
-		label:   lipgloss.NewStyle().Foreground(lipgloss.Color("109")),
-		value:   lipgloss.NewStyle().Foreground(lipgloss.Color("255")),
-		ok:      lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("42")),
-		warn:    lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("214")),
-		bad:     lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("196")),
-		accent:  lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("81")),
-		error:   lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("203")),
-		help:    lipgloss.NewStyle().Foreground(lipgloss.Color("245")),
-		mono:    lipgloss.NewStyle().Foreground(lipgloss.Color("252")),
-		loading: lipgloss.NewStyle().Foreground(lipgloss.Color("117")),
+		title:   lipgloss.NewStyle().Bold(true).Foreground(p.title).Background(p.titleBG).Padding(0, 1),
+		dim:     lipgloss.NewStyle().Foreground(p.dim),
+		panel:   basePanel.BorderForeground(p.border),
+		label:   lipgloss.NewStyle().Foreground(p.label),
+		value:   lipgloss.NewStyle().Foreground(p.value),
+		ok:      lipgloss.NewStyle().Bold(true).Foreground(p.ok),
+		warn:    lipgloss.NewStyle().Bold(true).Foreground(p.warn),
+		bad:     lipgloss.NewStyle().Bold(true).Foreground(p.bad),
+		accent:  lipgloss.NewStyle().Bold(true).Foreground(p.accent),
+		error:   lipgloss.NewStyle().Bold(true).Foreground(p.error),
+		help:    lipgloss.NewStyle().Foreground(p.help),
+		mono:    lipgloss.NewStyle().Foreground(p.mono),
+		loading: lipgloss.NewStyle().Foreground(p.loading),
 	}
 }
 
 func (m Model) Init() tea.Cmd {
-	return tea.Batch(fetchCmd(m.fetch, m.timeout), pollCmd(m.interval), clockCmd())
+	cmds := []tea.Cmd{fetchCmd(m.fetch, m.timeout), pollCmd(m.interval, m.pollSeq), clockCmd()}
+	if m.spinnerActive {
+		cmds = append(cmds, spinnerCmd())
+	}
+	return tea.Batch(cmds...)
 }
 
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch v := msg.(type) {
 	case tea.KeyMsg:
 		switch v.String() {
-		case "ctrl+c":
+		case "ctrl+c", "q", "esc":
 			return m, tea.Quit
+		case "t":
+			m.showAccountTable = !m.showAccountTable
+		case "l":
+			m.showEventLog = !m.showEventLog
+		case "r":
+			return m.manualRefresh()
+		case "R":
+			return m.hardRefresh()
+		}
+	case tea.FocusMsg:
+		// Only sent when focus reporting is on and the terminal supports it.
+		if m.refreshOnFocus {
+			return m.startFetch()
 		}
 	case tea.WindowSizeMsg:
 		m.width = v.Width
 		m.height = v.Height
 	case pollTickMsg:
-		m.nextFetchAt = v.at.UTC().Add(m.interval)
-		cmds := []tea.Cmd{pollCmd(m.interval)}
+		if v.seq != m.pollSeq {
+			return m, nil
+		}
+		interval := m.pollInterval()
+		m.nextFetchAt = v.at.UTC().Add(interval)
+		cmds := []tea.Cmd{pollCmd(interval, m.pollSeq)}
 		if !m.fetching {
 			m.fetching = true
 			cmds = append(cmds, fetchCmd(m.fetch, m.timeout))
+			if m.spinnerEnabled && !m.spinnerActive {
+				m.spinnerActive = true
+				cmds = append(cmds, spinnerCmd())
+			}
 		}
 		return m, tea.Batch(cmds...)
 	case clockTickMsg:
 		m.now = v.at.UTC()
+		m.statsLine = m.sampleStats()
 		return m, clockCmd()
-	case fetchResultMsg:
-		m.fetching = false
-		m.lastAttemptAt = v.at.UTC()
-		m.lastFetchDuration = v.duration
-		if v.err != nil {
-			m.lastError = v.err.Error()
+	case spinnerTickMsg:
+		if !m.fetching {
+			m.spinnerActive = false
 			return m, nil
 		}
-		m.lastError = ""
-		m.lastSuccessAt = v.at.UTC()
-		m.summary = v.summary
-		return m, nil
+		m.spinnerFrame = (m.spinnerFrame + 1) % len(spinnerFrames)
+		return m, spinnerCmd()
+	case fetchResultMsg:
+		prevSummary, prevError := m.summary, m.lastError
+		cmd := m.applyFetchResult(v)
+		m.recordEvents(v.at, prevSummary, prevError)
+		m.announceChange(v.at, prevSummary, prevError)
+		return m, cmd
 	}
 	return m, nil
 }
 
+func (m *Model) applyFetchResult(v fetchResultMsg) tea.Cmd {
+	m.fetching = false
+	m.lastAttemptAt = v.at.UTC()
+	m.lastFetchDuration = v.duration
+	wasBackingOff := m.backingOff()
+	m.pollCount++
+	if v.err != nil {
+		m.lastError = v.err.Error()
+		m.failureCount++
+		m.consecutiveFailures++
+		if m.maxConsecutiveFailures > 0 && m.consecutiveFailures >= m.maxConsecutiveFailures {
+			m.gaveUp = true
+			return tea.Quit
+		}
+		if !m.backingOff() {
+			return nil
+		}
+		return m.reschedulePoll(v.at)
+	}
+	m.lastError = ""
+	m.lastSuccessAt = v.at.UTC()
+	m.summary = v.summary
+	m.consecutiveFailures = 0
+	if wasBackingOff {
+		return m.reschedulePoll(v.at)
+	}
+	return nil
+}
+
+const (
+	// eventLogCapacity bounds the session event log.
+	eventLogCapacity = 50
+	// eventLogMaxRows is the most events the pane shows at once.
+	eventLogMaxRows = 5
+)
+
+type logEvent struct {
+	at   time.Time
+	text string
+}
+
+// recordEvents appends what the latest fetch changed to the event log: fetch
+// failures and recoveries, identity changes, accounts added or removed, and
+// window resets. Routine successful polls are not logged.
+func (m *Model) recordEvents(at time.Time, prev *usage.Summary, prevError string) {
+	var texts []string
+	switch {
+	case m.lastError != "" && m.lastError != prevError:
+		texts = append(texts, "fetch failed: "+m.lastError)
+	case m.lastError == "" && prevError != "":
+		texts = append(texts, "fetch succeeded after failure")
+	case m.lastError == "" && prev == nil && m.summary != nil:
+		texts = append(texts, "first fetch succeeded")
+	}
+	if m.lastError == "" && prev != nil && m.summary != nil {
+		texts = append(texts, summaryEvents(prev, m.summary)...)
+	}
+	for _, text := range texts {
+		m.events = append(m.events, logEvent{at: at, text: text})
+	}
+	if len(m.events) > eventLogCapacity {
+		m.events = append([]logEvent(nil), m.events[len(m.events)-eventLogCapacity:]...)
+	}
+}
+
+func summaryEvents(prev, next *usage.Summary) []string {
+	var out []string
+	if prev.AccountEmail != next.AccountEmail && prev.AccountEmail != "" && next.AccountEmail != "" {
+		out = append(out, fmt.Sprintf("signed-in account changed: %s -> %s", prev.AccountEmail, next.AccountEmail))
+	}
+	before := map[string]bool{}
+	for _, account := range prev.Accounts {
+		before[account.Label] = true
+	}
+	after := map[string]bool{}
+	for _, account := range next.Accounts {
+		after[account.Label] = true
+		if !before[account.Label] {
+			out = append(out, "account added: "+account.Label)
+		}
+	}
+	for _, account := range prev.Accounts {
+		if !after[account.Label] {
+			out = append(out, "account removed: "+account.Label)
+		}
+	}
+	if prev.WindowDataAvailable && next.WindowDataAvailable {
+		if next.PrimaryWindow.UsedPercent < prev.PrimaryWindow.UsedPercent && resetMoved(prev.PrimaryWindow, next.PrimaryWindow) {
+			out = append(out, "5h window reset")
+		}
+		if next.SecondaryWindow.UsedPercent < prev.SecondaryWindow.UsedPercent && resetMoved(prev.SecondaryWindow, next.SecondaryWindow) {
+			out = append(out, "weekly window reset")
+		}
+	}
+	return out
+}
+
+// renderEventLog draws the newest events, oldest first, in a panel that takes
+// at most a third of the viewport; it returns "" when even one row won't fit.
+func (m Model) renderEventLog(contentWidth int) string {
+	rows := min(eventLogMaxRows, m.height/3-verticalOverhead(m.styles.panel))
+	if rows < 1 {
+		return ""
+	}
+	lines := []string{m.styles.label.Render("event log (l hides)")}
+	if len(m.events) == 0 {
+		lines = append(lines, m.styles.dim.Render("no events yet"))
+	}
+	rows--
+	start := max(0, len(m.events)-rows)
+	for _, event := range m.events[start:] {
+		lines = append(lines, m.styles.dim.Render(m.formatClock(event.at))+" "+event.text)
+	}
+	maxWidth := max(8, contentWidth-4)
+	for i := range lines {
+		lines[i] = ansi.Truncate(lines[i], maxWidth, "...")
+	}
+	return m.styles.panel.Width(contentWidth).Render(strings.Join(lines, "\n"))
+}
+
+func (m Model) formatClock(t time.Time) string {
+	loc := m.location
+	if loc == nil {
+		loc = time.UTC
+	}
+	return t.In(loc).Format("15:04:05")
+}
+
+// announceChange writes a line describing what the latest fetch changed,
+// once m holds the new state.
+func (m *Model) announceChange(at time.Time, prevSummary *usage.Summary, prevError string) {
+	if m.announce == nil {
+		return
+	}
+	text := describeChange(prevSummary, m.summary, prevError, m.lastError)
+	if text == "" {
+		return
+	}
+	fmt.Fprintf(m.announce, "%s %s\n", at.In(m.location).Format("15:04"), text)
+}
+
+// describeChange summarizes the difference between two states in plain words,
+// or returns "" when nothing worth announcing changed.
+func describeChange(prev, next *usage.Summary, prevError, nextError string) string {
+	var parts []string
+	switch {
+	case nextError != "" && nextError != prevError:
+		return "fetch failed: " + nextError
+	case nextError != "":
+		return ""
+	case prevError != "":
+		parts = append(parts, "fetch recovered")
+	}
+	if next == nil {
+		return strings.Join(parts, "; ")
+	}
+	if !next.WindowDataAvailable {
+		if prev == nil || prev.WindowDataAvailable {
+			parts = append(parts, "usage windows unavailable")
+		}
+		return strings.Join(parts, "; ")
+	}
+	for _, window := range []struct {
+		name       string
+		prev, next usage.WindowSummary
+	}{
+		{"5h", prevWindow(prev, true), next.PrimaryWindow},
+		{"weekly", prevWindow(prev, false), next.SecondaryWindow},
+	} {
+		switch {
+		case prev == nil || !prev.WindowDataAvailable:
+			parts = append(parts, fmt.Sprintf("%s %d%%", window.name, window.next.UsedPercent))
+		case window.next.UsedPercent < window.prev.UsedPercent && resetMoved(window.prev, window.next):
+			parts = append(parts, fmt.Sprintf("%s window reset, now %d%%", window.name, window.next.UsedPercent))
+		case window.next.UsedPercent != window.prev.UsedPercent:
+			parts = append(parts, fmt.Sprintf("%s %d%% (was %d%%)", window.name, window.next.UsedPercent, window.prev.UsedPercent))
+		}
+	}
+	return strings.Join(parts, "; ")
+}
+
+func prevWindow(summary *usage.Summary, primary bool) usage.WindowSummary {
+	switch {
+	case summary == nil:
+		return usage.WindowSummary{}
+	case primary:
+		return summary.PrimaryWindow
+	default:
+		return summary.SecondaryWindow
+	}
+}
+
+func resetMoved(prev, next usage.WindowSummary) bool {
+	if prev.ResetsAt == nil || next.ResetsAt == nil {
+		return true
+	}
+	return !prev.ResetsAt.Equal(*next.ResetsAt)
+}
+
+// hardRefresh is a debugging aid: it drops cached observed-token estimates and
+// starts a fetch immediately instead of waiting for the next poll.
+func (m Model) hardRefresh() (tea.Model, tea.Cmd) {
+	if m.clearCache != nil {
+		m.clearCache()
+	}
+	return m.startFetch()
+}
+
+// manualRefresh fetches now and restarts the poll timer from this moment, so
+// the next automatic poll is a full interval away.
+func (m Model) manualRefresh() (tea.Model, tea.Cmd) {
+	if m.fetching {
+		return m, nil
+	}
+	poll := m.reschedulePoll(m.now)
+	next, fetch := m.startFetch()
+	return next, tea.Batch(fetch, poll)
+}
+
+// startFetch begins an out-of-band fetch unless one is already running.
+func (m Model) startFetch() (tea.Model, tea.Cmd) {
+	if m.fetching {
+		return m, nil
+	}
+	m.fetching = true
+	cmds := []tea.Cmd{fetchCmd(m.fetch, m.timeout)}
+	if m.spinnerEnabled && !m.spinnerActive {
+		m.spinnerActive = true
+		cmds = append(cmds, spinnerCmd())
+	}
+	return m, tea.Batch(cmds...)
+}
+
 func (m Model) View() string {
 	if m.width <= 0 || m.height <= 0 {
 		return "initializing..."
 	}
 
 	header := m.renderHeader()
-	body := m.renderBody()
-	exitHint := m.styles.dim.Render("Ctrl+C to exit")
+	logPanel := ""
+	bodyModel := m
+	if m.showEventLog {
+		logPanel = m.renderEventLog(max(20, m.width-4))
+		bodyModel.height -= lipgloss.Height(logPanel)
+	}
+	body := bodyModel.renderBody()
+	if logPanel != "" {
+		body = lipgloss.JoinVertical(lipgloss.Left, body, logPanel)
+	}
+	hint := "q to exit | r to refresh | l log"
+	if m.summary != nil && len(m.summary.Accounts) > 0 {
+		hint += " | t toggles account table"
+	}
+	if polls := m.pollSummary(); polls != "" {
+		hint += " | " + polls
+	}
+	exitHint := m.styles.dim.Render(hint)
+	if m.statsLine != "" {
+		exitHint = joinWithPaddingKeepRight(exitHint, m.styles.dim.Render(m.statsLine), m.width)
+	}
 
 	top := lipgloss.JoinVertical(lipgloss.Left, header, body, "")
 	combined := pinFooterToBottom(top, exitHint, m.height)
 	return clipToViewport(combined, m.width, m.height)
 }
 
+// pollSummary is the session-cumulative fetch reliability, e.g.
+// "polls: 120, failures: 3 (97.5%)".
+func (m Model) pollSummary() string {
+	if m.pollCount == 0 {
+		return ""
+	}
+	successRate := float64(m.pollCount-m.failureCount) * 100 / float64(m.pollCount)
+	rate := strconv.FormatFloat(successRate, 'f', 1, 64)
+	rate = strings.TrimSuffix(rate, ".0")
+	return fmt.Sprintf("polls: %d, failures: %d (%s%%)", m.pollCount, m.failureCount, rate)
+}
+
+// sampleStats formats the --show-stats footer; it runs on the clock tick
+// because ReadMemStats briefly stops the world.
+func (m Model) sampleStats() string {
+	if m.statsFn == nil {
+		return ""
+	}
+	stats := m.statsFn()
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	return fmt.Sprintf("app-server %d | goroutines %d | heap %s | cache %d | fetches %d (fallback %d, failed %d)",
+		stats.AppServerSessions, runtime.NumGoroutine(), humanBytes(mem.HeapAlloc), stats.ObservedCacheEntries,
+		stats.Fetches.Fetches, stats.Fetches.FallbackSuccesses, stats.Fetches.Failures)
+}
+
+func humanBytes(v uint64) string {
+	const unit = 1024
+	if v < unit {
+		return fmt.Sprintf("%dB", v)
+	}
+	value := float64(v)
+	suffixes := []string{"KiB", "MiB", "GiB", "TiB"}
+	i := -1
+	for value >= unit && i < len(suffixes)-1 {
+		value /= unit
+		i++
+	}
+	return fmt.Sprintf("%.1f%s", value, suffixes[i])
+}
+
 func (m Model) renderHeader() string {
 	title := m.styles.title.Render(" codex usage monitor ")
 
@@ -217,22 +691,59 @@ func (m Model) renderHeader() string {
 	}
 
 	left := title + "  " + m.styles.label.Render("state: ") + stateStyle.Render(stateText)
-	if !m.nextFetchAt.IsZero() {
-		refreshText := "[next refresh in " + humanDuration(m.nextFetchAt.Sub(m.now)) + "]"
+	if spinner := m.spinnerGlyph(); spinner != "" {
+		left += " " + m.styles.loading.Render(spinner)
+	}
+	if refreshText := m.refreshIndicator(); refreshText != "" {
 		left += " " + m.styles.dim.Render(refreshText)
 	}
-	right := m.styles.dim.Render("utc " + m.now.Format("2006-01-02 15:04:05"))
+	right := m.styles.dim.Render(m.headerClock())
 	line1 := joinWithPaddingKeepRight(left, right, m.width)
 	return line1
 }
 
+// refreshIndicator describes the next poll. CountdownOff hides the routine
+// countdown but keeps the backoff notice, which signals repeated failures.
+func (m Model) refreshIndicator() string {
+	if m.nextFetchAt.IsZero() {
+		return ""
+	}
+	when := "in " + humanDuration(m.nextFetchAt.Sub(m.now))
+	if m.countdown == CountdownAbsolute {
+		loc := m.location
+		if loc == nil {
+			loc = time.UTC
+		}
+		when = "at " + m.nextFetchAt.In(loc).Format("15:04:05")
+	}
+	if m.backingOff() {
+		return "[retrying " + when + " (backoff)]"
+	}
+	if m.countdown == CountdownOff {
+		return ""
+	}
+	return "[next refresh " + when + "]"
+}
+
+// headerClock labels the clock with the zone it is shown in rather than
+// assuming UTC.
+func (m Model) headerClock() string {
+	loc := m.location
+	if loc == nil {
+		loc = time.UTC
+	}
+	now := m.now.In(loc)
+	zone, _ := now.Zone()
+	return strings.ToLower(zone) + " " + now.Format("2006-01-02 15:04:05")
+}
+
 func (m Model) renderBody() string {
 	if m.summary == nil {
 		if m.lastError != "" {
 			msg := m.styles.error.Render("last error: " + m.lastError)
 			return m.styles.panel.Width(max(20, m.width-4)).Render(msg)
 		}
-		return m.styles.panel.Width(max(20, m.width-4)).Render(m.styles.loading.Render("loading usage data..."))
+		return m.renderSkeletonBody()
 	}
 
 	contentWidth := max(20, m.width-4)
@@ -253,29 +764,48 @@ func (m Model) renderBody() string {
 			windowPanelSpec{title: weeklyTitle, window: m.summary.SecondaryWindow, available: m.summary.WindowDataAvailable},
 		),
 	}
-	for _, account := range m.additionalAccountWindowRows() {
-		available := accountWindowAvailable(account)
-		windowRows = append(windowRows, m.renderWindowRow(
-			contentWidth,
-			windowPanelSpec{title: windowPanelTitle("five-hour window", account), window: account.PrimaryWindow, available: available},
-			windowPanelSpec{title: windowPanelTitle("weekly window", account), window: account.SecondaryWindow, available: available},
-		))
-	}
 	panelVerticalOverhead := verticalOverhead(m.styles.panel)
-	windowRows = fitWindowRowsToViewport(windowRows, m.height, panelVerticalOverhead)
+	// The layout math assumes the default five breakdown lines per window;
+	// extra or hidden lines shift the space left for account and status rows.
+	extras := m.breakdownExtras()
+	layoutHeight := m.height - 2*(m.breakdownLineCount(extras)-observedBreakdownBaseLines)
+	projection := m.renderProjectionLine()
+	if projection != "" {
+		layoutHeight--
+	}
+	if m.showAccountTable {
+		tableRows := accountTableRowsForLayout(layoutHeight, lipgloss.Height(windowRows[0]), panelVerticalOverhead)
+		if table := m.renderAccountTable(contentWidth, tableRows); table != "" {
+			windowRows = append(windowRows, table)
+		}
+	} else {
+		for _, account := range m.additionalAccountWindowRows() {
+			available := accountWindowAvailable(account)
+			windowRows = append(windowRows, m.renderWindowRow(
+				contentWidth,
+				windowPanelSpec{title: windowPanelTitle("five-hour window", account), window: account.PrimaryWindow, available: available},
+				windowPanelSpec{title: windowPanelTitle("weekly window", account), window: account.SecondaryWindow, available: available},
+			))
+		}
+		windowRows = fitWindowRowsToViewport(windowRows, layoutHeight, panelVerticalOverhead)
+	}
 	windowsBlock := lipgloss.JoinVertical(lipgloss.Left, windowRows...)
 
 	metaLines := []string{}
 	maxMetaWidth := max(8, contentWidth-4)
 	windowsHeight := lipgloss.Height(windowsBlock)
-	statusRows := statusRowsForLayout(m.height, windowsHeight, panelVerticalOverhead)
+	statusRows := statusRowsForLayout(layoutHeight, windowsHeight, panelVerticalOverhead)
 	visibleStatusRows := min(4, statusRows)
 
 	metaLines = append(metaLines, m.renderAccountsLine(maxMetaWidth))
-	metaLines = append(metaLines, m.renderObservedHeaderLine("five-hour tokens", m.summary.ObservedWindow5h, m.summary.ObservedTokens5h))
-	metaLines = append(metaLines, m.renderObservedBreakdownLinesFixed(m.summary.ObservedWindow5h, m.summary.ObservedTokens5h)...)
-	metaLines = append(metaLines, m.renderObservedHeaderLine("weekly tokens", m.summary.ObservedWindowWeekly, m.summary.ObservedTokensWeekly))
-	metaLines = append(metaLines, m.renderObservedBreakdownLinesFixed(m.summary.ObservedWindowWeekly, m.summary.ObservedTokensWeekly)...)
+	primaryWindow, secondaryWindow := m.observedWindows()
+	metaLines = append(metaLines, m.renderObservedHeaderLine(windowTokensLabel(primaryWindow, defaultPrimaryWindow, "five-hour"), m.summary.ObservedWindow5h, m.summary.ObservedTokens5h)+m.renderBurstBadge())
+	metaLines = append(metaLines, m.renderObservedBreakdownLinesFixed(m.summary.ObservedWindow5h, m.summary.ObservedTokens5h, extras)...)
+	if projection != "" {
+		metaLines = append(metaLines, projection)
+	}
+	metaLines = append(metaLines, m.renderObservedHeaderLine(windowTokensLabel(secondaryWindow, defaultSecondaryWindow, "weekly"), m.summary.ObservedWindowWeekly, m.summary.ObservedTokensWeekly))
+	metaLines = append(metaLines, m.renderObservedBreakdownLinesFixed(m.summary.ObservedWindowWeekly, m.summary.ObservedTokensWeekly, extras)...)
 	metaLines = append(metaLines, m.renderStatusLinesFixed(visibleStatusRows)...)
 	for i := 0; i < statusRows-visibleStatusRows; i++ {
 		metaLines = append(metaLines, "")
@@ -288,6 +818,43 @@ func (m Model) renderBody() string {
 	return lipgloss.JoinVertical(lipgloss.Left, windowsBlock, metaPanel)
 }
 
+// renderSkeletonBody is the first paint before any fetch completes: placeholder
+// window cards plus the configured account list, which is known up front.
+func (m Model) renderSkeletonBody() string {
+	contentWidth := max(20, m.width-4)
+	windowsBlock := m.renderWindowRow(
+		contentWidth,
+		windowPanelSpec{title: "five-hour window", loading: true},
+		windowPanelSpec{title: "weekly window", loading: true},
+	)
+	maxMetaWidth := max(8, contentWidth-4)
+	loadingText := "loading usage data..."
+	if spinner := m.spinnerGlyph(); spinner != "" {
+		loadingText = spinner + " " + loadingText
+	}
+	metaLines := []string{
+		m.styles.loading.Render(loadingText),
+		m.renderConfiguredAccountsLine(maxMetaWidth),
+	}
+	metaPanel := m.styles.panel.Width(contentWidth).Render(strings.Join(metaLines, "\n"))
+	return lipgloss.JoinVertical(lipgloss.Left, windowsBlock, metaPanel)
+}
+
+func (m Model) renderConfiguredAccountsLine(maxWidth int) string {
+	labels := make([]string, 0, len(m.accounts))
+	for _, account := range m.accounts {
+		if label := strings.TrimSpace(account.Label); label != "" {
+			labels = append(labels, label)
+		}
+	}
+	if len(labels) == 0 {
+		labels = append(labels, "none")
+	}
+	value := fmt.Sprintf("%d configured [%s]", len(m.accounts), strings.Join(labels, ", "))
+	line := m.styles.label.Render("accounts: ") + m.styles.value.Render(value)
+	return ansi.Truncate(line, maxWidth, "...")
+}
+
 func (m Model) renderWindowRow(contentWidth int, left, right windowPanelSpec) string {
 	leftPanelWidth := contentWidth
 	rightPanelWidth := contentWidth
@@ -297,33 +864,51 @@ func (m Model) renderWindowRow(contentWidth int, left, right windowPanelSpec) st
 		spacer := strings.Repeat(" ", spacerWidth)
 		leftPanelWidth = panelWidth
 		rightPanelWidth = panelWidth
-		leftPanel := m.renderWindowPanel(left.title, left.window, leftPanelWidth, left.available)
-		rightPanel := m.renderWindowPanel(right.title, right.window, rightPanelWidth, right.available)
+		leftPanel := m.renderWindowSpec(left, leftPanelWidth)
+		rightPanel := m.renderWindowSpec(right, rightPanelWidth)
 		return lipgloss.JoinHorizontal(lipgloss.Top, leftPanel, spacer, rightPanel)
 	}
-	leftPanel := m.renderWindowPanel(left.title, left.window, leftPanelWidth, left.available)
-	rightPanel := m.renderWindowPanel(right.title, right.window, rightPanelWidth, right.available)
+	leftPanel := m.renderWindowSpec(left, leftPanelWidth)
+	rightPanel := m.renderWindowSpec(right, rightPanelWidth)
 	return lipgloss.JoinVertical(lipgloss.Left, leftPanel, "", rightPanel)
 }
 
+func (m Model) renderWindowSpec(spec windowPanelSpec, maxWidth int) string {
+	if spec.loading {
+		return m.renderPlaceholderWindowPanel(spec.title, "loading", m.styles.loading, maxWidth)
+	}
+	return m.renderWindowPanel(spec.title, spec.window, maxWidth, spec.available)
+}
+
+func (m Model) renderPlaceholderWindowPanel(title, state string, stateStyle lipgloss.Style, maxWidth int) string {
+	lines := []string{
+		m.styles.accent.Render(title),
+		m.styles.label.Render("used: ") + stateStyle.Render(state),
+		m.renderResetLine(state, state),
+	}
+	if m.showBars {
+		lines = append(lines, m.renderPercentBar(0, maxWidth, m.styles.dim))
+	}
+	for i := range lines {
+		lines[i] = ansi.Truncate(lines[i], max(4, maxWidth), "...")
+	}
+	return m.styles.panel.Width(max(20, maxWidth)).Render(strings.Join(lines, "\n"))
+}
+
 func (m Model) renderWindowPanel(title string, win usage.WindowSummary, maxWidth int, available bool) string {
 	if !available {
-		lines := []string{
-			m.styles.accent.Render(title),
-			m.styles.label.Render("used: ") + m.styles.bad.Render("unavailable"),
-			m.renderResetLine("unavailable", "unavailable"),
-		}
-		for i := range lines {
-			lines[i] = ansi.Truncate(lines[i], max(4, maxWidth), "...")
-		}
-		return m.styles.panel.Width(max(20, maxWidth)).Render(strings.Join(lines, "\n"))
+		return m.renderPlaceholderWindowPanel(title, "unavailable", m.styles.bad, maxWidth)
 	}
 
-	statusStyle := percentStyle(win.UsedPercent, m.styles)
+	statusStyle := percentStyle(win.UsedPercent, m.thresholds, m.styles)
+	stale, staleAge := m.staleSummary()
+	if stale {
+		statusStyle = m.styles.dim
+	}
 
 	reset := "unknown"
 	if win.ResetsAt != nil {
-		reset = win.ResetsAt.Format("2006-01-02 15:04:05 UTC")
+		reset = m.formatTimestamp(*win.ResetsAt)
 	}
 	remaining := "unknown"
 	if win.SecondsUntilReset != nil {
@@ -334,10 +919,30 @@ func (m Model) renderWindowPanel(title string, win usage.WindowSummary, maxWidth
 		}
 	}
 
+	resetLine := m.renderResetLine(reset, remaining)
+	if m.relativeTime {
+		resetLine = m.renderRelativeResetLine(win)
+	}
+
+	usageLine := m.styles.label.Render("used: ") + statusStyle.Render(m.formatWindowUsed(win))
+	if m.showRemaining {
+		// Same thresholds as "used", so a low remaining budget turns red.
+		usageLine = m.styles.label.Render("remaining: ") + statusStyle.Render(m.formatWindowRemaining(win))
+	}
+	if stale {
+		usageLine += " " + m.styles.warn.Render("[stale "+staleAge+"]")
+	}
 	lines := []string{
 		m.styles.accent.Render(title),
-		m.styles.label.Render("used: ") + statusStyle.Render(fmt.Sprintf("%d%%", win.UsedPercent)),
-		m.renderResetLine(reset, remaining),
+		usageLine,
+		resetLine,
+	}
+	if m.showBars {
+		percent := win.UsedPercent
+		if m.showRemaining {
+			percent = 100 - percent
+		}
+		lines = append(lines, m.renderPercentBar(percent, maxWidth, statusStyle))
 	}
 	for i := range lines {
 		lines[i] = ansi.Truncate(lines[i], max(4, maxWidth), "...")
@@ -345,6 +950,93 @@ func (m Model) renderWindowPanel(title string, win usage.WindowSummary, maxWidth
 	return m.styles.panel.Width(max(20, maxWidth)).Render(strings.Join(lines, "\n"))
 }
 
+// staleSummary reports whether the window values on screen predate a failed
+// refresh, with the age of the last good data.
+func (m Model) staleSummary() (bool, string) {
+	if m.summary == nil || m.lastError == "" || !m.lastSuccessAt.Before(m.lastAttemptAt) {
+		return false, ""
+	}
+	if m.lastSuccessAt.IsZero() {
+		return true, "age unknown"
+	}
+	return true, humanDuration(m.now.Sub(m.lastSuccessAt))
+}
+
+// formatWindowUsed shows absolute usage ("12k/50k (24%)") when the source
+// reports caps, and the bare percent otherwise.
+func (m Model) formatWindowUsed(win usage.WindowSummary) string {
+	if win.Limit != nil && win.Used != nil {
+		return fmt.Sprintf("%s/%s (%d%%)", m.formatCount(*win.Used), m.formatCount(*win.Limit), win.UsedPercent)
+	}
+	return fmt.Sprintf("%d%%", win.UsedPercent)
+}
+
+// renderPercentBar draws "[####------] 41%" sized to the panel. The bar is
+// plain ASCII so it reads the same without color.
+func (m Model) renderPercentBar(percent, maxWidth int, style lipgloss.Style) string {
+	inner := min(40, maxWidth-horizontalOverhead(m.styles.panel)-7)
+	if inner < 5 {
+		inner = 5
+	}
+	clamped := min(100, max(0, percent))
+	filled := (clamped*inner + 50) / 100
+	return "[" + style.Render(strings.Repeat("#", filled)) +
+		m.styles.dim.Render(strings.Repeat("-", inner-filled)) + "] " +
+		style.Render(fmt.Sprintf("%d%%", percent))
+}
+
+func (m Model) formatWindowRemaining(win usage.WindowSummary) string {
+	remaining := 100 - win.UsedPercent
+	if win.Limit != nil && win.Used != nil {
+		left := *win.Limit - *win.Used
+		if left < 0 {
+			left = 0
+		}
+		return fmt.Sprintf("%s/%s (%d%%)", m.formatCount(left), m.formatCount(*win.Limit), remaining)
+	}
+	return fmt.Sprintf("%d%%", remaining)
+}
+
+func (m Model) formatTimestamp(t time.Time) string {
+	if m.timeFormat == TimeFormatUnix {
+		return fmt.Sprintf("%d", t.Unix())
+	}
+	return t.UTC().Format(m.timeFormat)
+}
+
+// ParseTimeFormat resolves a named preset (rfc3339, kitchen, unix) or a Go
+// time layout. Layouts without any recognized layout element are rejected.
+func ParseTimeFormat(value string) (string, error) {
+	trimmed := strings.TrimSpace(value)
+	switch strings.ToLower(trimmed) {
+	case "", "default":
+		return defaultTimeFormat, nil
+	case "rfc3339":
+		return time.RFC3339, nil
+	case "kitchen":
+		return time.Kitchen, nil
+	case TimeFormatUnix:
+		return TimeFormatUnix, nil
+	}
+	probe := time.Date(2001, 12, 25, 8, 9, 7, 0, time.UTC)
+	if probe.Format(trimmed) == trimmed {
+		return "", fmt.Errorf("invalid time format %q (use rfc3339, kitchen, unix, or a Go layout such as \"2006-01-02 15:04\")", trimmed)
+	}
+	return trimmed, nil
+}
+
+func (m Model) renderRelativeResetLine(win usage.WindowSummary) string {
+	value := "unknown"
+	if win.ResetsAt != nil {
+		if win.ResetsAt.After(m.now) {
+			value = relativeTime(*win.ResetsAt, m.now)
+		} else {
+			value = "resetting"
+		}
+	}
+	return m.styles.label.Render("resets: ") + m.styles.value.Render(value)
+}
+
 func (m Model) renderResetLine(reset, remaining string) string {
 	return m.styles.label.Render("resets at: ") +
 		m.styles.value.Render(reset) +
@@ -356,15 +1048,187 @@ func (m Model) renderAccountsLine(maxWidth int) string {
 	if detected <= 0 {
 		detected = len(m.summary.Accounts)
 	}
-	identities := summarizeAccountIdentities(m.summary.Accounts)
-	value := fmt.Sprintf("%d detected [%s]", detected, strings.Join(identities, ", "))
-	line := m.styles.label.Render("accounts: ") + m.styles.value.Render(value)
+	// Annotate each identity with its observed-token state so it is clear
+	// which accounts are feeding the totals.
+	var identities []string
+	seen := map[string]struct{}{}
+	for _, account := range m.summary.Accounts {
+		identity := accountIdentityLabel(account)
+		if _, ok := seen[identity]; ok {
+			continue
+		}
+		seen[identity] = struct{}{}
+		entry := m.styles.value.Render(identity)
+		if glyph, style, ok := m.observedStatusGlyph(account); ok {
+			entry += " " + style.Render(glyph)
+		}
+		identities = append(identities, entry)
+	}
+	if len(identities) == 0 {
+		identities = []string{m.styles.value.Render("none")}
+	}
+	line := m.styles.label.Render("accounts: ") +
+		m.styles.value.Render(fmt.Sprintf("%d detected [", detected)) +
+		strings.Join(identities, m.styles.value.Render(", ")) +
+		m.styles.value.Render("]")
 	return ansi.Truncate(line, maxWidth, "...")
 }
 
+// observedStatusGlyph maps an account's observed-token state to a glyph, or a
+// short word in no-color mode. Accounts without a status are left bare.
+func (m Model) observedStatusGlyph(account usage.AccountSummary) (string, lipgloss.Style, bool) {
+	status := strings.ToLower(strings.TrimSpace(account.ObservedTokensStatus))
+	switch {
+	case account.ObservedTokensWarming:
+		return m.glyphOrText("⏳", "(warming)"), m.styles.loading, true
+	case status == "estimated":
+		return m.glyphOrText("✓", "(ok)"), m.styles.ok, true
+	case status == "partial":
+		return m.glyphOrText("◐", "(partial)"), m.styles.warn, true
+	case status == "unavailable":
+		return m.glyphOrText("✕", "(n/a)"), m.styles.warn, true
+	default:
+		return "", lipgloss.Style{}, false
+	}
+}
+
+func (m Model) glyphOrText(glyph, text string) string {
+	if m.noColor || m.ascii {
+		return text
+	}
+	return glyph
+}
+
 func (m Model) renderObservedHeaderLine(windowLabel string, win *usage.ObservedTokenBreakdown, fallbackTotal *int64) string {
 	state, style := m.observedHeaderState(win, fallbackTotal)
-	return m.styles.label.Render(windowLabel+" ") + style.Render("["+state+"]") + m.styles.label.Render(" (sum across accounts):")
+	line := m.styles.label.Render(windowLabel+" ") + style.Render("["+state+"]")
+	// Keep the spinner outside the brackets so bracket states stay plain words.
+	if spinner := m.spinnerGlyph(); spinner != "" && state == "refreshing" {
+		line += " " + style.Render(spinner)
+	}
+	return line + m.styles.label.Render(" (sum across accounts):")
+}
+
+const (
+	// DefaultBurstThreshold flags a five-hour window using three times its
+	// pro-rated share of the weekly total.
+	DefaultBurstThreshold = 3.0
+	// burstMinWeeklyTokens keeps the badge off until there is enough weekly
+	// history for the ratio to mean anything.
+	burstMinWeeklyTokens = 10_000
+
+	defaultPrimaryWindow   = 5 * time.Hour
+	defaultSecondaryWindow = 7 * 24 * time.Hour
+)
+
+// observedWindows are the lookbacks behind the observed totals, falling back
+// to 5h and 7d for summaries that do not report them.
+func (m Model) observedWindows() (primary, secondary time.Duration) {
+	primary, secondary = defaultPrimaryWindow, defaultSecondaryWindow
+	if m.summary != nil && m.summary.ObservedPrimaryMins > 0 {
+		primary = time.Duration(m.summary.ObservedPrimaryMins) * time.Minute
+	}
+	if m.summary != nil && m.summary.ObservedSecondaryMins > 0 {
+		secondary = time.Duration(m.summary.ObservedSecondaryMins) * time.Minute
+	}
+	return primary, secondary
+}
+
+// windowTokensLabel names an observed block by its lookback, keeping the
+// familiar name for the default length, e.g. "weekly tokens" or "24h tokens".
+func windowTokensLabel(d, defaultWindow time.Duration, defaultName string) string {
+	if d == defaultWindow {
+		return defaultName + " tokens"
+	}
+	switch {
+	case d > 24*time.Hour && d%(24*time.Hour) == 0:
+		return fmt.Sprintf("%dd tokens", d/(24*time.Hour))
+	case d%time.Hour == 0:
+		return fmt.Sprintf("%dh tokens", d/time.Hour)
+	default:
+		return humanDuration(d) + " tokens"
+	}
+}
+
+// burstRatio compares primary-window tokens to the secondary total pro-rated
+// to the primary length; 1.0 means the recent rate matches the longer average.
+func burstRatio(fiveHour, weekly int64, primary, secondary time.Duration) (float64, bool) {
+	if weekly < burstMinWeeklyTokens || fiveHour < 0 || primary <= 0 || secondary <= 0 {
+		return 0, false
+	}
+	share := float64(primary) / float64(secondary)
+	return float64(fiveHour) / (float64(weekly) * share), true
+}
+
+// renderProjectionLine says when the five-hour window reaches 100% at its pace
+// so far and whether that lands before the reset. It is empty without a
+// projection, so the line only takes space once there is usage to project.
+func (m Model) renderProjectionLine() string {
+	at := m.summary.ProjectedExhaustionAt
+	if at == nil || !m.summary.WindowDataAvailable {
+		return ""
+	}
+	burn := ""
+	if m.summary.BurnRatePerMin > 0 {
+		burn = " at " + m.formatCount(m.summary.BurnRatePerMin) + " tokens/min"
+	}
+	if !at.After(m.now) {
+		return m.styles.bad.Render("limit reached" + burn)
+	}
+	text := "projected to hit 100% in " + projectionDuration(at.Sub(m.now)) + burn
+	style := m.styles.dim
+	if reset := m.summary.PrimaryWindow.ResetsAt; reset != nil {
+		verdict := "OK"
+		style = m.styles.ok
+		if at.Before(*reset) {
+			verdict, style = "AT RISK", m.styles.warn
+		}
+		text += " (before reset: " + verdict + ")"
+	}
+	return style.Render(text)
+}
+
+// projectionDuration is humanDuration without a trailing zero unit, e.g. "42m"
+// rather than "42m0s".
+func projectionDuration(d time.Duration) string {
+	text := humanDuration(d)
+	for _, zero := range []struct{ suffix, trim string }{{"m0s", "0s"}, {"h0m", "0m"}, {"d0h", "0h"}} {
+		if strings.HasSuffix(text, zero.suffix) {
+			return strings.TrimSuffix(text, zero.trim)
+		}
+	}
+	return text
+}
+
+// renderBurstBadge is appended to the five-hour token header once totals are in.
+func (m Model) renderBurstBadge() string {
+	if m.burstThreshold <= 0 || m.summary.ObservedTokens5h == nil || m.summary.ObservedTokensWeekly == nil {
+		return ""
+	}
+	if state, _ := m.observedHeaderState(m.summary.ObservedWindow5h, m.summary.ObservedTokens5h); state != "ready" && state != "refreshing" {
+		return ""
+	}
+	primary, secondary := m.observedWindows()
+	ratio, ok := burstRatio(*m.summary.ObservedTokens5h, *m.summary.ObservedTokensWeekly, primary, secondary)
+	if !ok {
+		return ""
+	}
+	label, style := "steady", m.styles.ok
+	if ratio >= m.burstThreshold {
+		label, style = "bursting", m.styles.warn
+	}
+	return " " + style.Render(fmt.Sprintf("[%s %.1fx]", label, ratio))
+}
+
+func (m Model) spinnerGlyph() string {
+	if !m.spinnerEnabled || !m.fetching {
+		return ""
+	}
+	frames := spinnerFrames
+	if m.ascii {
+		frames = asciiSpinnerFrames
+	}
+	return frames[m.spinnerFrame%len(frames)]
 }
 
 func (m Model) observedHeaderState(win *usage.ObservedTokenBreakdown, fallbackTotal *int64) (string, lipgloss.Style) {
@@ -385,6 +1249,9 @@ func (m Model) observedHeaderState(win *usage.ObservedTokenBreakdown, fallbackTo
 		}
 	} else if warming {
 		state = "loading"
+		if since := m.summary.ObservedTokensWarmingSince; since != nil {
+			state = "warming " + humanDuration(m.now.Sub(*since))
+		}
 		style = m.styles.loading
 	} else if observedStatus == "partial" {
 		state = "partial"
@@ -396,31 +1263,17 @@ func (m Model) observedHeaderState(win *usage.ObservedTokenBreakdown, fallbackTo
 	return state, style
 }
 
-func summarizeAccountIdentities(accounts []usage.AccountSummary) []string {
-	if len(accounts) == 0 {
-		return []string{"none"}
+func accountIdentityLabel(account usage.AccountSummary) string {
+	if email := strings.TrimSpace(account.AccountEmail); email != "" {
+		return email
 	}
-	out := make([]string, 0, len(accounts))
-	seen := map[string]struct{}{}
-	for _, account := range accounts {
-		identity := "unidentified"
-		if email := strings.TrimSpace(account.AccountEmail); email != "" {
-			identity = email
-		} else if accountID := strings.TrimSpace(account.AccountID); accountID != "" {
-			identity = "account_id:" + accountID
-		} else if userID := strings.TrimSpace(account.UserID); userID != "" {
-			identity = "user_id:" + userID
-		}
-		if _, ok := seen[identity]; ok {
-			continue
-		}
-		seen[identity] = struct{}{}
-		out = append(out, identity)
+	if accountID := strings.TrimSpace(account.AccountID); accountID != "" {
+		return "account_id:" + accountID
 	}
-	if len(out) == 0 {
-		return []string{"none"}
+	if userID := strings.TrimSpace(account.UserID); userID != "" {
+		return "user_id:" + userID
 	}
-	return out
+	return "unidentified"
 }
 
 func (m Model) additionalAccountWindowRows() []usage.AccountSummary {
@@ -488,6 +1341,75 @@ func windowPanelTitle(base string, account usage.AccountSummary) string {
 	return base
 }
 
+// renderAccountTable lists every account on one row each. maxRows bounds the
+// data rows; any accounts that do not fit collapse into a trailing "+N more".
+func (m Model) renderAccountTable(contentWidth, maxRows int) string {
+	if m.summary == nil || len(m.summary.Accounts) == 0 || maxRows < 1 {
+		return ""
+	}
+	const (
+		labelWidth   = 12
+		percentWidth = 7
+		tokensWidth  = 10
+	)
+	lineWidth := max(8, contentWidth-4)
+	identityWidth := max(8, lineWidth-labelWidth-2*percentWidth-tokensWidth-4)
+	cell := func(text string, width int) string {
+		text = ansi.Truncate(text, width, "...")
+		return text + strings.Repeat(" ", max(0, width-lipgloss.Width(text)))
+	}
+	percentCell := func(win usage.WindowSummary, available bool) string {
+		if !available {
+			return m.styles.bad.Render(cell("n/a", percentWidth))
+		}
+		return percentStyle(win.UsedPercent, m.thresholds, m.styles).Render(cell(fmt.Sprintf("%d%%", win.UsedPercent), percentWidth))
+	}
+
+	header := cell("account", labelWidth) + " " + cell("identity", identityWidth) + " " +
+		cell("5h", percentWidth) + " " + cell("weekly", percentWidth) + " " + cell("5h tokens", tokensWidth)
+	lines := []string{m.styles.label.Render(header)}
+
+	accounts := m.summary.Accounts
+	hidden := 0
+	if len(accounts) > maxRows {
+		keep := max(0, maxRows-1)
+		hidden = len(accounts) - keep
+		accounts = accounts[:keep]
+	}
+	for _, account := range accounts {
+		available := accountWindowAvailable(account)
+		identity := accountIdentityLabel(account)
+		tokens := "n/a"
+		if account.ObservedWindow5h != nil {
+			tokens = m.formatCount(account.ObservedWindow5h.Total)
+		} else if account.ObservedTokens5h != nil {
+			tokens = m.formatCount(*account.ObservedTokens5h)
+		}
+		row := m.styles.value.Render(cell(strings.TrimSpace(account.Label), labelWidth)) + " " +
+			m.styles.value.Render(cell(identity, identityWidth)) + " " +
+			percentCell(account.PrimaryWindow, available) + " " +
+			percentCell(account.SecondaryWindow, available) + " " +
+			m.styles.value.Render(cell(tokens, tokensWidth))
+		lines = append(lines, row)
+	}
+	if hidden > 0 {
+		lines = append(lines, m.styles.dim.Render(fmt.Sprintf("+%d more", hidden)))
+	}
+	for i := range lines {
+		lines[i] = ansi.Truncate(lines[i], lineWidth, "...")
+	}
+	return m.styles.panel.Width(contentWidth).Render(strings.Join(lines, "\n"))
+}
+
+// accountTableRowsForLayout returns how many data rows the account table can
+// show without squeezing the meta panel below its minimum height.
+func accountTableRowsForLayout(viewportHeight, activeRowHeight, panelVerticalOverhead int) int {
+	bodyTargetHeight := max(1, viewportHeight-3) // header + spacer + exit hint
+	minMetaHeight := panelVerticalOverhead + observedMetaBaseLineCount() + 1
+	// One line goes to the table's column header.
+	return bodyTargetHeight - activeRowHeight - minMetaHeight - panelVerticalOverhead - 1
+}
+
 func fitWindowRowsToViewport(rows []string, viewportHeight, panelVerticalOverhead int) []string {
 	if len(rows) <= 1 {
 		return rows
@@ -512,31 +1434,83 @@ func fitWindowRowsToViewport(rows []string, viewportHeight, panelVerticalOverhea
 	return rows[:keep]
 }
 
-func (m Model) renderObservedBreakdownLinesFixed(win *usage.ObservedTokenBreakdown, fallbackTotal *int64) []string {
+// breakdownExtras are the breakdown lines shown only when reported.
+type breakdownExtras struct {
+	cachedOutput bool
+	cost         bool
+}
+
+// breakdownExtras turns a line on when either observed window reports it, so
+// both breakdown blocks stay the same height.
+func (m Model) breakdownExtras() breakdownExtras {
+	var extras breakdownExtras
+	if m.summary == nil {
+		return extras
+	}
+	for _, win := range []*usage.ObservedTokenBreakdown{m.summary.ObservedWindow5h, m.summary.ObservedWindowWeekly} {
+		if win == nil {
+			continue
+		}
+		extras.cachedOutput = extras.cachedOutput || win.HasCachedOutput
+		extras.cost = extras.cost || win.EstimatedCostUSD != nil
+	}
+	extras.cachedOutput = extras.cachedOutput && m.tokenFields.Has(TokenFieldCachedOutput)
+	extras.cost = extras.cost && m.tokenFields.Has(TokenFieldCost)
+	return extras
+}
+
+func (e breakdownExtras) shows(field TokenField) bool {
+	switch field {
+	case TokenFieldCachedOutput:
+		return e.cachedOutput
+	case TokenFieldCost:
+		return e.cost
+	}
+	return true
+}
+
+func (m Model) renderObservedBreakdownLinesFixed(win *usage.ObservedTokenBreakdown, fallbackTotal *int64, extras breakdownExtras) []string {
 	total := "n/a"
 	input := "n/a"
 	cachedInput := "n/a"
 	output := "n/a"
+	cachedOutput := "n/a"
 	reasoningOutput := "n/a"
+	cost := "n/a"
 
 	if win != nil {
-		total = compactCount(win.Total)
+		total = m.formatCount(win.Total)
+		if win.EstimatedCostUSD != nil {
+			cost = fmt.Sprintf("$%.2f", *win.EstimatedCostUSD)
+		}
 		if win.HasSplit {
-			input = compactCount(win.Input)
-			cachedInput = compactCount(win.CachedInput)
-			output = compactCount(win.Output)
-			reasoningOutput = compactCount(win.ReasoningOutput)
+			input = m.formatCount(win.Input)
+			cachedInput = m.formatCount(win.CachedInput)
+			output = m.formatCount(win.Output)
+			cachedOutput = m.formatCount(win.CachedOutput)
+			reasoningOutput = m.formatCount(win.ReasoningOutput)
 		}
 	} else if fallbackTotal != nil {
-		total = compactCount(*fallbackTotal)
-	}
-
-	lines := []string{
-		m.styles.dim.Render("- total: " + total),
-		m.styles.dim.Render("- input: " + input),
-		m.styles.dim.Render("- input (cached): " + cachedInput),
-		m.styles.dim.Render("- output: " + output),
-		m.styles.dim.Render("- output (reasoning): " + reasoningOutput),
+		total = m.formatCount(*fallbackTotal)
+	}
+
+	var lines []string
+	for _, row := range []struct {
+		field TokenField
+		text  string
+	}{
+		{TokenFieldTotal, "- total: " + total},
+		{TokenFieldInput, "- input: " + input},
+		{TokenFieldCachedInput, "- input (cached): " + cachedInput},
+		{TokenFieldOutput, "- output: " + output},
+		{TokenFieldCachedOutput, "- output (cached): " + cachedOutput},
+		{TokenFieldReasoning, "- output (reasoning): " + reasoningOutput},
+		{TokenFieldCost, "- est cost: " + cost},
+	} {
+		if !m.tokenFields.Has(row.field) || !extras.shows(row.field) {
+			continue
+		}
+		lines = append(lines, m.styles.dim.Render(row.text))
 	}
 	return lines
 }
@@ -551,6 +1525,7 @@ type windowPanelSpec struct {
 	title     string
 	window    usage.WindowSummary
 	available bool
+	loading   bool
 }
 
 func (m Model) renderStatusLinesFixed(rows int) []string {
@@ -670,22 +1645,179 @@ func statusRowsForLayout(viewportHeight, windowsBlockHeight, panelVerticalOverhe
 	return rows
 }
 
+// observedBreakdownBaseLines is the default breakdown block: total, input,
+// cached input, output, and reasoning.
+const observedBreakdownBaseLines = 5
+
 func observedMetaBaseLineCount() int {
 	// accounts line + two observed headers + two fixed 5-line breakdown blocks.
-	return 1 + 1 + 5 + 1 + 5
+	return 1 + 1 + observedBreakdownBaseLines + 1 + observedBreakdownBaseLines
+}
+
+// breakdownLineCount is how many lines each observed breakdown block renders.
+func (m Model) breakdownLineCount(extras breakdownExtras) int {
+	count := 0
+	for _, field := range allTokenFields {
+		if !extras.shows(field) {
+			continue
+		}
+		if m.tokenFields.Has(field) {
+			count++
+		}
+	}
+	return count
+}
+
+// Thresholds are the used percents at which window usage turns warn and bad.
+type Thresholds struct {
+	WarnAt int
+	BadAt  int
+}
+
+// DefaultThresholds colors usage warn from 70% and bad from 90%.
+var DefaultThresholds = Thresholds{WarnAt: 70, BadAt: 90}
+
+// Validate requires 0 <= WarnAt < BadAt <= 100.
+func (t Thresholds) Validate() error {
+	if t.WarnAt < 0 || t.WarnAt > 100 || t.BadAt < 0 || t.BadAt > 100 {
+		return fmt.Errorf("--warn-at and --bad-at must be between 0 and 100")
+	}
+	if t.WarnAt >= t.BadAt {
+		return fmt.Errorf("--warn-at (%d) must be below --bad-at (%d)", t.WarnAt, t.BadAt)
+	}
+	return nil
 }
 
-func percentStyle(percent int, styles styles) lipgloss.Style {
+func percentStyle(percent int, thresholds Thresholds, styles styles) lipgloss.Style {
 	switch {
-	case percent >= 90:
+	case percent >= thresholds.BadAt:
 		return styles.bad
-	case percent >= 70:
+	case percent >= thresholds.WarnAt:
 		return styles.warn
 	default:
 		return styles.ok
 	}
 }
 
+// CountFormat selects how token counts are rendered.
+type CountFormat string
+
+const (
+	// CountFormatShort is the default: "1.23m", "45.6k".
+	CountFormatShort CountFormat = "short"
+	// CountFormatFull prints every digit with no suffix.
+	CountFormatFull CountFormat = "full"
+	// CountFormatUpper is the short form with capitalized suffixes: "1.23M".
+	CountFormatUpper CountFormat = "upper"
+)
+
+// ParseCountFormat resolves a --count-format value; empty means short.
+func ParseCountFormat(value string) (CountFormat, error) {
+	switch CountFormat(strings.ToLower(strings.TrimSpace(value))) {
+	case "", CountFormatShort:
+		return CountFormatShort, nil
+	case CountFormatFull:
+		return CountFormatFull, nil
+	case CountFormatUpper:
+		return CountFormatUpper, nil
+	}
+	return "", fmt.Errorf("unsupported count format %q (use short, full, or upper)", value)
+}
+
+// CountdownMode selects the header's next-refresh indicator.
+type CountdownMode string
+
+const (
+	// CountdownRelative is the default: "[next refresh in 13s]".
+	CountdownRelative CountdownMode = "relative"
+	// CountdownAbsolute shows the wall-clock time: "[next refresh at 15:04:05]".
+	CountdownAbsolute CountdownMode = "absolute"
+	// CountdownOff hides the indicator outside of backoff.
+	CountdownOff CountdownMode = "off"
+)
+
+// ParseCountdownMode accepts relative, absolute, or off; empty means relative.
+func ParseCountdownMode(value string) (CountdownMode, error) {
+	switch mode := CountdownMode(strings.ToLower(strings.TrimSpace(value))); mode {
+	case "", CountdownRelative:
+		return CountdownRelative, nil
+	case CountdownAbsolute, CountdownOff:
+		return mode, nil
+	}
+	return "", fmt.Errorf("unsupported countdown %q (use relative, absolute, or off)", value)
+}
+
+// TokenField names one line of the observed token breakdown.
+type TokenField string
+
+const (
+	TokenFieldTotal        TokenField = "total"
+	TokenFieldInput        TokenField = "input"
+	TokenFieldCachedInput  TokenField = "cached-input"
+	TokenFieldOutput       TokenField = "output"
+	TokenFieldCachedOutput TokenField = "cached-output"
+	TokenFieldReasoning    TokenField = "reasoning"
+	TokenFieldCost         TokenField = "cost"
+)
+
+var allTokenFields = []TokenField{
+	TokenFieldTotal,
+	TokenFieldInput,
+	TokenFieldCachedInput,
+	TokenFieldOutput,
+	TokenFieldCachedOutput,
+	TokenFieldReasoning,
+	TokenFieldCost,
+}
+
+// TokenFields is the set of breakdown lines to show; nil means all of them.
+type TokenFields map[TokenField]bool
+
+func (f TokenFields) Has(field TokenField) bool {
+	return f == nil || f[field]
+}
+
+// ParseTokenFields resolves a comma-separated --token-fields value; empty
+// means every field.
+func ParseTokenFields(value string) (TokenFields, error) {
+	if strings.TrimSpace(value) == "" {
+		return nil, nil
+	}
+	out := TokenFields{}
+	for _, part := range strings.Split(value, ",") {
+		field := TokenField(strings.ToLower(strings.TrimSpace(part)))
+		if field == "" {
+			continue
+		}
+		known := false
+		for _, candidate := range allTokenFields {
+			if field == candidate {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return nil, fmt.Errorf("unsupported token field %q (use total, input, cached-input, output, cached-output, reasoning, or cost)", part)
+		}
+		out[field] = true
+	}
+	if len(out) == 0 {
+		return nil, errors.New("token fields list is empty")
+	}
+	return out, nil
+}
+
+func (m Model) formatCount(v int64) string {
+	switch m.countFormat {
+	case CountFormatFull:
+		return strconv.FormatInt(v, 10)
+	case CountFormatUpper:
+		return strings.ToUpper(compactCount(v))
+	default:
+		return compactCount(v)
+	}
+}
+
 func compactCount(v int64) string {
 	sign := ""
 	if v < 0 {
@@ -718,18 +1850,68 @@ func compactCount(v int64) string {
 	return fmt.Sprintf("%s%s%s", sign, formatted, units[unitIndex])
 }
 
-func pollCmd(interval time.Duration) tea.Cmd {
+func pollCmd(interval time.Duration, seq int) tea.Cmd {
 	return tea.Tick(interval, func(t time.Time) tea.Msg {
-		return pollTickMsg{at: t}
+		return pollTickMsg{at: t, seq: seq}
 	})
 }
 
+const (
+	// backoffAfterFailures consecutive failed fetches start doubling the poll
+	// interval, up to maxBackoffInterval (or the base interval if longer).
+	backoffAfterFailures = 3
+	maxBackoffInterval   = 10 * time.Minute
+)
+
+func (m Model) backingOff() bool {
+	return m.consecutiveFailures >= backoffAfterFailures
+}
+
+// pollInterval is the base interval, doubled for each failure past the
+// backoff threshold.
+func (m Model) pollInterval() time.Duration {
+	if !m.backingOff() {
+		return m.interval
+	}
+	limit := maxDuration(m.interval, maxBackoffInterval)
+	d := m.interval
+	for i := backoffAfterFailures; i <= m.consecutiveFailures && d < limit; i++ {
+		d *= 2
+	}
+	if d > limit {
+		d = limit
+	}
+	return d
+}
+
+// reschedulePoll retires the pending tick and schedules the next poll at the
+// current effective interval.
+func (m *Model) reschedulePoll(at time.Time) tea.Cmd {
+	m.pollSeq++
+	interval := m.pollInterval()
+	m.nextFetchAt = at.UTC().Add(interval)
+	return pollCmd(interval, m.pollSeq)
+}
+
+func maxDuration(a, b time.Duration) time.Duration {
+	if a > b {
+		return a
+	}
+	return b
+}
+
 func clockCmd() tea.Cmd {
 	return tea.Tick(1*time.Second, func(t time.Time) tea.Msg {
 		return clockTickMsg{at: t}
 	})
 }
 
+func spinnerCmd() tea.Cmd {
+	return tea.Tick(spinnerTickInterval, func(time.Time) tea.Msg {
+		return spinnerTickMsg{}
+	})
+}
+
 func fetchCmd(fetch FetchFunc, timeout time.Duration) tea.Cmd {
 	return func() tea.Msg {
 		start := time.Now()
@@ -751,9 +1933,18 @@ func Run(opts Options) error {
 	if opts.AltScreen {
 		progOpts = append(progOpts, tea.WithAltScreen())
 	}
+	if opts.RefreshOnFocus {
+		progOpts = append(progOpts, tea.WithReportFocus())
+	}
 	prog := tea.NewProgram(model, progOpts...)
-	_, err := prog.Run()
-	return err
+	final, err := prog.Run()
+	if err != nil {
+		return err
+	}
+	if m, ok := final.(Model); ok && m.gaveUp {
+		return fmt.Errorf("giving up after %d consecutive fetch failures: %s", m.consecutiveFailures, m.lastError)
+	}
+	return nil
 }
 
 func joinWithPaddingKeepRight(left, right string, width int) string {
@@ -856,6 +2047,11 @@ func humanDuration(d time.Duration) string {
 	return fmt.Sprintf("%dd%dh", int(d.Hours())/24, int(d.Hours())%24)
 }
 
+// relativeTime phrases a future t against now, e.g. "in 1h20m".
+func relativeTime(t, now time.Time) string {
+	return "in " + humanDuration(t.Sub(now))
+}
+
 func min(a, b int) int {
 	if a < b {
 		return a
@@ -2,13 +2,17 @@ package tui
 
 import (
 	"context"
+	"errors"
 	"math"
 	"strconv"
 	"strings"
 	"testing"
 	"time"
+	"unicode"
 
+	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
 
 	"github.com/olliecrow/codex_usage_monitor/internal/usage"
 )
@@ -43,6 +47,74 @@ func TestViewFitsViewportAcrossSizes(t *testing.T) {
 	}
 }
 
+func FuzzViewFitsViewport(f *testing.F) {
+	for _, seed := range [][3]int{
+		{1, 1, 0}, {2, 3, 1}, {19, 5, 2}, {42, 14, 0}, {93, 21, 1}, {94, 22, 1},
+		{95, 23, 0}, {200, 80, 1}, {400, 200, 2},
+	} {
+		f.Add(seed[0], seed[1], seed[2])
+	}
+
+	f.Fuzz(func(t *testing.T, width, height, variant int) {
+		// Keep sizes within what a terminal can plausibly report.
+		width = 1 + abs(width)%500
+		height = 1 + abs(height)%250
+
+		var m Model
+		switch abs(variant) % 3 {
+		case 0:
+			m = seededModel()
+		case 1:
+			m = seededMultiAccountModel()
+		default:
+			m = NewModel(Options{NoColor: true, Accounts: []usage.MonitorAccount{{Label: "default"}}})
+		}
+		m.width = width
+		m.height = height
+
+		lines := strings.Split(m.View(), "\n")
+		if len(lines) != height {
+			t.Fatalf("%dx%d: expected %d lines, got %d", width, height, height, len(lines))
+		}
+		for i, line := range lines {
+			if lipgloss.Width(line) > width {
+				t.Fatalf("%dx%d: line %d exceeded width: got %d", width, height, i+1, lipgloss.Width(line))
+			}
+		}
+	})
+}
+
+func TestLayoutMathInvariantsAcrossRanges(t *testing.T) {
+	for overhead := 0; overhead <= 8; overhead++ {
+		for contentWidth := 0; contentWidth <= 600; contentWidth++ {
+			panelWidth, spacerWidth := splitEqualPanelContentWidths(contentWidth, overhead)
+			if panelWidth < 0 || spacerWidth < 0 {
+				t.Fatalf("negative split for width=%d overhead=%d: %d/%d", contentWidth, overhead, panelWidth, spacerWidth)
+			}
+			if contentWidth-overhead >= 3 && 2*(panelWidth+overhead)+spacerWidth != contentWidth+overhead {
+				t.Fatalf("split does not align for width=%d overhead=%d: panel=%d spacer=%d", contentWidth, overhead, panelWidth, spacerWidth)
+			}
+		}
+	}
+	for height := -5; height <= 300; height++ {
+		for windows := 0; windows <= 60; windows += 3 {
+			if rows := statusRowsForLayout(height, windows, 2); rows < 1 {
+				t.Fatalf("expected at least one status row for height=%d windows=%d, got %d", height, windows, rows)
+			}
+		}
+	}
+}
+
+func abs(v int) int {
+	if v < 0 {
+		if v == math.MinInt {
+			return math.MaxInt
+		}
+		return -v
+	}
+	return v
+}
+
 func TestMultiAccountViewFitsViewportAcrossSizes(t *testing.T) {
 	sizes := []struct {
 		width  int
@@ -73,6 +145,202 @@ func TestMultiAccountViewFitsViewportAcrossSizes(t *testing.T) {
 	}
 }
 
+func TestCachedOutputLineKeepsViewportHeight(t *testing.T) {
+	for _, height := range []int{24, 28, 40} {
+		m := seededMultiAccountModel()
+		m.width = 100
+		m.height = height
+		m.summary.ObservedWindow5h = &usage.ObservedTokenBreakdown{Total: 500, Input: 400, Output: 100, CachedOutput: 25, HasSplit: true, HasCachedOutput: true}
+		m.summary.ObservedWindowWeekly = &usage.ObservedTokenBreakdown{Total: 900, Input: 700, Output: 200, HasSplit: true}
+		out := m.View()
+		if lines := strings.Split(out, "\n"); len(lines) != height {
+			t.Fatalf("height %d: expected %d lines with cached output rows, got %d", height, height, len(lines))
+		}
+		if !strings.Contains(out, "- output (cached): 25") || !strings.Contains(out, "- output (cached): 0") {
+			t.Fatalf("height %d: expected cached output line in both blocks, got:\n%s", height, out)
+		}
+	}
+
+	m := seededModel()
+	m.width = 100
+	m.height = 30
+	if out := m.View(); strings.Contains(out, "output (cached)") {
+		t.Fatalf("did not expect cached output line without cached output data")
+	}
+}
+
+func TestEstimatedCostLineKeepsViewportHeight(t *testing.T) {
+	cost := 1.234
+	for _, height := range []int{24, 28, 40} {
+		m := seededMultiAccountModel()
+		m.width = 100
+		m.height = height
+		m.summary.ObservedWindow5h = &usage.ObservedTokenBreakdown{Total: 500, Input: 400, Output: 100, HasSplit: true, EstimatedCostUSD: &cost}
+		m.summary.ObservedWindowWeekly = &usage.ObservedTokenBreakdown{Total: 900, Input: 700, Output: 200, HasSplit: true}
+		out := m.View()
+		if lines := strings.Split(out, "\n"); len(lines) != height {
+			t.Fatalf("height %d: expected %d lines with cost rows, got %d", height, height, len(lines))
+		}
+		if !strings.Contains(out, "- est cost: $1.23") || !strings.Contains(out, "- est cost: n/a") {
+			t.Fatalf("height %d: expected a cost line in both blocks, got:\n%s", height, out)
+		}
+	}
+
+	m := seededModel()
+	m.width = 100
+	m.height = 30
+	if out := m.View(); strings.Contains(out, "est cost") {
+		t.Fatalf("did not expect a cost line without pricing")
+	}
+}
+
+func TestProjectionLineFlagsExhaustionBeforeReset(t *testing.T) {
+	for _, height := range []int{24, 28, 40} {
+		m := seededMultiAccountModel()
+		m.width = 120
+		m.height = height
+		at := m.now.Add(42 * time.Minute)
+		m.summary.ProjectedExhaustionAt = &at
+		m.summary.BurnRatePerMin = 1500
+		out := m.View()
+		if lines := strings.Split(out, "\n"); len(lines) != height {
+			t.Fatalf("height %d: expected %d lines with the projection row, got %d", height, height, len(lines))
+		}
+		if !strings.Contains(out, "projected to hit 100% in 42m at 1.5k tokens/min (before reset: AT RISK)") {
+			t.Fatalf("height %d: expected an at-risk projection, got:\n%s", height, out)
+		}
+	}
+
+	m := seededModel()
+	m.width = 120
+	m.height = 30
+	at := m.now.Add(3 * time.Hour)
+	m.summary.ProjectedExhaustionAt = &at
+	if out := m.View(); !strings.Contains(out, "projected to hit 100% in 3h (before reset: OK)") {
+		t.Fatalf("expected a projection past the reset to be OK, got:\n%s", out)
+	}
+	at = m.now.Add(90*time.Minute + 10*time.Second)
+	if out := m.View(); !strings.Contains(out, "projected to hit 100% in 1h30m (before reset: OK)") {
+		t.Fatalf("expected minutes kept when they are not zero, got:\n%s", out)
+	}
+	at = m.now
+	if out := m.View(); !strings.Contains(out, "limit reached") || strings.Contains(out, "projected to hit") {
+		t.Fatalf("expected an exhausted window to read limit reached, got:\n%s", out)
+	}
+	m.summary.ProjectedExhaustionAt = nil
+	if out := m.View(); strings.Contains(out, "projected to hit") {
+		t.Fatalf("did not expect a projection line without usage to project")
+	}
+}
+
+func TestTokenFieldsTrimBreakdownAndKeepViewportHeight(t *testing.T) {
+	fields, err := ParseTokenFields(" Input, output ,reasoning,")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !fields.Has(TokenFieldInput) || fields.Has(TokenFieldTotal) {
+		t.Fatalf("unexpected parsed fields: %v", fields)
+	}
+	if all, err := ParseTokenFields(""); err != nil || !all.Has(TokenFieldCachedInput) {
+		t.Fatalf("expected empty value to select every field, got %v (%v)", all, err)
+	}
+	if _, err := ParseTokenFields(" , "); err == nil {
+		t.Fatalf("expected error for an empty field list")
+	}
+
+	m := seededMultiAccountModel()
+	m.width = 100
+	m.height = 28
+	m.tokenFields = fields
+	out := m.View()
+	if lines := strings.Split(out, "\n"); len(lines) != m.height {
+		t.Fatalf("expected %d lines with trimmed breakdown, got %d", m.height, len(lines))
+	}
+	if strings.Contains(out, "- total:") || strings.Contains(out, "input (cached)") {
+		t.Fatalf("expected total and cached input lines to be hidden, got:\n%s", out)
+	}
+	if strings.Count(out, "- output (reasoning):") != 2 {
+		t.Fatalf("expected reasoning line in both blocks, got:\n%s", out)
+	}
+}
+
+func TestBurstBadgeComparesFiveHourToWeeklyAverage(t *testing.T) {
+	if _, ok := burstRatio(100, burstMinWeeklyTokens-1, defaultPrimaryWindow, defaultSecondaryWindow); ok {
+		t.Fatalf("expected no ratio below the weekly minimum")
+	}
+	if ratio, ok := burstRatio(5*1000, 168*1000, defaultPrimaryWindow, defaultSecondaryWindow); !ok || ratio < 0.99 || ratio > 1.01 {
+		t.Fatalf("expected ratio 1.0 for an even weekly pace, got %v (%v)", ratio, ok)
+	}
+	if ratio, ok := burstRatio(2*1000, 24*1000, 2*time.Hour, 24*time.Hour); !ok || ratio < 0.99 || ratio > 1.01 {
+		t.Fatalf("expected ratio 1.0 for an even pace over 2h/24h windows, got %v (%v)", ratio, ok)
+	}
+
+	m := seededModel()
+	m.width = 120
+	m.height = 30
+	m.burstThreshold = DefaultBurstThreshold
+	weekly := int64(168_000)
+	m.summary.ObservedTokensWeekly = &weekly
+
+	steady := int64(10_000)
+	m.summary.ObservedTokens5h = &steady
+	if out := m.renderBody(); !strings.Contains(out, "[steady 2.0x]") {
+		t.Fatalf("expected steady badge, got:\n%s", out)
+	}
+
+	burst := int64(20_000)
+	m.summary.ObservedTokens5h = &burst
+	if out := m.renderBody(); !strings.Contains(out, "[bursting 4.0x]") {
+		t.Fatalf("expected bursting badge, got:\n%s", out)
+	}
+
+	m.burstThreshold = 0
+	if out := m.renderBody(); strings.Contains(out, "bursting") {
+		t.Fatalf("expected badge to be hidden when threshold is 0")
+	}
+}
+
+func TestObservedHeadersFollowConfiguredWindows(t *testing.T) {
+	m := seededModel()
+	m.width = 120
+	m.height = 30
+	m.burstThreshold = DefaultBurstThreshold
+	m.summary.ObservedPrimaryMins = 120
+	m.summary.ObservedSecondaryMins = 24 * 60
+	primary, weekly := int64(2_000), int64(24_000)
+	m.summary.ObservedTokens5h = &primary
+	m.summary.ObservedTokensWeekly = &weekly
+
+	out := m.renderBody()
+	if !strings.Contains(out, "2h tokens [") || !strings.Contains(out, "24h tokens [") {
+		t.Fatalf("expected headers named after the 2h/24h windows, got:\n%s", out)
+	}
+	if strings.Contains(out, "five-hour tokens") || strings.Contains(out, "weekly tokens") {
+		t.Fatalf("expected default window names to be replaced, got:\n%s", out)
+	}
+	if !strings.Contains(out, "[steady 1.0x]") {
+		t.Fatalf("expected the burst ratio to use the configured windows, got:\n%s", out)
+	}
+}
+
+func TestObservedHeaderShowsWarmingElapsed(t *testing.T) {
+	m := seededModel()
+	m.width = 120
+	m.height = 30
+	m.summary.ObservedTokensStatus = "unavailable"
+	m.summary.ObservedTokensWarming = true
+	if out := m.renderBody(); !strings.Contains(out, "five-hour tokens [loading]") {
+		t.Fatalf("expected bare loading without a warmup start, got:\n%s", out)
+	}
+
+	since := m.now.Add(-12 * time.Second)
+	m.summary.ObservedTokensWarmingSince = &since
+	out := m.renderBody()
+	if !strings.Contains(out, "five-hour tokens [warming 12s]") || !strings.Contains(out, "weekly tokens [warming 12s]") {
+		t.Fatalf("expected warming elapsed in token headers, got:\n%s", out)
+	}
+}
+
 func TestNarrowViewStillRendersCoreFields(t *testing.T) {
 	m := seededModel()
 	m.width = 42
@@ -253,6 +521,32 @@ func TestAccountsLineShowsDetectedAndIdentifiers(t *testing.T) {
 	}
 }
 
+func TestAccountsLineAnnotatesObservedStatus(t *testing.T) {
+	m := seededModel()
+	m.width = 160
+	m.height = 28
+	m.summary.TotalAccounts = 4
+	m.summary.Accounts = []usage.AccountSummary{
+		{AccountEmail: "a@example.com", ObservedTokensStatus: "estimated"},
+		{AccountEmail: "b@example.com", ObservedTokensStatus: "unavailable", ObservedTokensWarming: true},
+		{AccountEmail: "c@example.com", ObservedTokensStatus: "partial"},
+		{AccountEmail: "d@example.com", ObservedTokensStatus: "unavailable"},
+	}
+
+	out := m.View()
+	want := "accounts: 4 detected [a@example.com (ok), b@example.com (warming), c@example.com (partial), d@example.com (n/a)]"
+	if !strings.Contains(out, want) {
+		t.Fatalf("expected text status annotations in no-color mode, got:\n%s", out)
+	}
+
+	m.noColor = false
+	line := ansi.Strip(m.renderAccountsLine(200))
+	if !strings.Contains(line, "a@example.com ✓") || !strings.Contains(line, "b@example.com ⏳") ||
+		!strings.Contains(line, "c@example.com ◐") || !strings.Contains(line, "d@example.com ✕") {
+		t.Fatalf("expected status glyphs with color enabled, got %q", line)
+	}
+}
+
 func TestAccountsLineTruncatesWithDots(t *testing.T) {
 	m := seededModel()
 	m.summary.TotalAccounts = 2
@@ -363,12 +657,12 @@ func TestObservedHeaderShowsLoadingWhenWarmingWithoutFetchInFlight(t *testing.T)
 	}
 }
 
-func TestHeaderShowsCtrlCOnly(t *testing.T) {
+func TestHeaderOmitsKeyHints(t *testing.T) {
 	m := seededModel()
 	m.width = 100
 	m.height = 20
 	header := m.renderHeader()
-	if strings.Contains(header, "ctrl+c") || strings.Contains(header, "q quit") || strings.Contains(header, "r refresh") {
+	if strings.Contains(header, "ctrl+c") || strings.Contains(header, "q to exit") || strings.Contains(header, "r to refresh") {
 		t.Fatalf("expected header without interactive key hints, got: %q", header)
 	}
 }
@@ -386,6 +680,33 @@ func TestWindowPanelCompactsResetLineIntoBracketedCountdown(t *testing.T) {
 	}
 }
 
+func TestASCIIModeRendersOnlyASCII(t *testing.T) {
+	m := seededModel()
+	m.ascii = true
+	m.styles = defaultStyles(true, false, true)
+	m.fetching = true
+	m.spinnerEnabled = true
+	m.width = 120
+	m.height = 32
+	view := m.View()
+	for i, r := range view {
+		if r > unicode.MaxASCII {
+			t.Fatalf("expected ASCII-only view, found %q at byte %d in:\n%s", r, i, view)
+		}
+	}
+
+	// The wide layout keeps the same geometry with ASCII corners.
+	var top []string
+	for _, line := range strings.Split(m.renderBody(), "\n") {
+		if strings.HasPrefix(line, "+-") {
+			top = append(top, line)
+		}
+	}
+	if len(top) < 2 || lipgloss.Width(top[0]) != lipgloss.Width(top[1]) {
+		t.Fatalf("expected aligned ASCII panel borders, got %q", top)
+	}
+}
+
 func TestWideLayoutPanelsAlignWidths(t *testing.T) {
 	widths := []int{98, 99, 100, 101, 120, 121, 140}
 	heights := []int{18, 24, 32}
@@ -454,6 +775,32 @@ func TestHeaderIncludesRefreshBracketOnTopLine(t *testing.T) {
 	}
 }
 
+func TestHeaderCountdownModes(t *testing.T) {
+	m := seededModel()
+	m.width = 120
+	m.nextFetchAt = m.now.Add(13 * time.Second)
+
+	m.countdown = CountdownAbsolute
+	if header := m.renderHeader(); !strings.Contains(header, "[next refresh at 15:00:13]") {
+		t.Fatalf("expected absolute countdown, got %q", header)
+	}
+	m.countdown = CountdownOff
+	if header := m.renderHeader(); strings.Contains(header, "next refresh") {
+		t.Fatalf("expected no countdown when off, got %q", header)
+	}
+	m.consecutiveFailures = backoffAfterFailures
+	if header := m.renderHeader(); !strings.Contains(header, "[retrying in 13s (backoff)]") {
+		t.Fatalf("expected backoff notice to survive countdown off, got %q", header)
+	}
+
+	if _, err := ParseCountdownMode("sometimes"); err == nil {
+		t.Fatalf("expected unknown countdown mode to be rejected")
+	}
+	if mode, err := ParseCountdownMode(""); err != nil || mode != CountdownRelative {
+		t.Fatalf("expected empty countdown to default to relative, got %q, %v", mode, err)
+	}
+}
+
 func TestHeaderRetainsUTCTimestampAtNarrowWidth(t *testing.T) {
 	m := seededModel()
 	m.width = 58
@@ -470,6 +817,18 @@ func TestHeaderRetainsUTCTimestampAtNarrowWidth(t *testing.T) {
 	}
 }
 
+func TestHeaderClockLabelsItsZone(t *testing.T) {
+	m := seededModel()
+	m.location = time.FixedZone("PST", -8*60*60)
+	if got := m.headerClock(); got != "pst 2026-02-26 07:00:00" {
+		t.Fatalf("expected zone-labelled local clock, got %q", got)
+	}
+	m.location = nil
+	if got := m.headerClock(); got != "utc 2026-02-26 15:00:00" {
+		t.Fatalf("expected utc fallback, got %q", got)
+	}
+}
+
 func TestViewportClippingHasNoEllipsisArtifacts(t *testing.T) {
 	m := seededModel()
 	m.width = 95
@@ -485,14 +844,14 @@ func TestViewShowsExitHintAtBottom(t *testing.T) {
 	m.width = 120
 	m.height = 30
 	out := m.View()
-	if !strings.Contains(out, "Ctrl+C to exit") {
+	if !strings.Contains(out, "q to exit | r to refresh | l log") {
 		t.Fatalf("expected bottom exit hint in view")
 	}
 	lines := strings.Split(out, "\n")
 	if len(lines) != m.height {
 		t.Fatalf("expected %d lines, got %d", m.height, len(lines))
 	}
-	if !strings.Contains(lines[len(lines)-1], "Ctrl+C to exit") {
+	if !strings.Contains(lines[len(lines)-1], "q to exit | r to refresh | l log") {
 		t.Fatalf("expected exit hint on bottom row, got: %q", lines[len(lines)-1])
 	}
 	if strings.Contains(out, "last successful snapshot") {
@@ -500,6 +859,225 @@ func TestViewShowsExitHintAtBottom(t *testing.T) {
 	}
 }
 
+func TestFirstPaintShowsConfiguredAccountsBeforeFetch(t *testing.T) {
+	m := NewModel(Options{
+		Interval: 15 * time.Second,
+		Timeout:  8 * time.Second,
+		NoColor:  true,
+		Accounts: []usage.MonitorAccount{
+			{Label: "default", CodexHome: "/tmp/a"},
+			{Label: "work", CodexHome: "/tmp/b"},
+		},
+	})
+	m.width = 120
+	m.height = 24
+
+	out := m.View()
+	if !strings.Contains(out, "accounts: 2 configured [default, work]") {
+		t.Fatalf("expected configured accounts in first paint, got:\n%s", out)
+	}
+	if !strings.Contains(out, "loading usage data...") {
+		t.Fatalf("expected loading message in first paint")
+	}
+	if !strings.Contains(out, "used: loading") {
+		t.Fatalf("expected skeleton window cards in first paint")
+	}
+	lines := strings.Split(out, "\n")
+	if len(lines) != m.height {
+		t.Fatalf("expected %d lines, got %d", m.height, len(lines))
+	}
+}
+
+func TestSpinnerAnimatesWhileFetching(t *testing.T) {
+	m := NewModel(Options{Interval: 15 * time.Second, Timeout: 8 * time.Second})
+	m.width = 120
+	m.height = 24
+	if !strings.Contains(m.renderHeader(), spinnerFrames[0]) {
+		t.Fatalf("expected first spinner frame in header while fetching")
+	}
+
+	next, cmd := m.Update(spinnerTickMsg{})
+	m = next.(Model)
+	if cmd == nil {
+		t.Fatalf("expected spinner to reschedule while fetching")
+	}
+	if !strings.Contains(m.renderHeader(), spinnerFrames[1]) {
+		t.Fatalf("expected spinner to advance on tick")
+	}
+
+	m.fetching = false
+	next, cmd = m.Update(spinnerTickMsg{})
+	m = next.(Model)
+	if cmd != nil || m.spinnerActive {
+		t.Fatalf("expected spinner to stop once fetch completes")
+	}
+	if strings.Contains(m.renderHeader(), spinnerFrames[1]) {
+		t.Fatalf("did not expect spinner glyph when idle")
+	}
+}
+
+func TestSpinnerDisabledByNoColorAndNoSpinner(t *testing.T) {
+	for _, opts := range []Options{{NoColor: true}, {NoSpinner: true}} {
+		m := NewModel(opts)
+		m.width = 120
+		m.height = 24
+		if m.spinnerGlyph() != "" {
+			t.Fatalf("expected spinner disabled for options %+v", opts)
+		}
+		for _, frame := range spinnerFrames {
+			if strings.Contains(m.View(), frame) {
+				t.Fatalf("did not expect spinner frame %q for options %+v", frame, opts)
+			}
+		}
+	}
+}
+
+func TestParseTimeFormatPresetsAndLayouts(t *testing.T) {
+	cases := map[string]string{
+		"":           defaultTimeFormat,
+		"rfc3339":    time.RFC3339,
+		"Kitchen":    time.Kitchen,
+		"unix":       TimeFormatUnix,
+		"2006-01-02": "2006-01-02",
+	}
+	for input, expected := range cases {
+		got, err := ParseTimeFormat(input)
+		if err != nil {
+			t.Fatalf("unexpected error for %q: %v", input, err)
+		}
+		if got != expected {
+			t.Fatalf("expected %q for %q, got %q", expected, input, got)
+		}
+	}
+	if _, err := ParseTimeFormat("not a layout"); err == nil {
+		t.Fatalf("expected error for layout without time elements")
+	}
+}
+
+func TestWindowPanelUsesConfiguredTimeFormat(t *testing.T) {
+	m := seededModel()
+	m.width = 100
+	m.height = 20
+	m.timeFormat = time.Kitchen
+	if out := m.renderBody(); !strings.Contains(out, "resets at: 4:30PM [1h30m]") {
+		t.Fatalf("expected kitchen reset time, got:\n%s", out)
+	}
+
+	m.timeFormat = TimeFormatUnix
+	expected := strconv.FormatInt(m.summary.PrimaryWindow.ResetsAt.Unix(), 10)
+	if out := m.renderBody(); !strings.Contains(out, "resets at: "+expected+" [1h30m]") {
+		t.Fatalf("expected unix reset time, got:\n%s", out)
+	}
+}
+
+func TestWindowPanelShowsAbsoluteLimitsWhenReported(t *testing.T) {
+	m := seededModel()
+	m.width = 100
+	m.height = 20
+	limit, used := int64(50000), int64(12000)
+	m.summary.PrimaryWindow.Limit = &limit
+	m.summary.PrimaryWindow.Used = &used
+	m.summary.PrimaryWindow.UsedPercent = 24
+	out := m.renderBody()
+	if !strings.Contains(out, "used: 12k/50k (24%)") {
+		t.Fatalf("expected absolute usage for five-hour window, got:\n%s", out)
+	}
+	if !strings.Contains(out, "used: 69%") {
+		t.Fatalf("expected percent-only fallback for weekly window, got:\n%s", out)
+	}
+}
+
+func TestWindowPanelShowRemainingReplacesUsedLine(t *testing.T) {
+	m := seededModel()
+	m.width = 100
+	m.height = 20
+	m.showRemaining = true
+	limit, used := int64(50000), int64(12000)
+	m.summary.PrimaryWindow.Limit = &limit
+	m.summary.PrimaryWindow.Used = &used
+	m.summary.PrimaryWindow.UsedPercent = 24
+	out := m.renderBody()
+	if !strings.Contains(out, "remaining: 38k/50k (76%)") {
+		t.Fatalf("expected absolute remaining budget for five-hour window, got:\n%s", out)
+	}
+	if !strings.Contains(out, "remaining: 31%") {
+		t.Fatalf("expected remaining percent for weekly window, got:\n%s", out)
+	}
+	if strings.Contains(out, "used: ") {
+		t.Fatalf("expected used line to be replaced, got:\n%s", out)
+	}
+}
+
+func TestWindowPanelsMarkStaleDataAfterFailedRefresh(t *testing.T) {
+	m := seededModel()
+	m.width = 100
+	m.height = 20
+	if out := m.renderBody(); strings.Contains(out, "[stale") {
+		t.Fatalf("did not expect stale marker for fresh data, got:\n%s", out)
+	}
+
+	updated, _ := m.Update(fetchResultMsg{at: m.now.Add(3 * time.Minute), err: errors.New("boom")})
+	m = updated.(Model)
+	m.now = m.now.Add(3 * time.Minute)
+	out := m.renderBody()
+	if !strings.Contains(out, "used: 41% [stale 3m2s]") || !strings.Contains(out, "used: 69% [stale 3m2s]") {
+		t.Fatalf("expected last good values with stale age after failed refresh, got:\n%s", out)
+	}
+
+	updated, _ = m.Update(fetchResultMsg{at: m.now, summary: m.summary})
+	m = updated.(Model)
+	if out := m.renderBody(); strings.Contains(out, "[stale") {
+		t.Fatalf("expected stale marker to clear after success, got:\n%s", out)
+	}
+}
+
+func TestPercentBarsAreWidthAwareAndPlainWithoutColor(t *testing.T) {
+	m := seededModel()
+	if got := m.renderPercentBar(41, 19, m.styles.ok); got != "[####------] 41%" {
+		t.Fatalf("expected 10-cell bar, got %q", got)
+	}
+	if got := m.renderPercentBar(100, 8, m.styles.ok); got != "[#####] 100%" {
+		t.Fatalf("expected minimum-width bar, got %q", got)
+	}
+	if got := m.renderPercentBar(120, 19, m.styles.ok); !strings.HasPrefix(got, "[##########]") {
+		t.Fatalf("expected bar fill to clamp at 100%%, got %q", got)
+	}
+
+	m.width = 100
+	m.height = 24
+	m.showBars = true
+	out := m.renderBody()
+	if !strings.Contains(out, "] 69%") {
+		t.Fatalf("expected weekly bar in window panel, got:\n%s", out)
+	}
+	for _, line := range strings.Split(out, "\n") {
+		if lipgloss.Width(line) > m.width {
+			t.Fatalf("expected bars to fit the panel width, got line %q", line)
+		}
+	}
+}
+
+func TestWindowPanelRelativeTimeReplacesAbsoluteReset(t *testing.T) {
+	m := seededModel()
+	m.width = 100
+	m.height = 20
+	m.relativeTime = true
+	out := m.renderBody()
+	if !strings.Contains(out, "resets: in 1h30m") {
+		t.Fatalf("expected relative reset phrasing, got:\n%s", out)
+	}
+	if strings.Contains(out, "2026-02-26 16:30:00 UTC") {
+		t.Fatalf("did not expect absolute reset timestamp in relative mode")
+	}
+}
+
+func TestRelativeTimePhrasing(t *testing.T) {
+	now := time.Date(2026, 2, 26, 15, 0, 0, 0, time.UTC)
+	if got := relativeTime(now.Add(80*time.Minute), now); got != "in 1h20m" {
+		t.Fatalf("expected future phrasing, got %q", got)
+	}
+}
+
 func nthRuneIndex(runes []rune, target rune, n int) int {
 	if n <= 0 {
 		return -1
@@ -526,6 +1104,302 @@ func countStatusRows(s string) int {
 	return count
 }
 
+func TestAccountTableToggleListsEveryAccount(t *testing.T) {
+	m := seededMultiAccountModel()
+	m.width = 120
+	m.height = 40
+
+	next, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'t'}})
+	m = next.(Model)
+	if !m.showAccountTable {
+		t.Fatalf("expected t to enable the account table")
+	}
+	out := m.View()
+	for _, want := range []string{"identity", "5h tokens", "alpha@example.com", "bravo"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected account table to contain %q, got:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "five-hour window [alpha@example.com]") {
+		t.Fatalf("expected table to replace per-account window cards")
+	}
+
+	next, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'t'}})
+	m = next.(Model)
+	if strings.Contains(m.View(), "5h tokens") {
+		t.Fatalf("expected second t to hide the account table")
+	}
+}
+
+func TestAccountTableTruncatesToViewport(t *testing.T) {
+	m := seededMultiAccountModel()
+	m.showAccountTable = true
+	m.width = 60
+	m.height = 35
+	lines := strings.Split(m.View(), "\n")
+	if len(lines) != m.height {
+		t.Fatalf("expected %d lines, got %d", m.height, len(lines))
+	}
+	for _, line := range lines {
+		if w := lipgloss.Width(line); w > m.width {
+			t.Fatalf("line exceeds viewport width (%d > %d): %q", w, m.width, line)
+		}
+	}
+
+	table := m.renderAccountTable(80, 2)
+	if !strings.Contains(table, "+2 more") {
+		t.Fatalf("expected overflow rows to collapse into +N more, got:\n%s", table)
+	}
+}
+
+func TestShowStatsRendersFooterBesideExitHint(t *testing.T) {
+	m := NewModel(Options{
+		NoColor: true,
+		Stats: func() usage.ResourceStats {
+			return usage.ResourceStats{AppServerSessions: 2, ObservedCacheEntries: 3, Fetches: usage.FetchCounters{Fetches: 12, PrimarySuccesses: 10, FallbackSuccesses: 1, Failures: 1}}
+		},
+	})
+	m.width = 120
+	m.height = 30
+	lines := strings.Split(m.View(), "\n")
+	bottom := lines[len(lines)-1]
+	if !strings.Contains(bottom, "q to exit | r to refresh | l log") {
+		t.Fatalf("expected exit hint on bottom row, got %q", bottom)
+	}
+	for _, want := range []string{"app-server 2", "goroutines ", "heap ", "cache 3", "fetches 12 (fallback 1, failed 1)"} {
+		if !strings.Contains(bottom, want) {
+			t.Fatalf("expected stats footer to contain %q, got %q", want, bottom)
+		}
+	}
+
+	plain := seededModel()
+	plain.width = 120
+	plain.height = 30
+	if strings.Contains(plain.View(), "goroutines") {
+		t.Fatalf("expected no stats footer without --show-stats")
+	}
+}
+
+func TestHumanBytes(t *testing.T) {
+	cases := map[uint64]string{
+		512:             "512B",
+		2048:            "2.0KiB",
+		5 * 1024 * 1024: "5.0MiB",
+	}
+	for in, want := range cases {
+		if got := humanBytes(in); got != want {
+			t.Fatalf("humanBytes(%d) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestFetchFailuresBackOffAndRecoverOnSuccess(t *testing.T) {
+	m := seededModel()
+	m.width = 120
+	m.height = 30
+	failAt := m.now
+	fail := func() {
+		m.fetching = true
+		next, _ := m.Update(fetchResultMsg{at: failAt, err: errors.New("network down")})
+		m = next.(Model)
+	}
+
+	for i := 1; i < backoffAfterFailures; i++ {
+		fail()
+	}
+	if m.backingOff() || m.pollInterval() != m.interval {
+		t.Fatalf("expected normal polling below the failure threshold, got %s", m.pollInterval())
+	}
+
+	seq := m.pollSeq
+	fail()
+	if !m.backingOff() || m.pollInterval() != 2*m.interval || m.pollSeq == seq {
+		t.Fatalf("expected doubled interval and a rescheduled poll, got %s (seq %d)", m.pollInterval(), m.pollSeq)
+	}
+	if !strings.Contains(m.renderHeader(), "retrying in 30s (backoff)") {
+		t.Fatalf("expected backoff countdown in header, got %q", m.renderHeader())
+	}
+	if _, cmd := m.Update(pollTickMsg{at: failAt, seq: seq}); cmd != nil {
+		t.Fatalf("expected ticks from before the reschedule to be ignored")
+	}
+
+	for i := 0; i < 20; i++ {
+		fail()
+	}
+	if m.pollInterval() != maxBackoffInterval {
+		t.Fatalf("expected backoff to cap at %s, got %s", maxBackoffInterval, m.pollInterval())
+	}
+
+	m.fetching = true
+	next, cmd := m.Update(fetchResultMsg{at: failAt, summary: m.summary})
+	m = next.(Model)
+	if m.backingOff() || m.pollInterval() != m.interval || cmd == nil {
+		t.Fatalf("expected success to reset backoff and reschedule normal polling")
+	}
+}
+
+func TestMaxConsecutiveFailuresQuitsOnlyWhenReached(t *testing.T) {
+	m := seededModel()
+	m.maxConsecutiveFailures = 2
+
+	updated, cmd := m.Update(fetchResultMsg{at: m.now, err: errors.New("boom")})
+	m = updated.(Model)
+	if m.gaveUp || cmd != nil {
+		t.Fatalf("did not expect to give up after one failure")
+	}
+	updated, cmd = m.Update(fetchResultMsg{at: m.now, err: errors.New("boom")})
+	m = updated.(Model)
+	if !m.gaveUp || cmd == nil {
+		t.Fatalf("expected to give up after two consecutive failures")
+	}
+	if _, ok := cmd().(tea.QuitMsg); !ok {
+		t.Fatalf("expected quit command")
+	}
+
+	unlimited := seededModel()
+	for i := 0; i < 10; i++ {
+		updated, _ = unlimited.Update(fetchResultMsg{at: unlimited.now, err: errors.New("boom")})
+		unlimited = updated.(Model)
+	}
+	if unlimited.gaveUp {
+		t.Fatalf("expected default of 0 to never give up")
+	}
+}
+
+func TestPollSummaryCountsFetchOutcomes(t *testing.T) {
+	m := seededModel()
+	m.width = 120
+	m.height = 30
+	if m.pollSummary() != "" {
+		t.Fatalf("expected no poll summary before the first result")
+	}
+	for i := 0; i < 40; i++ {
+		var err error
+		if i == 0 {
+			err = errors.New("timeout")
+		}
+		next, _ := m.Update(fetchResultMsg{at: m.now, summary: m.summary, err: err})
+		m = next.(Model)
+	}
+	if got := m.pollSummary(); got != "polls: 40, failures: 1 (97.5%)" {
+		t.Fatalf("unexpected poll summary %q", got)
+	}
+	if !strings.Contains(m.View(), "q to exit | r to refresh | l log | polls: 40, failures: 1 (97.5%)") {
+		t.Fatalf("expected poll summary in footer, got:\n%s", m.View())
+	}
+
+	m.failureCount = 0
+	if got := m.pollSummary(); got != "polls: 40, failures: 0 (100%)" {
+		t.Fatalf("expected whole-number rate without decimals, got %q", got)
+	}
+}
+
+func TestHardRefreshClearsCacheAndStartsFetch(t *testing.T) {
+	cleared := 0
+	m := seededModel()
+	m.clearCache = func() { cleared++ }
+
+	next, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'R'}})
+	m = next.(Model)
+	if cleared != 1 {
+		t.Fatalf("expected R to clear the observed cache once, got %d", cleared)
+	}
+	if !m.fetching || cmd == nil {
+		t.Fatalf("expected R to start a fetch immediately")
+	}
+
+	next, cmd = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'R'}})
+	m = next.(Model)
+	if cleared != 2 {
+		t.Fatalf("expected R to clear the cache while fetching, got %d", cleared)
+	}
+	if cmd != nil {
+		t.Fatalf("expected no overlapping fetch while one is in flight")
+	}
+}
+
+func TestRefreshKeyFetchesNowAndResetsPollTimer(t *testing.T) {
+	m := seededModel()
+	m.nextFetchAt = m.now.Add(5 * time.Second)
+	seq := m.pollSeq
+
+	next, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'r'}})
+	m = next.(Model)
+	if !m.fetching || cmd == nil {
+		t.Fatalf("expected r to start a fetch immediately")
+	}
+	if m.pollSeq != seq+1 || !m.nextFetchAt.Equal(m.now.Add(m.interval)) {
+		t.Fatalf("expected r to restart the poll timer, got next fetch %v", m.nextFetchAt)
+	}
+
+	if next, cmd = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'r'}}); cmd != nil || next.(Model).pollSeq != m.pollSeq {
+		t.Fatalf("expected r to be ignored while a fetch is in flight")
+	}
+}
+
+func TestQuitKeys(t *testing.T) {
+	for _, key := range []tea.KeyMsg{
+		{Type: tea.KeyRunes, Runes: []rune{'q'}},
+		{Type: tea.KeyEsc},
+		{Type: tea.KeyCtrlC},
+	} {
+		_, cmd := seededModel().Update(key)
+		if cmd == nil {
+			t.Fatalf("expected %q to quit", key.String())
+		}
+		if _, ok := cmd().(tea.QuitMsg); !ok {
+			t.Fatalf("expected %q to quit", key.String())
+		}
+	}
+}
+
+func TestFocusRefreshIsOptInAndSingleFlight(t *testing.T) {
+	m := seededModel()
+	next, cmd := m.Update(tea.FocusMsg{})
+	if next.(Model).fetching || cmd != nil {
+		t.Fatalf("expected focus to be ignored without --refresh-on-focus")
+	}
+
+	m.refreshOnFocus = true
+	next, cmd = m.Update(tea.FocusMsg{})
+	m = next.(Model)
+	if !m.fetching || cmd == nil {
+		t.Fatalf("expected focus to start a fetch")
+	}
+	if _, cmd = m.Update(tea.FocusMsg{}); cmd != nil {
+		t.Fatalf("expected no overlapping fetch while one is in flight")
+	}
+}
+
+func TestCountFormatStyles(t *testing.T) {
+	cases := map[CountFormat]string{
+		CountFormatShort: "1.23m",
+		CountFormatFull:  "1234567",
+		CountFormatUpper: "1.23M",
+	}
+	for format, want := range cases {
+		m := Model{countFormat: format}
+		if got := m.formatCount(1234567); got != want {
+			t.Fatalf("format %q: expected %q, got %q", format, want, got)
+		}
+	}
+	if got := (Model{}).formatCount(45600); got != "45.6k" {
+		t.Fatalf("expected zero-value model to use short format, got %q", got)
+	}
+}
+
+func TestParseCountFormat(t *testing.T) {
+	for input, want := range map[string]CountFormat{"": CountFormatShort, "FULL": CountFormatFull, " upper ": CountFormatUpper} {
+		got, err := ParseCountFormat(input)
+		if err != nil || got != want {
+			t.Fatalf("ParseCountFormat(%q) = %q, %v; want %q", input, got, err, want)
+		}
+	}
+	if _, err := ParseCountFormat("si"); err == nil {
+		t.Fatalf("expected error for unsupported count format")
+	}
+}
+
 func seededModel() Model {
 	now := time.Date(2026, 2, 26, 15, 0, 0, 0, time.UTC)
 	reset1 := now.Add(90 * time.Minute)
@@ -621,3 +1495,139 @@ func seededMultiAccountModel() Model {
 	}
 	return m
 }
+
+func TestPercentStyleUsesConfiguredThresholds(t *testing.T) {
+	s := defaultStyles(false, false, false)
+	custom := Thresholds{WarnAt: 60, BadAt: 85}
+	cases := []struct {
+		percent    int
+		thresholds Thresholds
+		want       lipgloss.Style
+	}{
+		{percent: 65, thresholds: DefaultThresholds, want: s.ok},
+		{percent: 65, thresholds: custom, want: s.warn},
+		{percent: 85, thresholds: custom, want: s.bad},
+		{percent: 85, thresholds: DefaultThresholds, want: s.warn},
+	}
+	for _, tc := range cases {
+		got := percentStyle(tc.percent, tc.thresholds, s)
+		if got.GetForeground() != tc.want.GetForeground() {
+			t.Fatalf("%d%% with %+v: expected %v, got %v", tc.percent, tc.thresholds, tc.want.GetForeground(), got.GetForeground())
+		}
+	}
+
+	if err := custom.Validate(); err != nil {
+		t.Fatalf("expected %+v to be valid, got %v", custom, err)
+	}
+	for _, bad := range []Thresholds{{WarnAt: 90, BadAt: 90}, {WarnAt: 80, BadAt: 70}, {WarnAt: -1, BadAt: 50}, {WarnAt: 50, BadAt: 101}} {
+		if err := bad.Validate(); err == nil {
+			t.Fatalf("expected %+v to be rejected", bad)
+		}
+	}
+}
+
+func TestAnnounceWritesOneLinePerStateChange(t *testing.T) {
+	var out strings.Builder
+	m := NewModel(Options{Announce: &out})
+	at := time.Date(2026, 3, 2, 9, 30, 0, 0, time.UTC)
+	resetAt := at.Add(2 * time.Hour)
+	summary := func(primary, weekly int, reset time.Time) *usage.Summary {
+		return &usage.Summary{
+			WindowDataAvailable: true,
+			PrimaryWindow:       usage.WindowSummary{UsedPercent: primary, ResetsAt: &reset},
+			SecondaryWindow:     usage.WindowSummary{UsedPercent: weekly},
+		}
+	}
+	steps := []fetchResultMsg{
+		{at: at, summary: summary(38, 12, resetAt)},
+		{at: at.Add(time.Minute), summary: summary(38, 12, resetAt)},
+		{at: at.Add(2 * time.Minute), summary: summary(42, 12, resetAt)},
+		{at: at.Add(3 * time.Minute), err: errors.New("network down")},
+		{at: at.Add(4 * time.Minute), err: errors.New("network down")},
+		{at: at.Add(5 * time.Minute), summary: summary(42, 12, resetAt)},
+		{at: at.Add(6 * time.Minute), summary: summary(0, 13, resetAt.Add(5*time.Hour))},
+	}
+	for _, step := range steps {
+		next, _ := m.Update(step)
+		m = next.(Model)
+	}
+	want := strings.Join([]string{
+		"09:30 5h 38%; weekly 12%",
+		"09:32 5h 42% (was 38%)",
+		"09:33 fetch failed: network down",
+		"09:35 fetch recovered",
+		"09:36 5h window reset, now 0%; weekly 13% (was 12%)",
+	}, "\n") + "\n"
+	if out.String() != want {
+		t.Fatalf("unexpected announcements:\n%s\nwant:\n%s", out.String(), want)
+	}
+	if m.spinnerEnabled {
+		t.Fatalf("expected announcements to disable the spinner")
+	}
+}
+
+func TestEventLogRecordsChangesAndFitsViewport(t *testing.T) {
+	m := seededModel()
+	m.width = 120
+	m.height = 40
+	at := m.now
+	base := *m.summary
+	base.WindowDataAvailable = true
+	base.PrimaryWindow.UsedPercent = 60
+
+	reset := base
+	reset.PrimaryWindow.UsedPercent = 2
+	later := base.PrimaryWindow.ResetsAt.Add(5 * time.Hour)
+	reset.PrimaryWindow.ResetsAt = &later
+	reset.Accounts = append([]usage.AccountSummary{}, base.Accounts...)
+	reset.Accounts = append(reset.Accounts, usage.AccountSummary{Label: "charlie"})
+
+	steps := []fetchResultMsg{
+		{at: at, summary: &base},
+		{at: at.Add(time.Minute), err: errors.New("network down")},
+		{at: at.Add(2 * time.Minute), err: errors.New("network down")},
+		{at: at.Add(3 * time.Minute), summary: &base},
+		{at: at.Add(4 * time.Minute), summary: &reset},
+	}
+	m.summary = nil
+	for _, step := range steps {
+		next, _ := m.Update(step)
+		m = next.(Model)
+	}
+	var got []string
+	for _, event := range m.events {
+		got = append(got, event.text)
+	}
+	want := []string{
+		"first fetch succeeded",
+		"fetch failed: network down",
+		"fetch succeeded after failure",
+		"account added: charlie",
+		"5h window reset",
+	}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Fatalf("unexpected events:\n%v\nwant:\n%v", got, want)
+	}
+
+	next, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("l")})
+	m = next.(Model)
+	view := m.View()
+	if !strings.Contains(view, "event log (l hides)") || !strings.Contains(view, "15:04:00 5h window reset") {
+		t.Fatalf("expected the event log pane with the newest events, got:\n%s", view)
+	}
+	if strings.Contains(view, "first fetch succeeded") {
+		t.Fatalf("expected only the newest %d events, got:\n%s", eventLogMaxRows-1, view)
+	}
+	lines := strings.Split(view, "\n")
+	if len(lines) != m.height || !strings.Contains(lines[len(lines)-1], "q to exit | r to refresh | l log") {
+		t.Fatalf("expected the pane to fit above the pinned footer, got:\n%s", view)
+	}
+
+	m.lastError = ""
+	for i := 0; i < eventLogCapacity+10; i++ {
+		m.recordEvents(at, nil, "")
+	}
+	if len(m.events) != eventLogCapacity {
+		t.Fatalf("expected the log to stay bounded at %d, got %d", eventLogCapacity, len(m.events))
+	}
+}
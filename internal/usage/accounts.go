@@ -15,8 +15,32 @@ const (
 	legacyMonitorDirName    = ".codex-usage-monitor"
 	defaultAccountsFileName = "accounts.json"
 	accountsFileEnvVar      = "CODEX_USAGE_MONITOR_ACCOUNTS_FILE"
+	defaultConfigFileName   = "config.json"
+	configFileEnvVar        = "CODEX_USAGE_MONITOR_CONFIG_FILE"
 )
 
+// ErrNoUserHome means the home directory is unset or "/", as in minimal
+// containers. Only an explicit CODEX_HOME works there.
+var ErrNoUserHome = errors.New("no usable home directory (HOME is unset or /); set CODEX_HOME to the codex home to monitor")
+
+func userHomeDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil || strings.TrimSpace(home) == "" || filepath.Clean(home) == string(filepath.Separator) {
+		return "", ErrNoUserHome
+	}
+	return home, nil
+}
+
+// CheckHomeEnvironment fails early with ErrNoUserHome when neither a home
+// directory nor CODEX_HOME can locate a codex home.
+func CheckHomeEnvironment() error {
+	if strings.TrimSpace(os.Getenv("CODEX_HOME")) != "" {
+		return nil
+	}
+	_, err := userHomeDir()
+	return err
+}
+
 type accountFile struct {
 	Version  int           `json:"version"`
 	Accounts []accountItem `json:"accounts"`
@@ -50,27 +74,38 @@ func loadMonitorAccounts() ([]MonitorAccount, string, error) {
 		}
 	}
 
-	fileAccounts, fileWarning, fileErr := loadAccountsFromFile()
-	if fileErr != nil {
-		collector.warnf("accounts file could not be read: %v", fileErr)
-	} else {
-		if fileWarning != "" {
-			collector.warnf("%s", fileWarning)
-		}
-		for _, account := range fileAccounts {
-			collector.add(account.Label, account.CodexHome, 100, true)
-		}
+	// Without a home directory the accounts file and auto discovery fail for
+	// the same reason, so say it once instead of surfacing both errors.
+	_, homeErr := userHomeDir()
+	if homeErr != nil {
+		collector.warnf("%v; monitoring CODEX_HOME only", homeErr)
 	}
 
-	autoAccounts, autoWarning, autoErr := discoverMonitorAccountsFromFilesystem()
-	if autoErr != nil {
-		collector.warnf("auto discovery error: %v", autoErr)
-	} else {
-		if autoWarning != "" {
-			collector.warnf("%s", autoWarning)
+	if homeErr == nil || strings.TrimSpace(os.Getenv(accountsFileEnvVar)) != "" {
+		fileAccounts, fileWarning, fileErr := loadAccountsFromFile()
+		if fileErr != nil {
+			collector.warnf("accounts file could not be read: %v", fileErr)
+		} else {
+			if fileWarning != "" {
+				collector.warnf("%s", fileWarning)
+			}
+			for _, account := range fileAccounts {
+				collector.add(account.Label, account.CodexHome, 100, true)
+			}
 		}
-		for _, account := range autoAccounts {
-			collector.add(account.Label, account.CodexHome, 30, false)
+	}
+
+	if homeErr == nil {
+		autoAccounts, autoWarning, autoErr := discoverMonitorAccountsFromFilesystem()
+		if autoErr != nil {
+			collector.warnf("auto discovery error: %v", autoErr)
+		} else {
+			if autoWarning != "" {
+				collector.warnf("%s", autoWarning)
+			}
+			for _, account := range autoAccounts {
+				collector.add(account.Label, account.CodexHome, 30, false)
+			}
 		}
 	}
 
@@ -129,9 +164,9 @@ func loadAccountsFromFile() ([]MonitorAccount, string, error) {
 }
 
 func discoverMonitorAccountsFromFilesystem() ([]MonitorAccount, string, error) {
-	home, err := os.UserHomeDir()
+	home, err := userHomeDir()
 	if err != nil {
-		return nil, "", fmt.Errorf("resolve home directory: %w", err)
+		return nil, "", err
 	}
 	paths, warnings, err := discoverCodexHomesFromSystem(home)
 	if err != nil {
@@ -318,9 +353,9 @@ func resolveAccountsFilePath() (string, error) {
 		return defaultPath, nil
 	}
 
-	home, err := os.UserHomeDir()
+	home, err := userHomeDir()
 	if err != nil {
-		return "", fmt.Errorf("resolve home directory: %w", err)
+		return "", err
 	}
 	legacyPath := filepath.Join(home, legacyMonitorDirName, defaultAccountsFileName)
 	if fileExists(legacyPath) {
@@ -329,16 +364,34 @@ func resolveAccountsFilePath() (string, error) {
 	return defaultPath, nil
 }
 
+// ConfigFilePath locates the optional flag-defaults file:
+// CODEX_USAGE_MONITOR_CONFIG_FILE when set, else config.json in the monitor
+// data dir. The file may not exist.
+func ConfigFilePath() (string, error) {
+	if explicit := strings.TrimSpace(os.Getenv(configFileEnvVar)); explicit != "" {
+		return expandPath(explicit)
+	}
+	dir, err := monitorDataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, defaultConfigFileName), nil
+}
+
 func monitorDataDir() (string, error) {
-	home, err := os.UserHomeDir()
+	home, err := userHomeDir()
 	if err != nil {
-		return "", fmt.Errorf("resolve home directory: %w", err)
+		return "", err
 	}
 	return filepath.Join(home, defaultMonitorDirName), nil
 }
 
 func EnsureMonitorDataDir() error {
 	dir, err := monitorDataDir()
+	if errors.Is(err, ErrNoUserHome) {
+		// Nothing to create; CheckHomeEnvironment already explains this.
+		return nil
+	}
 	if err != nil {
 		return err
 	}
@@ -349,9 +402,9 @@ func defaultCodexHome() (string, error) {
 	if codexHome := strings.TrimSpace(os.Getenv("CODEX_HOME")); codexHome != "" {
 		return expandPath(codexHome)
 	}
-	home, err := os.UserHomeDir()
+	home, err := userHomeDir()
 	if err != nil {
-		return "", fmt.Errorf("resolve home directory: %w", err)
+		return "", err
 	}
 	return filepath.Join(home, ".codex"), nil
 }
@@ -361,16 +414,16 @@ func expandPath(path string) (string, error) {
 		return "", nil
 	}
 	if path == "~" {
-		home, err := os.UserHomeDir()
+		home, err := userHomeDir()
 		if err != nil {
-			return "", fmt.Errorf("resolve home directory: %w", err)
+			return "", err
 		}
 		return home, nil
 	}
 	if strings.HasPrefix(path, "~/") {
-		home, err := os.UserHomeDir()
+		home, err := userHomeDir()
 		if err != nil {
-			return "", fmt.Errorf("resolve home directory: %w", err)
+			return "", err
 		}
 		return filepath.Join(home, path[2:]), nil
 	}
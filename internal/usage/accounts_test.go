@@ -1,6 +1,7 @@
 package usage
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
 	"strings"
@@ -209,3 +210,37 @@ func TestResolveAccountsFilePathPrefersDefaultDir(t *testing.T) {
 		t.Fatalf("expected default path %q, got %q", defaultFile, path)
 	}
 }
+
+func TestLoadMonitorAccountsWithoutHomeNeedsCodexHome(t *testing.T) {
+	t.Setenv("HOME", "")
+	t.Setenv("CODEX_HOME", "")
+	t.Setenv(accountsFileEnvVar, "")
+
+	if _, _, err := loadMonitorAccounts(); !errors.Is(err, ErrNoUserHome) {
+		t.Fatalf("expected ErrNoUserHome, got %v", err)
+	}
+	if err := CheckHomeEnvironment(); !errors.Is(err, ErrNoUserHome) || !strings.Contains(err.Error(), "set CODEX_HOME") {
+		t.Fatalf("expected a CODEX_HOME hint, got %v", err)
+	}
+
+	t.Setenv("HOME", "/")
+	if err := CheckHomeEnvironment(); !errors.Is(err, ErrNoUserHome) {
+		t.Fatalf("expected a root home to be rejected, got %v", err)
+	}
+
+	codexHome := t.TempDir()
+	t.Setenv("CODEX_HOME", codexHome)
+	if err := CheckHomeEnvironment(); err != nil {
+		t.Fatalf("expected an explicit CODEX_HOME to pass, got %v", err)
+	}
+	accounts, warning, err := loadMonitorAccounts()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(accounts) != 1 || accounts[0].CodexHome != codexHome {
+		t.Fatalf("expected only CODEX_HOME, got %+v", accounts)
+	}
+	if strings.Count(warning, "no usable home directory") != 1 || strings.Contains(warning, "auto discovery") {
+		t.Fatalf("expected a single home warning, got %q", warning)
+	}
+}
@@ -2,6 +2,7 @@ package usage
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
@@ -11,16 +12,34 @@ import (
 	"io"
 	"os"
 	"os/exec"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 )
 
+// Version is the monitor's release version, reported to the app-server and by
+// the version command. Release builds set it with
+// -ldflags "-X github.com/olliecrow/codex_usage_monitor/internal/usage.Version=v1.2.3".
+var Version = "dev"
+
 const (
-	clientName    = "codex-usage-monitor"
-	clientVersion = "0.1.0"
+	clientName = "codex-usage-monitor"
+
+	codexBinEnvVar      = "CODEX_USAGE_MONITOR_CODEX_BIN"
+	codexExtraEnvPrefix = "CODEX_USAGE_MONITOR_CODEX_ENV_"
+	noAuthRestartEnvVar = "CODEX_USAGE_MONITOR_NO_AUTH_RESTART"
 )
 
+// codexBinary is the codex executable to run; CODEX_USAGE_MONITOR_CODEX_BIN
+// overrides the PATH lookup (used for tests and non-standard installs).
+func codexBinary() string {
+	if explicit := strings.TrimSpace(os.Getenv(codexBinEnvVar)); explicit != "" {
+		return explicit
+	}
+	return "codex"
+}
+
 type rpcRequest struct {
 	JSONRPC string `json:"jsonrpc"`
 	ID      *int   `json:"id,omitempty"`
@@ -50,13 +69,15 @@ type clientInfo struct {
 }
 
 type AppServerSource struct {
-	mu      sync.Mutex
-	reqMu   sync.Mutex
-	session *appServerSession
+	mu       sync.Mutex
+	reqMu    sync.Mutex
+	session  *appServerSession
+	inflight *fetchCall
 
 	codexHome         string
 	authFingerprint   string
 	authFingerprintFn func() (string, error)
+	authCache         authFingerprintCache
 }
 
 func NewAppServerSource() *AppServerSource {
@@ -72,7 +93,62 @@ func (s *AppServerSource) Name() string {
 	return "app-server"
 }
 
+// Fetch coalesces concurrent callers: while one fetch is in flight, others
+// wait for and share its result instead of queueing their own round trip.
+// A shared fetch cut short by its leader's context is not handed to waiters
+// whose own context is still live; they retry instead.
 func (s *AppServerSource) Fetch(ctx context.Context) (*Summary, error) {
+	for {
+		s.mu.Lock()
+		call := s.inflight
+		if call == nil {
+			break
+		}
+		s.mu.Unlock()
+		select {
+		case <-call.done:
+			if call.leaderCanceled && ctx.Err() == nil {
+				continue
+			}
+			return call.result()
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	call := &fetchCall{done: make(chan struct{})}
+	s.inflight = call
+	s.mu.Unlock()
+
+	call.summary, call.err = s.fetch(ctx)
+	call.leaderCanceled = call.err != nil && ctx.Err() != nil
+
+	s.mu.Lock()
+	s.inflight = nil
+	s.mu.Unlock()
+	close(call.done)
+	return call.result()
+}
+
+type fetchCall struct {
+	done           chan struct{}
+	summary        *Summary
+	err            error
+	leaderCanceled bool
+}
+
+// result hands each caller its own copy so callers can annotate summaries
+// without racing.
+func (c *fetchCall) result() (*Summary, error) {
+	if c.summary == nil {
+		return nil, c.err
+	}
+	out := *c.summary
+	out.Warnings = append([]string(nil), c.summary.Warnings...)
+	out.Accounts = append([]AccountSummary(nil), c.summary.Accounts...)
+	return &out, c.err
+}
+
+func (s *AppServerSource) fetch(ctx context.Context) (*Summary, error) {
 	s.reqMu.Lock()
 	defer s.reqMu.Unlock()
 
@@ -87,11 +163,30 @@ func (s *AppServerSource) Fetch(ctx context.Context) (*Summary, error) {
 	}
 
 	result, err := session.fetchRateLimits(ctx)
-	if err != nil {
+	var closed *sessionClosedError
+	if errors.As(err, &closed) && ctx.Err() == nil {
+		// A subprocess that died between polls usually starts cleanly again;
+		// one restart per fetch keeps a crash loop from hiding behind retries.
 		s.resetSession()
+		warnings = append(warnings, fmt.Sprintf("app-server session ended (%v); restarted it", err))
+		if session, err = s.ensureSession(ctx); err != nil {
+			return nil, err
+		}
+		result, err = session.fetchRateLimits(ctx)
+	}
+	if err != nil {
+		// A logged-out session is still healthy; refreshAuthState restarts it
+		// once auth.json changes.
+		if !errors.Is(err, ErrAuthRequired) {
+			s.resetSession()
+		}
 		return nil, err
 	}
 
+	if result.UsedAlternateKeys {
+		warnings = append(warnings, "app-server returned rate limits under alternate key names")
+	}
+
 	additional := 0
 	if len(result.RateLimitsByLimitID) > 1 {
 		additional = len(result.RateLimitsByLimitID) - 1
@@ -102,7 +197,58 @@ func (s *AppServerSource) Fetch(ctx context.Context) (*Summary, error) {
 		warnings = append(warnings, fmt.Sprintf("account identity unavailable: %v", err))
 	}
 
-	return normalizeSummary(s.Name(), result.RateLimits, additional, identity, warnings)
+	summary, err := normalizeSummary(s.Name(), result.RateLimits, additional, identity, warnings)
+	if err != nil {
+		return nil, err
+	}
+	summary.AdditionalLimits = appServerAdditionalLimits(result)
+	return summary, nil
+}
+
+// Prestart starts and initializes the session ahead of the first fetch, which
+// waits for it instead of starting a second one.
+func (s *AppServerSource) Prestart(ctx context.Context) error {
+	s.reqMu.Lock()
+	defer s.reqMu.Unlock()
+	_, err := s.ensureSession(ctx)
+	return err
+}
+
+// appServerAdditionalLimits lists the by-limit-id snapshots other than the
+// main one, ordered by limit id. The main limit is "codex" when unnamed.
+func appServerAdditionalLimits(result *rateLimitsReadResultRaw) []AdditionalLimit {
+	mainID := result.RateLimits.LimitID
+	if mainID == "" {
+		mainID = "codex"
+	}
+	ids := make([]string, 0, len(result.RateLimitsByLimitID))
+	for id := range result.RateLimitsByLimitID {
+		if id != mainID {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	sort.Strings(ids)
+	out := make([]AdditionalLimit, 0, len(ids))
+	for _, id := range ids {
+		snapshot := result.RateLimitsByLimitID[id]
+		entry := AdditionalLimit{Name: id}
+		if snapshot.LimitName != nil && strings.TrimSpace(*snapshot.LimitName) != "" {
+			entry.Name = strings.TrimSpace(*snapshot.LimitName)
+		}
+		if snapshot.Primary != nil {
+			win := toWindowSummary(snapshot.Primary)
+			entry.PrimaryWindow = &win
+		}
+		if snapshot.Secondary != nil {
+			win := toWindowSummary(snapshot.Secondary)
+			entry.SecondaryWindow = &win
+		}
+		out = append(out, entry)
+	}
+	return out
 }
 
 func (s *AppServerSource) Close() error {
@@ -135,6 +281,13 @@ func (s *AppServerSource) ensureSession(ctx context.Context) (*appServerSession,
 	return session, nil
 }
 
+func (s *AppServerSource) sessionRunning() bool {
+	s.mu.Lock()
+	session := s.session
+	s.mu.Unlock()
+	return session != nil && session.running()
+}
+
 func (s *AppServerSource) resetSession() {
 	s.mu.Lock()
 	session := s.session
@@ -149,7 +302,7 @@ func (s *AppServerSource) refreshAuthState() string {
 	fingerprintFn := s.authFingerprintFn
 	if fingerprintFn == nil {
 		fingerprintFn = func() (string, error) {
-			return currentAuthFingerprintForHome(s.codexHome)
+			return s.authCache.fingerprint(s.codexHome, readAccessToken)
 		}
 	}
 
@@ -158,8 +311,11 @@ func (s *AppServerSource) refreshAuthState() string {
 		if s.authFingerprint == "" {
 			return ""
 		}
-		s.resetSession()
 		s.authFingerprint = ""
+		if authRestartDisabled() {
+			return ""
+		}
+		s.resetSession()
 		return "auth state changed; restarted app-server session"
 	}
 
@@ -171,22 +327,62 @@ func (s *AppServerSource) refreshAuthState() string {
 		return ""
 	}
 
-	s.resetSession()
 	s.authFingerprint = fingerprint
+	if authRestartDisabled() {
+		return ""
+	}
+	s.resetSession()
 	return "auth state changed; restarted app-server session"
 }
 
-func currentAuthFingerprintForHome(codexHome string) (string, error) {
+// authRestartDisabled reports whether CODEX_USAGE_MONITOR_NO_AUTH_RESTART opts
+// out of restarting on auth changes; the session then only restarts after a
+// fetch failure.
+func authRestartDisabled() bool {
+	switch strings.ToLower(strings.TrimSpace(os.Getenv(noAuthRestartEnvVar))) {
+	case "", "0", "false":
+		return false
+	default:
+		return true
+	}
+}
+
+// authFingerprintCache remembers the last auth.json fingerprint by path,
+// modtime, and size so steady polling does not re-read and re-hash the file.
+type authFingerprintCache struct {
+	path    string
+	modTime time.Time
+	size    int64
+	value   string
+}
+
+func (c *authFingerprintCache) fingerprint(codexHome string, readToken func(string) (string, error)) (string, error) {
 	authPath, err := findAuthJSONPathForHome(codexHome)
 	if err != nil {
+		*c = authFingerprintCache{}
 		return "", err
 	}
-	token, err := readAccessToken(authPath)
+	info, err := os.Stat(authPath)
+	if err != nil {
+		*c = authFingerprintCache{}
+		return "", err
+	}
+	if c.value != "" && c.path == authPath && c.modTime.Equal(info.ModTime()) && c.size == info.Size() {
+		return c.value, nil
+	}
+	token, err := readToken(authPath)
 	if err != nil {
+		*c = authFingerprintCache{}
 		return "", err
 	}
 	sum := sha256.Sum256([]byte(token))
-	return authPath + ":" + hex.EncodeToString(sum[:]), nil
+	*c = authFingerprintCache{
+		path:    authPath,
+		modTime: info.ModTime(),
+		size:    info.Size(),
+		value:   authPath + ":" + hex.EncodeToString(sum[:]),
+	}
+	return c.value, nil
 }
 
 type appServerSession struct {
@@ -236,12 +432,8 @@ func (s *appServerSession) ensureStarted() error {
 		}
 	}
 
-	cmd := exec.Command("codex", "-s", "read-only", "-a", "untrusted", "app-server")
-	env := os.Environ()
-	if s.codexHome != "" {
-		env = upsertEnvVar(env, "CODEX_HOME", s.codexHome)
-	}
-	cmd.Env = env
+	cmd := exec.Command(codexBinary(), "-s", "read-only", "-a", "untrusted", "app-server")
+	cmd.Env = appServerEnv(os.Environ(), s.codexHome)
 
 	stdin, err := cmd.StdinPipe()
 	if err != nil {
@@ -284,7 +476,7 @@ func (s *appServerSession) ensureInitialized(ctx context.Context) error {
 	if err := s.request(ctx, "initialize", initializeParams{
 		ClientInfo: clientInfo{
 			Name:    clientName,
-			Version: clientVersion,
+			Version: Version,
 		},
 		Capabilities: map[string]interface{}{},
 	}, &initResult); err != nil {
@@ -304,8 +496,14 @@ func (s *appServerSession) ensureInitialized(ctx context.Context) error {
 func (s *appServerSession) fetchRateLimits(ctx context.Context) (*rateLimitsReadResultRaw, error) {
 	var out rateLimitsReadResultRaw
 	if err := s.request(ctx, "account/rateLimits/read", map[string]interface{}{}, &out); err != nil {
+		if errors.Is(err, ErrAuthRequired) {
+			return nil, fmt.Errorf("app-server requires login: %w", err)
+		}
 		return nil, err
 	}
+	if out.RequiresOpenAIAuth && out.RateLimits.Primary == nil && out.RateLimits.Secondary == nil {
+		return nil, fmt.Errorf("app-server requires login: account/rateLimits/read returned no rate limits: %w", ErrAuthRequired)
+	}
 	return &out, nil
 }
 
@@ -316,7 +514,7 @@ func (s *appServerSession) fetchAccount(ctx context.Context) (*identityInfo, err
 	}
 	if out.Account == nil {
 		if out.RequiresOpenAIAuth {
-			return nil, errors.New("account/read requires OpenAI auth")
+			return nil, fmt.Errorf("account/read requires OpenAI auth: %w", ErrAuthRequired)
 		}
 		return nil, errors.New("account/read missing account")
 	}
@@ -346,17 +544,21 @@ func (s *appServerSession) request(ctx context.Context, method string, params an
 	done := s.done
 	if encodeErr != nil {
 		delete(s.pending, reqID)
+		close(respCh)
 		s.mu.Unlock()
-		return fmt.Errorf("send request %s: %w", method, encodeErr)
+		return &sessionClosedError{fmt.Errorf("send request %s: %w", method, encodeErr)}
 	}
 	s.mu.Unlock()
 
 	select {
 	case msg, ok := <-respCh:
 		if !ok {
-			return fmt.Errorf("request %s aborted: %w", method, s.doneErrSnapshot())
+			return &sessionClosedError{fmt.Errorf("request %s aborted: %w", method, s.doneErrSnapshot())}
 		}
 		if msg.Error != nil {
+			if isAuthRPCErrorMessage(msg.Error.Message) {
+				return fmt.Errorf("%s failed: %s: %w", method, msg.Error.Message, ErrAuthRequired)
+			}
 			return fmt.Errorf("%s failed: %s", method, msg.Error.Message)
 		}
 		if out != nil {
@@ -366,18 +568,40 @@ func (s *appServerSession) request(ctx context.Context, method string, params an
 		}
 		return nil
 	case <-ctx.Done():
-		s.mu.Lock()
-		delete(s.pending, reqID)
-		s.mu.Unlock()
+		s.abandon(reqID)
 		return fmt.Errorf("%s timeout: %w", method, ctx.Err())
 	case <-done:
-		s.mu.Lock()
+		s.abandon(reqID)
+		return &sessionClosedError{fmt.Errorf("%s failed: %w", method, s.doneErrSnapshot())}
+	}
+}
+
+// sessionClosedError marks a request lost because the subprocess went away,
+// as opposed to an RPC, auth, or decode failure.
+type sessionClosedError struct {
+	err error
+}
+
+func (e *sessionClosedError) Error() string { return e.err.Error() }
+func (e *sessionClosedError) Unwrap() error { return e.err }
+
+// abandon drops a request nobody is waiting on. If readLoop already claimed
+// the channel it closes it after sending; otherwise we close it here.
+func (s *appServerSession) abandon(reqID int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if ch, ok := s.pending[reqID]; ok {
 		delete(s.pending, reqID)
-		s.mu.Unlock()
-		return fmt.Errorf("%s failed: %w", method, s.doneErrSnapshot())
+		close(ch)
 	}
 }
 
+func (s *appServerSession) pendingCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.pending)
+}
+
 func (s *appServerSession) notify(method string, params any) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -396,34 +620,24 @@ func (s *appServerSession) notify(method string, params any) error {
 }
 
 func (s *appServerSession) readLoop(stdout io.Reader) {
-	scanner := bufio.NewScanner(stdout)
-	scanner.Buffer(make([]byte, 0, 64*1024), 2*1024*1024)
-
-	for scanner.Scan() {
-		line := scanner.Bytes()
-
-		var msg rpcMessage
-		if err := json.Unmarshal(line, &msg); err != nil {
-			continue
-		}
-		if msg.ID == nil {
-			continue
+	// ReadBytes grows past bufio's buffer, so oversized responses are not
+	// dropped. Messages are newline-delimited; a line that is not JSON, such
+	// as a banner or log line, is skipped rather than ending the session.
+	reader := bufio.NewReaderSize(stdout, 64*1024)
+
+	var streamErr error
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			s.dispatch(line)
 		}
-
-		s.mu.Lock()
-		respCh := s.pending[*msg.ID]
-		if respCh != nil {
-			delete(s.pending, *msg.ID)
-		}
-		s.mu.Unlock()
-
-		if respCh != nil {
-			respCh <- msg
-			close(respCh)
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				streamErr = fmt.Errorf("read app-server output: %w", err)
+			}
+			break
 		}
 	}
-
-	streamErr := scanner.Err()
 	if streamErr == nil {
 		streamErr = errors.New("app-server stream closed")
 	}
@@ -450,6 +664,43 @@ func (s *appServerSession) readLoop(stdout io.Reader) {
 	}
 }
 
+// dispatch skips lines that are not JSON or not a response we are waiting on.
+func (s *appServerSession) dispatch(line []byte) {
+	var msg rpcMessage
+	if err := json.Unmarshal(line, &msg); err != nil {
+		return
+	}
+	if msg.ID == nil {
+		return
+	}
+
+	s.mu.Lock()
+	respCh := s.pending[*msg.ID]
+	if respCh != nil {
+		delete(s.pending, *msg.ID)
+	}
+	s.mu.Unlock()
+
+	if respCh != nil {
+		respCh <- msg
+		close(respCh)
+	}
+}
+
+func (s *appServerSession) running() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cmd == nil {
+		return false
+	}
+	select {
+	case <-s.done:
+		return false
+	default:
+		return true
+	}
+}
+
 func (s *appServerSession) close() error {
 	s.mu.Lock()
 	cmd := s.cmd
@@ -488,6 +739,28 @@ func drain(r io.Reader) {
 	_, _ = io.Copy(io.Discard, r)
 }
 
+// appServerEnv builds the subprocess environment: each
+// CODEX_USAGE_MONITOR_CODEX_ENV_<NAME>=value is passed on as <NAME>=value, and
+// the account's CODEX_HOME is applied last so it cannot be overridden.
+func appServerEnv(environ []string, codexHome string) []string {
+	env := append([]string(nil), environ...)
+	for _, kv := range environ {
+		key, value, ok := strings.Cut(kv, "=")
+		if !ok || !strings.HasPrefix(key, codexExtraEnvPrefix) {
+			continue
+		}
+		name := strings.TrimPrefix(key, codexExtraEnvPrefix)
+		if name == "" {
+			continue
+		}
+		env = upsertEnvVar(env, name, value)
+	}
+	if codexHome != "" {
+		env = upsertEnvVar(env, "CODEX_HOME", codexHome)
+	}
+	return env
+}
+
 func upsertEnvVar(env []string, key, value string) []string {
 	prefix := key + "="
 	for i := range env {
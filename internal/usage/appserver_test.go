@@ -1,10 +1,105 @@
 package usage
 
 import (
+	"bufio"
+	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
+// fakeAppServerModeEnv turns the test binary into a fake `codex app-server`
+// speaking JSON-RPC over stdio; CODEX_USAGE_MONITOR_CODEX_BIN points at it.
+const fakeAppServerModeEnv = "CODEX_USAGE_MONITOR_FAKE_APP_SERVER"
+
+func TestMain(m *testing.M) {
+	if mode := os.Getenv(fakeAppServerModeEnv); mode != "" {
+		os.Exit(runFakeAppServer(mode))
+	}
+	os.Exit(m.Run())
+}
+
+// runFakeAppServer answers initialize, account/rateLimits/read, and
+// account/read. Modes: "ok", "rpc-error" (rate limits fail), "auth" (account
+// needs login), "limits-auth" and "limits-unauthorized" (rate limits need
+// login), "disconnect" (exit right after initialize), and
+// "disconnect-once:<marker>" (exit on the first rate-limits request, once per
+// marker file).
+func runFakeAppServer(mode string) int {
+	if rest, marker, ok := strings.Cut(mode, ":"); ok && rest == "disconnect-once" {
+		mode = "ok"
+		if _, err := os.Stat(marker); err != nil {
+			_ = os.WriteFile(marker, nil, 0o600)
+			mode = "exit-on-limits"
+		}
+	}
+	scanner := bufio.NewScanner(os.Stdin)
+	out := json.NewEncoder(os.Stdout)
+	for scanner.Scan() {
+		var req struct {
+			ID     *int   `json:"id"`
+			Method string `json:"method"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil || req.ID == nil {
+			continue
+		}
+		resp := map[string]any{"jsonrpc": "2.0", "id": *req.ID}
+		switch req.Method {
+		case "initialize":
+			resp["result"] = map[string]any{"userAgent": "fake"}
+			_ = out.Encode(resp)
+			if mode == "disconnect" {
+				return 0
+			}
+			continue
+		case "account/rateLimits/read":
+			switch mode {
+			case "exit-on-limits":
+				return 0
+			case "rpc-error":
+				resp["error"] = map[string]any{"code": -32000, "message": "rate limit backend unavailable"}
+			case "limits-auth":
+				resp["result"] = map[string]any{"rateLimits": nil, "requiresOpenaiAuth": true}
+			case "limits-unauthorized":
+				resp["error"] = map[string]any{"code": -32000, "message": "Not logged in"}
+			default:
+				resp["result"] = json.RawMessage(`{"rateLimits":{"planType":"pro","primary":{"usedPercent":35,"windowDurationMins":300},"secondary":{"usedPercent":60,"windowDurationMins":10080}}}`)
+			}
+		case "account/read":
+			if mode == "auth" {
+				resp["result"] = map[string]any{"account": nil, "requiresOpenaiAuth": true}
+			} else {
+				resp["result"] = map[string]any{"account": map[string]any{"email": "fake@example.com"}}
+			}
+		default:
+			resp["error"] = map[string]any{"code": -32601, "message": fmt.Sprintf("unknown method %s", req.Method)}
+		}
+		_ = out.Encode(resp)
+	}
+	return 0
+}
+
+func newFakeAppServerSource(t *testing.T, mode string) *AppServerSource {
+	t.Helper()
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatalf("resolve test binary: %v", err)
+	}
+	t.Setenv(codexBinEnvVar, exe)
+	t.Setenv(fakeAppServerModeEnv, mode)
+	source := NewAppServerSourceForHome(t.TempDir())
+	source.authFingerprintFn = func() (string, error) { return "fp", nil }
+	t.Cleanup(func() { _ = source.Close() })
+	return source
+}
+
 func TestRefreshAuthStateFirstFingerprintNoWarning(t *testing.T) {
 	s := &AppServerSource{
 		authFingerprintFn: func() (string, error) {
@@ -80,3 +175,487 @@ func TestRefreshAuthStateErrorAfterKnownFingerprintReturnsWarning(t *testing.T)
 	}
 }
 
+func TestRefreshAuthStateKeepsSessionWhenRestartDisabled(t *testing.T) {
+	t.Setenv(noAuthRestartEnvVar, "1")
+	session := &appServerSession{}
+	s := &AppServerSource{
+		authFingerprint: "fp-a",
+		authFingerprintFn: func() (string, error) {
+			return "fp-b", nil
+		},
+		session: session,
+	}
+
+	if warning := s.refreshAuthState(); warning != "" {
+		t.Fatalf("expected no restart warning when disabled, got %q", warning)
+	}
+	if s.session != session {
+		t.Fatalf("expected existing session to be kept")
+	}
+	if s.authFingerprint != "fp-b" {
+		t.Fatalf("expected fingerprint to track the new auth state, got %q", s.authFingerprint)
+	}
+
+	t.Setenv(noAuthRestartEnvVar, "0")
+	s.authFingerprintFn = func() (string, error) { return "fp-c", nil }
+	if warning := s.refreshAuthState(); warning == "" || s.session != nil {
+		t.Fatalf("expected restart when the opt-out is 0, got warning %q session %v", warning, s.session)
+	}
+}
+
+func TestAuthFingerprintCacheSkipsRereadForStableFile(t *testing.T) {
+	home := t.TempDir()
+	authPath := filepath.Join(home, "auth.json")
+	writeToken := func(token string, mtime time.Time) {
+		t.Helper()
+		if err := os.WriteFile(authPath, []byte(`{"tokens":{"access_token":"`+token+`"}}`), 0o600); err != nil {
+			t.Fatalf("write auth.json: %v", err)
+		}
+		if err := os.Chtimes(authPath, mtime, mtime); err != nil {
+			t.Fatalf("set mtime: %v", err)
+		}
+	}
+	reads := 0
+	countingRead := func(path string) (string, error) {
+		reads++
+		return readAccessToken(path)
+	}
+
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	writeToken("eyJhbGciOiJSUzI1NiJ9.first.token", base)
+	var cache authFingerprintCache
+	first, err := cache.fingerprint(home, countingRead)
+	if err != nil {
+		t.Fatalf("fingerprint: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		again, err := cache.fingerprint(home, countingRead)
+		if err != nil || again != first {
+			t.Fatalf("expected cached fingerprint %q, got %q (%v)", first, again, err)
+		}
+	}
+	if reads != 1 {
+		t.Fatalf("expected a stable auth.json to be read once, got %d reads", reads)
+	}
+
+	writeToken("eyJhbGciOiJSUzI1NiJ9.other.token", base.Add(time.Minute))
+	changed, err := cache.fingerprint(home, countingRead)
+	if err != nil {
+		t.Fatalf("fingerprint after change: %v", err)
+	}
+	if changed == first || reads != 2 {
+		t.Fatalf("expected modtime change to trigger a re-read and new fingerprint, got %q after %d reads", changed, reads)
+	}
+}
+
+func TestRateLimitsReadResultDecodesCanonicalKeys(t *testing.T) {
+	var out rateLimitsReadResultRaw
+	payload := `{"rateLimits":{"planType":"pro","primary":{"usedPercent":12}},"rateLimitsByLimitId":{"codex":{},"other":{}}}`
+	if err := json.Unmarshal([]byte(payload), &out); err != nil {
+		t.Fatalf("unexpected decode error: %v", err)
+	}
+	if out.UsedAlternateKeys {
+		t.Fatalf("did not expect alternate-key flag for canonical payload")
+	}
+	if out.RateLimits.Primary == nil || out.RateLimits.Primary.UsedPercent != 12 {
+		t.Fatalf("expected primary window to decode, got %+v", out.RateLimits.Primary)
+	}
+	if len(out.RateLimitsByLimitID) != 2 {
+		t.Fatalf("expected 2 limit ids, got %d", len(out.RateLimitsByLimitID))
+	}
+}
+
+func TestAppServerAdditionalLimitsSkipMainLimit(t *testing.T) {
+	var out rateLimitsReadResultRaw
+	payload := `{"rateLimits":{"primary":{"usedPercent":12}},"rateLimitsByLimitId":{
+		"codex":{"primary":{"usedPercent":12}},
+		"spark":{"limitName":"Codex Spark","primary":{"usedPercent":64,"windowDurationMins":300}},
+		"alpha":{}}}`
+	if err := json.Unmarshal([]byte(payload), &out); err != nil {
+		t.Fatalf("unexpected decode error: %v", err)
+	}
+	limits := appServerAdditionalLimits(&out)
+	if len(limits) != 2 || limits[0].Name != "alpha" || limits[1].Name != "Codex Spark" {
+		t.Fatalf("expected alpha then Codex Spark, got %+v", limits)
+	}
+	if limits[0].PrimaryWindow != nil || limits[1].PrimaryWindow == nil || limits[1].PrimaryWindow.UsedPercent != 64 {
+		t.Fatalf("unexpected windows: %+v", limits)
+	}
+}
+
+func TestRateLimitsReadResultDecodesSnakeCaseKeys(t *testing.T) {
+	var out rateLimitsReadResultRaw
+	payload := `{"rate_limits":{"planType":"plus","primary":{"usedPercent":40},"secondary":{"usedPercent":70}},"rate_limits_by_limit_id":{"codex":{}}}`
+	if err := json.Unmarshal([]byte(payload), &out); err != nil {
+		t.Fatalf("unexpected decode error: %v", err)
+	}
+	if !out.UsedAlternateKeys {
+		t.Fatalf("expected alternate-key flag for snake_case payload")
+	}
+	if out.RateLimits.PlanType != "plus" || out.RateLimits.Secondary == nil || out.RateLimits.Secondary.UsedPercent != 70 {
+		t.Fatalf("expected snake_case rate limits to decode, got %+v", out.RateLimits)
+	}
+	if len(out.RateLimitsByLimitID) != 1 {
+		t.Fatalf("expected 1 limit id, got %d", len(out.RateLimitsByLimitID))
+	}
+}
+
+func TestNormalizeSummaryCarriesCredits(t *testing.T) {
+	var out rateLimitsReadResultRaw
+	payload := `{"rateLimits":{"planType":"pro","primary":{"usedPercent":1},"secondary":{"usedPercent":2},"credits":{"hasCredits":true,"unlimited":false,"balance":" 42.10 "}}}`
+	if err := json.Unmarshal([]byte(payload), &out); err != nil {
+		t.Fatalf("unexpected decode error: %v", err)
+	}
+	summary, err := normalizeSummary("app-server", out.RateLimits, 0, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if summary.Credits == nil || !summary.Credits.HasCredits || summary.Credits.Balance != "42.10" {
+		t.Fatalf("expected credits to be surfaced, got %+v", summary.Credits)
+	}
+}
+
+func TestToWindowSummaryKeepsAbsoluteLimitsOnlyWhenComplete(t *testing.T) {
+	limit, used := int64(50000), int64(12000)
+	win := toWindowSummary(&rateLimitWindowRaw{UsedPercent: 24, Limit: &limit, Used: &used})
+	if win.Limit == nil || *win.Limit != 50000 || win.Used == nil || *win.Used != 12000 {
+		t.Fatalf("expected absolute limits, got %+v", win)
+	}
+	if win := toWindowSummary(&rateLimitWindowRaw{UsedPercent: 24, Limit: &limit}); win.Limit != nil || win.Used != nil {
+		t.Fatalf("expected percent-only window when used is missing, got %+v", win)
+	}
+	zero := int64(0)
+	if win := toWindowSummary(&rateLimitWindowRaw{Limit: &zero, Used: &used}); win.Limit != nil {
+		t.Fatalf("expected non-positive limit to be ignored, got %+v", win)
+	}
+}
+
+func TestAppServerSourceEndToEndAgainstFakeSubprocess(t *testing.T) {
+	source := newFakeAppServerSource(t, "ok")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	summary, err := source.Fetch(ctx)
+	if err != nil {
+		t.Fatalf("unexpected fetch error: %v", err)
+	}
+	if summary.Source != "app-server" || summary.PlanType != "pro" || summary.AccountEmail != "fake@example.com" {
+		t.Fatalf("unexpected summary identity: %+v", summary)
+	}
+	if summary.PrimaryWindow.UsedPercent != 35 || summary.SecondaryWindow.UsedPercent != 60 {
+		t.Fatalf("unexpected windows: %+v / %+v", summary.PrimaryWindow, summary.SecondaryWindow)
+	}
+	if !source.sessionRunning() {
+		t.Fatalf("expected the session to stay up between fetches")
+	}
+
+	// A second fetch reuses the initialized session.
+	if _, err := source.Fetch(ctx); err != nil {
+		t.Fatalf("unexpected second fetch error: %v", err)
+	}
+}
+
+func TestAppServerSourceSurfacesRPCErrorsAndAuth(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := newFakeAppServerSource(t, "rpc-error").Fetch(ctx)
+	if err == nil || !strings.Contains(err.Error(), "rate limit backend unavailable") {
+		t.Fatalf("expected rpc error to surface, got %v", err)
+	}
+	if errors.Is(err, ErrAuthRequired) {
+		t.Fatalf("did not expect backend rpc error to be classified as auth")
+	}
+
+	summary, err := newFakeAppServerSource(t, "auth").Fetch(ctx)
+	if err != nil {
+		t.Fatalf("expected rate limits despite missing identity, got %v", err)
+	}
+	if summary.AccountEmail != "" || len(summary.Warnings) == 0 || !strings.Contains(summary.Warnings[0], "requires OpenAI auth") {
+		t.Fatalf("expected identity warning for auth-required account, got %+v", summary.Warnings)
+	}
+}
+
+func TestAppServerSourceReportsLoginRequiredForRateLimits(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	for _, mode := range []string{"limits-auth", "limits-unauthorized"} {
+		source := newFakeAppServerSource(t, mode)
+		_, err := source.Fetch(ctx)
+		if !errors.Is(err, ErrAuthRequired) || !strings.Contains(err.Error(), "app-server requires login") {
+			t.Fatalf("%s: expected a login-required error, got %v", mode, err)
+		}
+		if !source.sessionRunning() {
+			t.Fatalf("%s: expected the session to be kept for the next auth refresh", mode)
+		}
+
+		fallback := &fakeSource{name: "oauth", out: &Summary{Source: "oauth"}}
+		summary, primaryErr, err := fetchWithFallback(ctx, source, fallback)
+		if err != nil || summary.Source != "oauth" || !errors.Is(primaryErr, ErrAuthRequired) {
+			t.Fatalf("%s: expected the oauth fallback, got %+v, %v, %v", mode, summary, primaryErr, err)
+		}
+		if len(summary.Warnings) != 1 || !strings.Contains(summary.Warnings[0], "run `codex login`") {
+			t.Fatalf("%s: expected a login hint in the fallback warning, got %v", mode, summary.Warnings)
+		}
+	}
+}
+
+func TestAppServerSourceRecoversAfterDisconnect(t *testing.T) {
+	source := newFakeAppServerSource(t, "disconnect")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if _, err := source.Fetch(ctx); err == nil {
+		t.Fatalf("expected fetch to fail when app-server exits mid-session")
+	}
+	if source.sessionRunning() {
+		t.Fatalf("expected no live session after disconnect")
+	}
+
+	t.Setenv(fakeAppServerModeEnv, "ok")
+	summary, err := source.Fetch(ctx)
+	if err != nil {
+		t.Fatalf("expected a fresh session after disconnect, got %v", err)
+	}
+	if summary.AccountEmail != "fake@example.com" {
+		t.Fatalf("unexpected summary after restart: %+v", summary)
+	}
+}
+
+func TestAppServerSourceRestartsOnceWhenSessionDies(t *testing.T) {
+	marker := filepath.Join(t.TempDir(), "disconnected")
+	source := newFakeAppServerSource(t, "disconnect-once:"+marker)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	summary, err := source.Fetch(ctx)
+	if err != nil {
+		t.Fatalf("expected the retry to recover, got %v", err)
+	}
+	if summary.PrimaryWindow.UsedPercent != 35 {
+		t.Fatalf("unexpected windows after restart: %+v", summary.PrimaryWindow)
+	}
+	if len(summary.Warnings) != 1 || !strings.Contains(summary.Warnings[0], "restarted it") {
+		t.Fatalf("expected a restart warning, got %v", summary.Warnings)
+	}
+
+	// RPC failures are answers from a live session; they are not retried.
+	_, err = newFakeAppServerSource(t, "rpc-error").Fetch(ctx)
+	var closed *sessionClosedError
+	if errors.As(err, &closed) {
+		t.Fatalf("did not expect an rpc error to count as a closed session: %v", err)
+	}
+}
+
+func TestReadLoopDeliversResponsesLargerThanScannerLimit(t *testing.T) {
+	session := newAppServerSession("")
+	first := make(chan rpcMessage, 1)
+	second := make(chan rpcMessage, 1)
+	session.pending[1] = first
+	session.pending[2] = second
+
+	padding := strings.Repeat("x", 3*1024*1024)
+	stream := `{"id":1,"result":{"padding":"` + padding + `"}}` + "\n" + `{"id":2,"result":{}}`
+	session.readLoop(strings.NewReader(stream))
+
+	msg, ok := <-first
+	if !ok || len(msg.Result) < len(padding) {
+		t.Fatalf("expected oversized response to be delivered, got ok=%v len=%d", ok, len(msg.Result))
+	}
+	if msg, ok := <-second; !ok || msg.ID == nil || *msg.ID != 2 {
+		t.Fatalf("expected trailing unterminated response to be delivered, got ok=%v %+v", ok, msg)
+	}
+	if err := session.doneErrSnapshot(); err == nil || !strings.Contains(err.Error(), "stream closed") {
+		t.Fatalf("expected stream closed after EOF, got %v", err)
+	}
+}
+
+func TestReadLoopSkipsUnexpectedMessagesAndNonJSONLines(t *testing.T) {
+	session := newAppServerSession("")
+	respCh := make(chan rpcMessage, 1)
+	session.pending[3] = respCh
+
+	stream := `{"method":"account/updated","params":{}}` + "\n" +
+		`{"id":"not-a-number","result":{}}` + "\n" +
+		"codex app-server starting...\n" +
+		"not json\n" +
+		`{"id":3,"result":{"ok":true}}` + "\n"
+	session.readLoop(strings.NewReader(stream))
+
+	if msg, ok := <-respCh; !ok || string(msg.Result) != `{"ok":true}` {
+		t.Fatalf("expected the response after the non-JSON lines, got ok=%v %+v", ok, msg)
+	}
+	if err := session.doneErrSnapshot(); err == nil || !strings.Contains(err.Error(), "stream closed") {
+		t.Fatalf("expected the stream to end only at EOF, got %v", err)
+	}
+}
+
+func TestRequestCancellationReleasesPendingChannels(t *testing.T) {
+	session := newAppServerSession("")
+	session.cmd = &exec.Cmd{}
+	session.encoder = json.NewEncoder(io.Discard)
+	session.done = make(chan struct{})
+
+	for i := 0; i < 50; i++ {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if err := session.request(ctx, "account/read", nil, nil); !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected cancellation error, got %v", err)
+		}
+	}
+	if n := session.pendingCount(); n != 0 {
+		t.Fatalf("expected no pending requests after cancellation, got %d", n)
+	}
+
+	// Late responses for abandoned requests are dropped without blocking.
+	session.readLoop(strings.NewReader(`{"id":1,"result":{}}` + "\n" + `{"id":50,"result":{}}`))
+	if n := session.pendingCount(); n != 0 {
+		t.Fatalf("expected no pending requests after teardown, got %d", n)
+	}
+}
+
+func TestAppServerFetchCoalescesConcurrentCallers(t *testing.T) {
+	source := NewAppServerSourceForHome(t.TempDir())
+	call := &fetchCall{done: make(chan struct{})}
+	source.inflight = call
+
+	const callers = 8
+	type result struct {
+		summary *Summary
+		err     error
+	}
+	results := make(chan result, callers)
+	for i := 0; i < callers; i++ {
+		go func() {
+			summary, err := source.Fetch(context.Background())
+			results <- result{summary, err}
+		}()
+	}
+
+	call.summary = &Summary{Source: "app-server", PlanType: "pro", Warnings: []string{"shared"}}
+	close(call.done)
+
+	seen := map[*Summary]bool{}
+	for i := 0; i < callers; i++ {
+		r := <-results
+		if r.err != nil || r.summary == nil || r.summary.PlanType != "pro" {
+			t.Fatalf("expected shared in-flight result, got %+v, %v", r.summary, r.err)
+		}
+		if seen[r.summary] {
+			t.Fatalf("expected each caller to receive its own copy")
+		}
+		seen[r.summary] = true
+		r.summary.Warnings[0] = "mutated"
+	}
+	if call.summary.Warnings[0] != "shared" {
+		t.Fatalf("expected caller mutations not to leak into the shared result")
+	}
+	if source.sessionRunning() {
+		t.Fatalf("expected coalesced callers not to start their own session")
+	}
+}
+
+func TestAppServerFetchWaiterHonorsOwnContext(t *testing.T) {
+	source := NewAppServerSourceForHome(t.TempDir())
+	source.inflight = &fetchCall{done: make(chan struct{})}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := source.Fetch(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected waiter to stop on its own context, got %v", err)
+	}
+}
+
+func TestAppServerFetchWaiterRetriesWhenLeaderCanceled(t *testing.T) {
+	source := NewAppServerSourceForHome(t.TempDir())
+	first := &fetchCall{done: make(chan struct{})}
+	source.inflight = first
+
+	type result struct {
+		summary *Summary
+		err     error
+	}
+	results := make(chan result, 1)
+	go func() {
+		summary, err := source.Fetch(context.Background())
+		results <- result{summary, err}
+	}()
+
+	// The leader's context ends mid-fetch while a second fetch has already
+	// taken its place; the waiter should join that one.
+	second := &fetchCall{done: make(chan struct{})}
+	source.mu.Lock()
+	first.err = context.Canceled
+	first.leaderCanceled = true
+	source.inflight = second
+	source.mu.Unlock()
+	close(first.done)
+
+	second.summary = &Summary{Source: "app-server", PlanType: "pro"}
+	close(second.done)
+
+	r := <-results
+	if r.err != nil || r.summary == nil || r.summary.PlanType != "pro" {
+		t.Fatalf("expected waiter to retry past the canceled leader, got %+v, %v", r.summary, r.err)
+	}
+}
+
+func TestNormalizeSummaryClampsOutOfRangePercents(t *testing.T) {
+	summary, err := normalizeSummary("app-server", rateLimitSnapshotRaw{
+		Primary:   &rateLimitWindowRaw{UsedPercent: -5},
+		Secondary: &rateLimitWindowRaw{UsedPercent: 140},
+	}, 0, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if summary.PrimaryWindow.UsedPercent != 0 || summary.SecondaryWindow.UsedPercent != 100 {
+		t.Fatalf("expected clamped percents 0/100, got %d/%d", summary.PrimaryWindow.UsedPercent, summary.SecondaryWindow.UsedPercent)
+	}
+	if len(summary.Warnings) != 2 ||
+		!strings.Contains(summary.Warnings[0], "primary window used_percent -5") ||
+		!strings.Contains(summary.Warnings[1], "secondary window used_percent 140") {
+		t.Fatalf("expected clamp warnings, got %v", summary.Warnings)
+	}
+
+	summary, err = normalizeSummary("app-server", rateLimitSnapshotRaw{
+		Primary:   &rateLimitWindowRaw{UsedPercent: 0},
+		Secondary: &rateLimitWindowRaw{UsedPercent: 100},
+	}, 0, nil, nil)
+	if err != nil || len(summary.Warnings) != 0 {
+		t.Fatalf("expected boundary values to pass without warnings, got %v (%v)", summary.Warnings, err)
+	}
+}
+
+func TestAppServerEnvMergesPrefixedExtras(t *testing.T) {
+	environ := []string{
+		"PATH=/usr/bin",
+		"CODEX_FEATURE_X=old",
+		"CODEX_USAGE_MONITOR_CODEX_ENV_CODEX_FEATURE_X=on",
+		"CODEX_USAGE_MONITOR_CODEX_ENV_CODEX_CONFIG_DIR=/tmp/config",
+		"CODEX_USAGE_MONITOR_CODEX_ENV_=ignored",
+		"CODEX_USAGE_MONITOR_CODEX_ENV_CODEX_HOME=/tmp/wrong",
+	}
+	env := appServerEnv(environ, "/tmp/home")
+
+	values := map[string]string{}
+	for _, kv := range env {
+		key, value, _ := strings.Cut(kv, "=")
+		values[key] = value
+	}
+	if values["CODEX_FEATURE_X"] != "on" {
+		t.Fatalf("expected extra env to override existing value, got %q", values["CODEX_FEATURE_X"])
+	}
+	if values["CODEX_CONFIG_DIR"] != "/tmp/config" {
+		t.Fatalf("expected extra env to be added, got %q", values["CODEX_CONFIG_DIR"])
+	}
+	if values["CODEX_HOME"] != "/tmp/home" {
+		t.Fatalf("expected account CODEX_HOME to win, got %q", values["CODEX_HOME"])
+	}
+	if values["PATH"] != "/usr/bin" {
+		t.Fatalf("expected inherited env to be kept, got %q", values["PATH"])
+	}
+	if environ[1] != "CODEX_FEATURE_X=old" {
+		t.Fatalf("expected input environ to be left untouched, got %q", environ[1])
+	}
+}
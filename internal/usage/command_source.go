@@ -0,0 +1,100 @@
+package usage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+const (
+	commandSourceEnvVar = "CODEX_USAGE_MONITOR_COMMAND_SOURCE"
+
+	// commandSourceSchemaVersion is the only schema_version a command may
+	// declare; output without one is read as the current Summary shape.
+	commandSourceSchemaVersion = 1
+
+	// commandSourceWaitDelay bounds how long a finished or cancelled command
+	// may hold its output pipes open through a background child.
+	commandSourceWaitDelay = time.Second
+)
+
+// CommandSource runs a user-supplied shell command that prints Summary JSON on
+// stdout, for custom collectors (remote hosts over ssh, cached files, fixtures).
+type CommandSource struct {
+	command string
+}
+
+type commandSourcePayload struct {
+	SchemaVersion *int `json:"schema_version"`
+	Summary
+}
+
+func NewCommandSource(command string) *CommandSource {
+	return &CommandSource{command: strings.TrimSpace(command)}
+}
+
+func (s *CommandSource) Name() string {
+	return "command"
+}
+
+func (s *CommandSource) Fetch(ctx context.Context) (*Summary, error) {
+	if s.command == "" {
+		return nil, fmt.Errorf("command source is empty; set %s", commandSourceEnvVar)
+	}
+	shell, flag := "sh", "-c"
+	if runtime.GOOS == "windows" {
+		shell, flag = "cmd", "/C"
+	}
+	cmd := exec.CommandContext(ctx, shell, flag, s.command)
+	cmd.WaitDelay = commandSourceWaitDelay
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	// ErrWaitDelay alone means the command exited cleanly but left a child
+	// holding stdout; what it printed is still the summary.
+	if err := cmd.Run(); err != nil && !errors.Is(err, exec.ErrWaitDelay) {
+		if detail := summarizeBody(stderr.Bytes()); detail != "" {
+			return nil, fmt.Errorf("command source failed: %v: %s", err, detail)
+		}
+		return nil, fmt.Errorf("command source failed: %w", err)
+	}
+	summary, err := decodeCommandSummary(stdout.Bytes())
+	if err != nil {
+		if detail := summarizeBody(stderr.Bytes()); detail != "" {
+			return nil, fmt.Errorf("%w (stderr: %s)", err, detail)
+		}
+		return nil, err
+	}
+	return summary, nil
+}
+
+func (s *CommandSource) Close() error {
+	return nil
+}
+
+func decodeCommandSummary(data []byte) (*Summary, error) {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, fmt.Errorf("command source printed no output")
+	}
+	var payload commandSourcePayload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil, fmt.Errorf("decode command source output: %w", err)
+	}
+	if payload.SchemaVersion != nil && *payload.SchemaVersion != commandSourceSchemaVersion {
+		return nil, fmt.Errorf("command source schema_version %d is not supported (expected %d)", *payload.SchemaVersion, commandSourceSchemaVersion)
+	}
+	summary := payload.Summary
+	if strings.TrimSpace(summary.Source) == "" {
+		summary.Source = "command"
+	}
+	if summary.FetchedAt.IsZero() {
+		summary.FetchedAt = time.Now().UTC()
+	}
+	return &summary, nil
+}
@@ -0,0 +1,90 @@
+package usage
+
+import (
+	"context"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDecodeCommandSummaryValidatesSchemaVersion(t *testing.T) {
+	summary, err := decodeCommandSummary([]byte(`{"schema_version":1,"plan_type":"pro","window_data_available":true,"primary_window":{"used_percent":12}}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if summary.PlanType != "pro" || summary.PrimaryWindow.UsedPercent != 12 {
+		t.Fatalf("expected decoded summary fields, got %+v", summary)
+	}
+	if summary.Source != "command" || summary.FetchedAt.IsZero() {
+		t.Fatalf("expected default source and fetch time, got source %q at %v", summary.Source, summary.FetchedAt)
+	}
+
+	unversioned, err := decodeCommandSummary([]byte(`{"source":"remote","window_data_available":true}`))
+	if err != nil || unversioned.Source != "remote" {
+		t.Fatalf("expected unversioned summary to decode, got %+v (%v)", unversioned, err)
+	}
+
+	if _, err := decodeCommandSummary([]byte(`{"schema_version":2}`)); err == nil || !strings.Contains(err.Error(), "schema_version 2") {
+		t.Fatalf("expected unsupported schema version error, got %v", err)
+	}
+	if _, err := decodeCommandSummary([]byte("  \n")); err == nil {
+		t.Fatalf("expected error for empty output")
+	}
+	if _, err := decodeCommandSummary([]byte("[1,2]")); err == nil {
+		t.Fatalf("expected error for non-object output")
+	}
+}
+
+func TestCommandSourceSurfacesStderrOnFailure(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	ok := NewCommandSource(`printf '{"source":"fixture","plan_type":"plus"}'`)
+	summary, err := ok.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if summary.Source != "fixture" || summary.PlanType != "plus" {
+		t.Fatalf("expected command summary, got %+v", summary)
+	}
+
+	failing := NewCommandSource(`echo "ssh: connect to host devbox: Connection refused" >&2; exit 255`)
+	_, err = failing.Fetch(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "Connection refused") {
+		t.Fatalf("expected stderr in error, got %v", err)
+	}
+}
+
+func TestCommandSourceDoesNotWaitForBackgroundChildren(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	start := time.Now()
+	source := NewCommandSource(`sleep 5 & printf '{"plan_type":"pro"}'`)
+	summary, err := source.Fetch(context.Background())
+	if err != nil || summary.PlanType != "pro" {
+		t.Fatalf("expected the printed summary despite the background child, got %+v, %v", summary, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := NewCommandSource(`sleep 5 & sleep 5`).Fetch(ctx); err == nil {
+		t.Fatalf("expected the deadline to fail the fetch")
+	}
+	if elapsed := time.Since(start); elapsed > 4*time.Second {
+		t.Fatalf("expected both fetches to return within the wait delay, took %v", elapsed)
+	}
+}
+
+func TestNewDefaultFetcherUsesCommandSourceFromEnv(t *testing.T) {
+	t.Setenv(commandSourceEnvVar, "cat summary.json")
+	f := NewDefaultFetcher(FetcherOptions{})
+	defer f.Close()
+	if _, ok := f.Primary().(*CommandSource); !ok {
+		t.Fatalf("expected command source as primary, got %T", f.Primary())
+	}
+	if len(f.Accounts()) != 0 {
+		t.Fatalf("expected no account discovery with a command source, got %d accounts", len(f.Accounts()))
+	}
+}
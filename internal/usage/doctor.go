@@ -2,31 +2,137 @@ package usage
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
 	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
 	"strings"
 	"time"
 )
 
+const (
+	doctorFailureAuthentication = "authentication"
+	doctorFailureConnectivity   = "connectivity"
+)
+
 type DoctorReport struct {
-	Checks []DoctorCheck `json:"checks"`
+	Checks          []DoctorCheck `json:"checks"`
+	TotalDurationMS int64         `json:"total_duration_ms"`
 }
 
-func RunDoctor(ctx context.Context) DoctorReport {
+// DoctorOptions configures optional doctor checks; the zero value runs the defaults.
+type DoctorOptions struct {
+	// CreditsMin, when set, fails doctor if the credit balance is below it.
+	CreditsMin *float64
+	// AllAccounts adds one fetch check per discovered account home.
+	AllAccounts bool
+	// CheckTimeout bounds each source fetch; zero means defaultCheckTimeout.
+	CheckTimeout time.Duration
+}
+
+const defaultCheckTimeout = 8 * time.Second
+
+func (o DoctorOptions) checkTimeout() time.Duration {
+	if o.CheckTimeout > 0 {
+		return o.CheckTimeout
+	}
+	return defaultCheckTimeout
+}
+
+func RunDoctor(ctx context.Context, opts DoctorOptions) DoctorReport {
+	started := time.Now()
+	checkTimeout := opts.checkTimeout()
 	var checks []DoctorCheck
 
-	checks = append(checks, checkCodexBinary(ctx))
-	checks = append(checks, checkAuthJSON())
+	checks = append(checks, timedCheck(func() DoctorCheck { return checkCodexBinary(ctx) }))
+	checks = append(checks, timedCheck(func() DoctorCheck { return checkCodexPath(codexBinary(), os.Getenv("PATH")) }))
+	checks = append(checks, timedCheck(checkAuthJSON))
 
 	appSource := NewAppServerSource()
 	defer appSource.Close()
-	checks = append(checks, checkSourceFetch(ctx, appSource, 8*time.Second))
+	var appSummary *Summary
+	checks = append(checks, timedCheck(func() DoctorCheck {
+		var check DoctorCheck
+		check, appSummary = checkSourceFetch(ctx, appSource, checkTimeout)
+		return check
+	}))
 
 	oauthSource := NewOAuthSource()
 	defer oauthSource.Close()
-	checks = append(checks, checkSourceFetch(ctx, oauthSource, 8*time.Second))
+	var oauthSummary *Summary
+	checks = append(checks, timedCheck(func() DoctorCheck {
+		var check DoctorCheck
+		check, oauthSummary = checkSourceFetch(ctx, oauthSource, checkTimeout)
+		return check
+	}))
+
+	if opts.CreditsMin != nil {
+		credits := appSummary
+		if credits == nil || credits.Credits == nil {
+			credits = oauthSummary
+		}
+		var snapshot *CreditsSummary
+		if credits != nil {
+			snapshot = credits.Credits
+		}
+		checks = append(checks, timedCheck(func() DoctorCheck { return checkCredits(snapshot, *opts.CreditsMin) }))
+	}
+
+	if opts.AllAccounts {
+		checks = append(checks, accountChecks(ctx, checkTimeout)...)
+	}
+
+	return DoctorReport{Checks: checks, TotalDurationMS: time.Since(started).Milliseconds()}
+}
+
+// accountChecks runs the source fetch checks against every account home the
+// TUI would monitor, one row per account.
+func accountChecks(ctx context.Context, timeout time.Duration) []DoctorCheck {
+	accounts, _, err := loadMonitorAccounts()
+	if err != nil {
+		return []DoctorCheck{{Name: "accounts", Details: fmt.Sprintf("could not load accounts: %v", err)}}
+	}
+	checks := make([]DoctorCheck, 0, len(accounts))
+	for _, account := range accounts {
+		checks = append(checks, timedCheck(func() DoctorCheck {
+			primary := NewAppServerSourceForHome(account.CodexHome)
+			defer primary.Close()
+			fallback := NewOAuthSourceForHome(account.CodexHome)
+			defer fallback.Close()
+			return checkAccountSources(ctx, account, primary, fallback, timeout)
+		}))
+	}
+	return checks
+}
 
-	return DoctorReport{Checks: checks}
+// checkAccountSources passes when either source fetches for the account. A
+// failure reports the fallback's classification, the last thing tried.
+func checkAccountSources(ctx context.Context, account MonitorAccount, primary, fallback Source, timeout time.Duration) DoctorCheck {
+	name := "account " + account.Label
+	check, _ := checkSourceFetch(ctx, primary, timeout)
+	if !check.OK {
+		fallbackCheck, _ := checkSourceFetch(ctx, fallback, timeout)
+		if fallbackCheck.OK {
+			fallbackCheck.Details += fmt.Sprintf("; %s failed: %s", primary.Name(), check.Details)
+		} else {
+			fallbackCheck.Details = fmt.Sprintf("%s: %s; %s: %s", primary.Name(), check.Details, fallback.Name(), fallbackCheck.Details)
+		}
+		check = fallbackCheck
+	}
+	check.Name = name
+	check.Details = account.CodexHome + ": " + check.Details
+	return check
+}
+
+// timedCheck records how long run took, whether the check passed or failed.
+func timedCheck(run func() DoctorCheck) DoctorCheck {
+	started := time.Now()
+	check := run()
+	check.DurationMS = time.Since(started).Milliseconds()
+	return check
 }
 
 func (r DoctorReport) Healthy() bool {
@@ -37,13 +143,23 @@ func (r DoctorReport) Healthy() bool {
 			appOK = c.OK
 		case "oauth fetch":
 			oauthOK = c.OK
+		case "credits":
+			if !c.OK {
+				return false
+			}
+		default:
+			// --all-accounts rows, including the one reporting that the
+			// accounts list could not be loaded.
+			if (c.Name == "accounts" || strings.HasPrefix(c.Name, "account ")) && !c.OK {
+				return false
+			}
 		}
 	}
 	return appOK || oauthOK
 }
 
 func checkCodexBinary(ctx context.Context) DoctorCheck {
-	cmd := exec.CommandContext(ctx, "codex", "--version")
+	cmd := exec.CommandContext(ctx, codexBinary(), "--version")
 	out, err := cmd.CombinedOutput()
 	if err != nil {
 		return DoctorCheck{
@@ -63,6 +179,86 @@ func checkCodexBinary(ctx context.Context) DoctorCheck {
 	}
 }
 
+// checkCodexPath reports which codex binary doctor runs and warns when PATH
+// holds more than one, since the shell and doctor may then disagree.
+func checkCodexPath(binary, pathEnv string) DoctorCheck {
+	resolved, err := exec.LookPath(binary)
+	if err != nil {
+		return DoctorCheck{
+			Name:    "codex path",
+			OK:      false,
+			Details: fmt.Sprintf("could not resolve %s: %v", binary, err),
+		}
+	}
+	if abs, absErr := filepath.Abs(resolved); absErr == nil {
+		resolved = abs
+	}
+	details := "resolved " + resolved
+	if candidates := codexBinariesOnPath(binary, pathEnv); len(candidates) > 1 {
+		details += fmt.Sprintf("; warning: %d codex binaries on PATH (%s); the first one wins", len(candidates), strings.Join(candidates, ", "))
+	}
+	return DoctorCheck{
+		Name:    "codex path",
+		OK:      true,
+		Details: details,
+	}
+}
+
+// codexBinariesOnPath lists executable files named binary in PATH order,
+// counting symlinks to the same target once. A binary given as a path is not
+// searched for, so it has no duplicates.
+func codexBinariesOnPath(binary, pathEnv string) []string {
+	if filepath.Base(binary) != binary {
+		return nil
+	}
+	names := executableNames(binary)
+	var found []string
+	seen := map[string]bool{}
+	for _, dir := range filepath.SplitList(pathEnv) {
+		if dir == "" {
+			continue
+		}
+		for _, name := range names {
+			candidate := filepath.Join(dir, name)
+			info, err := os.Stat(candidate)
+			if err != nil || !info.Mode().IsRegular() || (runtime.GOOS != "windows" && info.Mode().Perm()&0o111 == 0) {
+				continue
+			}
+			key := candidate
+			if target, err := filepath.EvalSymlinks(candidate); err == nil {
+				key = target
+			}
+			if !seen[key] {
+				seen[key] = true
+				found = append(found, candidate)
+			}
+			// Like exec.LookPath, the first matching extension in a
+			// directory is the one that runs.
+			break
+		}
+	}
+	return found
+}
+
+// executableNames expands binary with the PATHEXT extensions on Windows when it
+// has none of its own, matching how exec.LookPath resolves it.
+func executableNames(binary string) []string {
+	if runtime.GOOS != "windows" || filepath.Ext(binary) != "" {
+		return []string{binary}
+	}
+	pathext := os.Getenv("PATHEXT")
+	if pathext == "" {
+		pathext = ".com;.exe;.bat;.cmd"
+	}
+	var names []string
+	for _, ext := range strings.Split(pathext, ";") {
+		if ext = strings.TrimSpace(ext); ext != "" {
+			names = append(names, binary+strings.ToLower(ext))
+		}
+	}
+	return names
+}
+
 func checkAuthJSON() DoctorCheck {
 	path, err := findAuthJSONPath()
 	if err != nil {
@@ -73,10 +269,14 @@ func checkAuthJSON() DoctorCheck {
 		}
 	}
 	if _, err := readAccessToken(path); err != nil {
+		details := fmt.Sprintf("found %s but token read failed: %v", path, err)
+		if errors.Is(err, errAuthTokenInvalid) {
+			details += "; run `codex login` to re-authenticate"
+		}
 		return DoctorCheck{
 			Name:    "auth file",
 			OK:      false,
-			Details: fmt.Sprintf("found %s but token read failed: %v", path, err),
+			Details: details,
 		}
 	}
 	return DoctorCheck{
@@ -86,27 +286,143 @@ func checkAuthJSON() DoctorCheck {
 	}
 }
 
-func checkSourceFetch(parent context.Context, source Source, timeout time.Duration) DoctorCheck {
+func checkSourceFetch(parent context.Context, source Source, timeout time.Duration) (DoctorCheck, *Summary) {
 	ctx, cancel := context.WithTimeout(parent, timeout)
 	defer cancel()
 
 	summary, err := source.Fetch(ctx)
 	if err != nil {
+		if errors.Is(err, ErrAuthRequired) {
+			return DoctorCheck{
+				Name:    source.Name() + " fetch",
+				OK:      false,
+				Details: err.Error() + "; run `codex login` to re-authenticate",
+				Failure: doctorFailureAuthentication,
+			}, nil
+		}
 		return DoctorCheck{
 			Name:    source.Name() + " fetch",
 			OK:      false,
 			Details: err.Error(),
-		}
+			Failure: doctorFailureConnectivity,
+		}, nil
 	}
 	return DoctorCheck{
 		Name: source.Name() + " fetch",
 		OK:   true,
 		Details: fmt.Sprintf(
-			"plan=%s 5h=%d%% weekly=%d%% source=%s",
+			"plan=%s 5h=%d%% weekly=%d%% credits=%s source=%s",
 			summary.PlanType,
 			summary.PrimaryWindow.UsedPercent,
 			summary.SecondaryWindow.UsedPercent,
+			describeCredits(summary.Credits),
 			summary.Source,
 		),
+	}, summary
+}
+
+func describeCredits(credits *CreditsSummary) string {
+	switch {
+	case credits == nil || !credits.HasCredits:
+		return "none"
+	case credits.Unlimited:
+		return "unlimited"
+	case credits.Balance == "":
+		return "unknown"
+	default:
+		return credits.Balance
+	}
+}
+
+// checkCredits compares the balance with min. Unlimited credits skip the check.
+func checkCredits(credits *CreditsSummary, minBalance float64) DoctorCheck {
+	check := DoctorCheck{Name: "credits"}
+	switch {
+	case credits == nil:
+		check.Details = "credit balance unavailable from all sources"
+		return check
+	case credits.Unlimited:
+		check.OK = true
+		check.Details = "unlimited; --credits-min skipped"
+		return check
+	case !credits.HasCredits:
+		check.Details = fmt.Sprintf("no credits on account (minimum %g)", minBalance)
+		return check
+	}
+	balance, err := strconv.ParseFloat(credits.Balance, 64)
+	if err != nil {
+		check.Details = fmt.Sprintf("unparsable credit balance %q", credits.Balance)
+		return check
+	}
+	check.OK = balance >= minBalance
+	if check.OK {
+		check.Details = fmt.Sprintf("balance %s (minimum %g)", credits.Balance, minBalance)
+	} else {
+		check.Details = fmt.Sprintf("balance %s is below minimum %g", credits.Balance, minBalance)
+	}
+	return check
+}
+
+// ObservedFileScan is one session file's contribution to the observed totals.
+type ObservedFileScan struct {
+	Path         string   `json:"path"`
+	Events5h     int      `json:"events_5h"`
+	Tokens5h     int64    `json:"tokens_5h"`
+	EventsWeekly int      `json:"events_weekly"`
+	TokensWeekly int64    `json:"tokens_weekly"`
+	Warnings     []string `json:"warnings,omitempty"`
+	Error        string   `json:"error,omitempty"`
+}
+
+// ObservedHomeScan lists the session files discovered for one account.
+type ObservedHomeScan struct {
+	Label     string             `json:"label"`
+	CodexHome string             `json:"codex_home"`
+	Files     []ObservedFileScan `json:"files"`
+	Warnings  []string           `json:"warnings,omitempty"`
+	Error     string             `json:"error,omitempty"`
+}
+
+// ScanObservedTokenFiles reports, per configured account, which session files
+// feed the observed-token estimate and how many events each contributed.
+func ScanObservedTokenFiles(ctx context.Context, now time.Time) []ObservedHomeScan {
+	accounts, _, err := loadMonitorAccounts()
+	if err != nil || len(accounts) == 0 {
+		if home, homeErr := defaultCodexHome(); homeErr == nil {
+			accounts = []MonitorAccount{{Label: "default", CodexHome: home}}
+		}
+	}
+	now = now.UTC()
+	windows, windowWarnings := observedWindowsFromEnv()
+	cutoff5h := now.Add(-windows.Primary)
+	cutoff1w := now.Add(-windows.Secondary)
+
+	out := make([]ObservedHomeScan, 0, len(accounts))
+	for _, account := range accounts {
+		scan := ObservedHomeScan{Label: account.Label, CodexHome: account.CodexHome}
+		files, warnings, _, err := discoverRecentUsageFiles(ctx, account.CodexHome, SessionsScopeAll, windows, now)
+		scan.Warnings = append(append([]string(nil), windowWarnings...), warnings...)
+		if err != nil {
+			scan.Error = err.Error()
+			out = append(out, scan)
+			continue
+		}
+		for _, file := range files {
+			if ctx.Err() != nil {
+				scan.Error = "scan cancelled"
+				break
+			}
+			fileScan := ObservedFileScan{Path: file}
+			sum5h, sum1w, fileWarnings, err := estimateTokensFromFile(file, cutoff5h, cutoff1w)
+			if err != nil {
+				fileScan.Error = err.Error()
+			}
+			fileScan.Events5h, fileScan.Tokens5h = sum5h.Events, sum5h.Total
+			fileScan.EventsWeekly, fileScan.TokensWeekly = sum1w.Events, sum1w.Total
+			fileScan.Warnings = fileWarnings
+			scan.Files = append(scan.Files, fileScan)
+		}
+		out = append(out, scan)
 	}
+	return out
 }
@@ -0,0 +1,211 @@
+package usage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckCreditsThreshold(t *testing.T) {
+	cases := []struct {
+		name    string
+		credits *CreditsSummary
+		min     float64
+		ok      bool
+		details string
+	}{
+		{name: "above", credits: &CreditsSummary{HasCredits: true, Balance: "12.50"}, min: 10, ok: true, details: "balance 12.50"},
+		{name: "below", credits: &CreditsSummary{HasCredits: true, Balance: "4"}, min: 10, details: "below minimum 10"},
+		{name: "unlimited", credits: &CreditsSummary{HasCredits: true, Unlimited: true}, min: 10, ok: true, details: "skipped"},
+		{name: "none", credits: &CreditsSummary{}, min: 1, details: "no credits"},
+		{name: "missing", min: 1, details: "unavailable"},
+		{name: "unparsable", credits: &CreditsSummary{HasCredits: true, Balance: "lots"}, min: 1, details: "unparsable"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			check := checkCredits(tc.credits, tc.min)
+			if check.OK != tc.ok {
+				t.Fatalf("expected ok=%v, got %+v", tc.ok, check)
+			}
+			if !strings.Contains(check.Details, tc.details) {
+				t.Fatalf("expected details to contain %q, got %q", tc.details, check.Details)
+			}
+		})
+	}
+}
+
+func TestDoctorReportUnhealthyWhenCreditsCheckFails(t *testing.T) {
+	report := DoctorReport{Checks: []DoctorCheck{
+		{Name: "app-server fetch", OK: true},
+		{Name: "credits", OK: false},
+	}}
+	if report.Healthy() {
+		t.Fatalf("expected failing credits check to make doctor unhealthy")
+	}
+	report.Checks[1].OK = true
+	if !report.Healthy() {
+		t.Fatalf("expected healthy report when credits pass")
+	}
+}
+
+func TestDoctorReportUnhealthyWhenAccountsCannotLoad(t *testing.T) {
+	report := DoctorReport{Checks: []DoctorCheck{
+		{Name: "app-server fetch", OK: true},
+		{Name: "accounts", Details: "could not load accounts: bad json"},
+	}}
+	if report.Healthy() {
+		t.Fatalf("expected an unloadable accounts list to make doctor unhealthy")
+	}
+}
+
+func TestCheckSourceFetchSeparatesAuthFromConnectivity(t *testing.T) {
+	authErr := fmt.Errorf("oauth endpoint returned HTTP 401: expired: %w", ErrAuthRequired)
+	check, _ := checkSourceFetch(context.Background(), &fakeSource{name: "oauth", err: authErr}, time.Second)
+	if check.OK || check.Failure != doctorFailureAuthentication {
+		t.Fatalf("expected authentication failure, got %+v", check)
+	}
+	if !strings.Contains(check.Details, "run `codex login`") {
+		t.Fatalf("expected login remediation, got %q", check.Details)
+	}
+
+	check, _ = checkSourceFetch(context.Background(), &fakeSource{name: "oauth", err: errors.New("dial tcp: no route to host")}, time.Second)
+	if check.OK || check.Failure != doctorFailureConnectivity {
+		t.Fatalf("expected connectivity failure, got %+v", check)
+	}
+	if strings.Contains(check.Details, "codex login") {
+		t.Fatalf("did not expect login remediation for connectivity failure, got %q", check.Details)
+	}
+}
+
+func TestDoctorOptionsCheckTimeoutDefaultsToEightSeconds(t *testing.T) {
+	if got := (DoctorOptions{}).checkTimeout(); got != defaultCheckTimeout {
+		t.Fatalf("expected default %s, got %s", defaultCheckTimeout, got)
+	}
+	if got := (DoctorOptions{CheckTimeout: 30 * time.Second}).checkTimeout(); got != 30*time.Second {
+		t.Fatalf("expected override 30s, got %s", got)
+	}
+}
+
+func TestIsAuthRPCErrorMessage(t *testing.T) {
+	for _, msg := range []string{"Unauthorized", "user is not logged in", "account/read requires OpenAI auth"} {
+		if !isAuthRPCErrorMessage(msg) {
+			t.Fatalf("expected %q to be classified as auth error", msg)
+		}
+	}
+	if isAuthRPCErrorMessage("rate limit backend unavailable") {
+		t.Fatalf("did not expect backend error to be classified as auth error")
+	}
+}
+
+func TestTimedCheckRecordsDurationForFailures(t *testing.T) {
+	check := timedCheck(func() DoctorCheck {
+		time.Sleep(15 * time.Millisecond)
+		return DoctorCheck{Name: "oauth fetch", OK: false, Failure: doctorFailureConnectivity}
+	})
+	if check.OK || check.Failure != doctorFailureConnectivity {
+		t.Fatalf("expected failure to be preserved, got %+v", check)
+	}
+	if check.DurationMS < 15 {
+		t.Fatalf("expected duration >= 15ms, got %d", check.DurationMS)
+	}
+
+	raw, err := json.Marshal(DoctorReport{Checks: []DoctorCheck{check}, TotalDurationMS: 20})
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if !strings.Contains(string(raw), `"duration_ms":`) || !strings.Contains(string(raw), `"total_duration_ms":20`) {
+		t.Fatalf("expected duration fields in JSON, got %s", raw)
+	}
+}
+
+func TestCheckCodexPathWarnsOnMultipleBinaries(t *testing.T) {
+	first := t.TempDir()
+	second := t.TempDir()
+	for _, dir := range []string{first, second} {
+		if err := os.WriteFile(filepath.Join(dir, "codex"), []byte("#!/bin/sh\n"), 0o755); err != nil {
+			t.Fatalf("write codex: %v", err)
+		}
+	}
+	pathEnv := strings.Join([]string{first, second, first}, string(os.PathListSeparator))
+	t.Setenv("PATH", pathEnv)
+
+	check := checkCodexPath("codex", pathEnv)
+	if !check.OK {
+		t.Fatalf("expected codex path check to pass, got %+v", check)
+	}
+	if !strings.Contains(check.Details, "resolved "+filepath.Join(first, "codex")) {
+		t.Fatalf("expected resolved path of first entry, got %q", check.Details)
+	}
+	if !strings.Contains(check.Details, "2 codex binaries on PATH") {
+		t.Fatalf("expected duplicate warning, got %q", check.Details)
+	}
+
+	single := strings.Join([]string{first}, string(os.PathListSeparator))
+	t.Setenv("PATH", single)
+	if check := checkCodexPath("codex", single); strings.Contains(check.Details, "warning") {
+		t.Fatalf("did not expect a warning for a single binary, got %q", check.Details)
+	}
+
+	t.Setenv("PATH", t.TempDir())
+	if check := checkCodexPath("codex", ""); check.OK {
+		t.Fatalf("expected failure when codex is missing, got %+v", check)
+	}
+}
+
+func TestCodexBinariesOnPathUsesConfiguredName(t *testing.T) {
+	first := t.TempDir()
+	second := t.TempDir()
+	for _, dir := range []string{first, second} {
+		if err := os.WriteFile(filepath.Join(dir, "codex-dev"), []byte("#!/bin/sh\n"), 0o755); err != nil {
+			t.Fatalf("write codex-dev: %v", err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(second, "codex"), []byte("#!/bin/sh\n"), 0o755); err != nil {
+		t.Fatalf("write codex: %v", err)
+	}
+	pathEnv := strings.Join([]string{first, second}, string(os.PathListSeparator))
+
+	if runtime.GOOS != "windows" {
+		if got := codexBinariesOnPath("codex-dev", pathEnv); len(got) != 2 {
+			t.Fatalf("expected both codex-dev binaries, got %v", got)
+		}
+	}
+	if got := codexBinariesOnPath(filepath.Join(first, "codex-dev"), pathEnv); got != nil {
+		t.Fatalf("expected an explicit binary path not to be searched, got %v", got)
+	}
+}
+
+func TestCheckAccountSourcesReportsOneRowPerAccount(t *testing.T) {
+	account := MonitorAccount{Label: "work", CodexHome: "/home/user/.codex-work"}
+	ok := &fakeSource{name: "oauth", out: &Summary{Source: "oauth", PlanType: "pro"}}
+	down := &fakeSource{name: "app-server", err: errors.New("stream closed")}
+
+	check := checkAccountSources(context.Background(), account, down, ok, time.Second)
+	if !check.OK || check.Name != "account work" {
+		t.Fatalf("expected the fallback to pass the account row, got %+v", check)
+	}
+	if !strings.Contains(check.Details, "app-server failed: stream closed") {
+		t.Fatalf("expected the primary failure in details, got %q", check.Details)
+	}
+
+	expired := &fakeSource{name: "oauth", err: fmt.Errorf("HTTP 401: %w", ErrAuthRequired)}
+	check = checkAccountSources(context.Background(), account, down, expired, time.Second)
+	if check.OK || check.Failure != doctorFailureAuthentication {
+		t.Fatalf("expected an authentication failure, got %+v", check)
+	}
+	if !strings.HasPrefix(check.Details, account.CodexHome+": app-server: stream closed") {
+		t.Fatalf("expected both source errors, got %q", check.Details)
+	}
+
+	report := DoctorReport{Checks: []DoctorCheck{{Name: "app-server fetch", OK: true}, check}}
+	if report.Healthy() {
+		t.Fatalf("expected a failing account row to make doctor unhealthy")
+	}
+}
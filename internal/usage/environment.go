@@ -0,0 +1,93 @@
+package usage
+
+import (
+	"os"
+	"sort"
+	"strings"
+)
+
+// EnvironmentReport is the resolved setup behind the `config` command: the
+// homes and files the monitor would use and which of its env vars are set.
+// Env var values are left out because the codex env passthrough may carry
+// secrets.
+type EnvironmentReport struct {
+	CodexHome       string           `json:"codex_home"`
+	AccountsFile    string           `json:"accounts_file"`
+	ConfigFile      string           `json:"config_file"`
+	Accounts        []MonitorAccount `json:"accounts"`
+	AccountsWarning string           `json:"accounts_warning,omitempty"`
+	CodexBinary     string           `json:"codex_binary"`
+	UsageEndpoint   string           `json:"usage_endpoint"`
+	EnvVarsSet      []string         `json:"env_vars_set"`
+	Errors          []string         `json:"errors,omitempty"`
+}
+
+// monitorEnvVars lists every variable the usage package reads.
+var monitorEnvVars = []string{
+	"CODEX_HOME",
+	accountsFileEnvVar,
+	configFileEnvVar,
+	codexBinEnvVar,
+	noAuthRestartEnvVar,
+	commandSourceEnvVar,
+	observedMergeEnvVar,
+	maxArchivedFilesEnvVar,
+	sessionGlobEnvVar,
+	primaryWindowEnvVar,
+	secondaryWindowEnvVar,
+	pricingFileEnvVar,
+}
+
+// ResolveEnvironment reports the current setup. extraEnvVars names variables
+// the caller reads itself so they show up alongside the package's own.
+func ResolveEnvironment(extraEnvVars ...string) EnvironmentReport {
+	var report EnvironmentReport
+	if home, err := defaultCodexHome(); err != nil {
+		report.Errors = append(report.Errors, "codex home: "+err.Error())
+	} else {
+		report.CodexHome = home
+	}
+	if path, err := resolveAccountsFilePath(); err != nil {
+		report.Errors = append(report.Errors, "accounts file: "+err.Error())
+	} else {
+		report.AccountsFile = path
+	}
+	if path, err := ConfigFilePath(); err != nil {
+		report.Errors = append(report.Errors, "config file: "+err.Error())
+	} else {
+		report.ConfigFile = path
+	}
+	if command := strings.TrimSpace(os.Getenv(commandSourceEnvVar)); command != "" {
+		report.AccountsWarning = commandSourceEnvVar + " is set; account discovery and the built-in sources are bypassed"
+	} else if accounts, warning, err := loadMonitorAccounts(); err != nil {
+		report.Errors = append(report.Errors, "accounts: "+err.Error())
+	} else {
+		report.Accounts = accounts
+		report.AccountsWarning = warning
+	}
+	report.CodexBinary = codexBinary()
+	report.UsageEndpoint = chatGPTOAuthUsageEndpoint
+	report.EnvVarsSet = setEnvVarNames(os.Environ(), append(monitorEnvVars, extraEnvVars...))
+	return report
+}
+
+// setEnvVarNames returns the names from names, plus any codex env passthrough
+// variables, that are set to a non-blank value in environ.
+func setEnvVarNames(environ []string, names []string) []string {
+	wanted := map[string]bool{}
+	for _, name := range names {
+		wanted[name] = true
+	}
+	out := []string{}
+	for _, entry := range environ {
+		name, value, ok := strings.Cut(entry, "=")
+		if !ok || strings.TrimSpace(value) == "" {
+			continue
+		}
+		if wanted[name] || strings.HasPrefix(name, codexExtraEnvPrefix) {
+			out = append(out, name)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
@@ -2,8 +2,13 @@ package usage
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"math"
+	"os"
 	"path/filepath"
+	"runtime"
 	"sort"
 	"strings"
 	"sync"
@@ -20,8 +25,46 @@ type Fetcher struct {
 	accountLoader           func() ([]MonitorAccount, string, error)
 	accountRefreshInterval  time.Duration
 	accountsLastRefreshedAt time.Time
+	accountSort             AccountSortMode
+	sessionsScope           SessionsScope
+	observedMerge           ObservedMergeMode
+	selectedAccount         string
+	observedWindows         observedWindows
+	// configWarnings are settings problems found at construction; every
+	// summary repeats them.
+	configWarnings []string
+	// pricing prices observed tokens; nil without a pricing file.
+	pricing  pricingTable
+	counters fetchCounters
+	// lastSuccessByHome remembers each account home's last good fetch across
+	// polls. Failed fetches carry no identity, so homes are the stable key.
+	lastSuccessByHome map[string]time.Time
+
+	prestartCancel context.CancelFunc
+	prestartWG     sync.WaitGroup
+}
+
+// FetcherOptions configures optional fetcher behavior; the zero value keeps defaults.
+type FetcherOptions struct {
+	AccountSort AccountSortMode
+	// ObservedMerge combines duplicate identities; empty means max.
+	ObservedMerge ObservedMergeMode
+	// SessionsScope limits observed totals to live or archived sessions.
+	SessionsScope SessionsScope
+	// WarmStart scans the active home's sessions during the first fetch
+	// instead of reporting it as warming.
+	WarmStart bool
+	// Account picks the account, by label or email, whose windows fill the
+	// summary instead of the active CODEX_HOME's.
+	Account string
+	// NoPrestart skips starting app-server sessions during construction, for
+	// environments where the extra startup processes are unwelcome.
+	NoPrestart bool
 }
 
+// prestartTimeout bounds the background session warm-up.
+const prestartTimeout = 30 * time.Second
+
 const unverifiedAccountIdentityKey = "unverified"
 
 type accountFetcher struct {
@@ -41,32 +84,99 @@ type accountFetchResult struct {
 }
 
 type tokenEstimator interface {
-	Estimate(codexHome string, now time.Time) (ObservedTokenEstimate, error)
+	Estimate(ctx context.Context, codexHome string, now time.Time) (ObservedTokenEstimate, error)
 }
 
-func NewDefaultFetcher() *Fetcher {
-	return newConfiguredFetcher(true)
+func NewDefaultFetcher(opts FetcherOptions) *Fetcher {
+	return newConfiguredFetcher(true, opts)
 }
 
-func NewSnapshotFetcher() *Fetcher {
-	return newConfiguredFetcher(false)
+func NewSnapshotFetcher(opts FetcherOptions) *Fetcher {
+	return newConfiguredFetcher(false, opts)
 }
 
-func newConfiguredFetcher(asyncObserved bool) *Fetcher {
+func newConfiguredFetcher(asyncObserved bool, opts FetcherOptions) *Fetcher {
+	// A command source replaces account discovery and local sources entirely;
+	// the command is responsible for the whole summary.
+	if command := strings.TrimSpace(os.Getenv(commandSourceEnvVar)); command != "" {
+		return &Fetcher{primary: NewCommandSource(command)}
+	}
+	windows, windowWarnings := observedWindowsFromEnv()
+	estimator := newObservedTokenEstimator(60*time.Second, asyncObserved, windows)
+	estimator.scope = opts.SessionsScope
+	if asyncObserved && opts.WarmStart {
+		estimator.warmHome = resolveActiveCodexHome()
+	}
 	f := &Fetcher{
-		observed:               newObservedTokenEstimator(60*time.Second, asyncObserved),
+		observed:               estimator,
+		sessionsScope:          opts.SessionsScope,
+		observedMerge:          opts.ObservedMerge,
 		accountLoader:          loadMonitorAccounts,
 		accountRefreshInterval: 60 * time.Second,
+		accountSort:            opts.AccountSort,
+		selectedAccount:        strings.TrimSpace(opts.Account),
+		observedWindows:        windows,
+		configWarnings:         windowWarnings,
 	}
+	pricing, err := loadPricing()
+	if err != nil {
+		f.configWarnings = append(f.configWarnings, fmt.Sprintf("pricing file could not be read: %v", err))
+	}
+	f.pricing = pricing
 	f.refreshAccounts(time.Now().UTC(), true)
+	if !opts.NoPrestart {
+		f.prestartSessions()
+	}
 	return f
 }
 
+// prestarter is a source that can get ready before its first fetch.
+type prestarter interface {
+	Prestart(context.Context) error
+}
+
+// prestartSessions starts every primary source in the background so the first
+// fetch finds a warm session. Failures are left for that fetch to report.
+func (f *Fetcher) prestartSessions() {
+	sources := []Source{f.primary}
+	for _, account := range f.accounts {
+		sources = append(sources, account.primary)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), prestartTimeout)
+	f.prestartCancel = cancel
+	for _, source := range sources {
+		p, ok := source.(prestarter)
+		if !ok {
+			continue
+		}
+		f.prestartWG.Add(1)
+		go func() {
+			defer f.prestartWG.Done()
+			_ = p.Prestart(ctx)
+		}()
+	}
+	go func() {
+		f.prestartWG.Wait()
+		cancel()
+	}()
+}
+
 func (f *Fetcher) Fetch(ctx context.Context) (*Summary, error) {
+	fetch := f.fetchSingle
 	if len(f.accounts) > 0 {
-		return f.fetchMultiAccount(ctx)
+		fetch = f.fetchMultiAccount
 	}
-	return f.fetchSingle(ctx)
+	summary, err := fetch(ctx)
+	if summary != nil {
+		windows := f.observedWindows.withDefaults()
+		// A command source may already report the lookbacks it used.
+		if summary.ObservedPrimaryMins == 0 && summary.ObservedSecondaryMins == 0 {
+			summary.ObservedPrimaryMins = int(windows.Primary / time.Minute)
+			summary.ObservedSecondaryMins = int(windows.Secondary / time.Minute)
+		}
+		applyProjection(summary, windows.Primary, time.Now().UTC())
+	}
+	return summary, err
 }
 
 func (f *Fetcher) fetchSingle(ctx context.Context) (*Summary, error) {
@@ -74,10 +184,14 @@ func (f *Fetcher) fetchSingle(ctx context.Context) (*Summary, error) {
 		return nil, fmt.Errorf("missing primary source")
 	}
 
-	primarySummary, primaryErr := fetchWithFallback(ctx, f.primary, f.fallback)
+	primarySummary, fallbackCause, primaryErr := fetchWithFallback(ctx, f.primary, f.fallback)
+	f.counters.record("default", fallbackCause, primaryErr)
 	if primaryErr != nil {
 		return nil, primaryErr
 	}
+	if f.selectedAccount != "" && !strings.EqualFold(f.selectedAccount, primarySummary.AccountEmail) {
+		primarySummary.Warnings = append(primarySummary.Warnings, fmt.Sprintf("account %q not found; only the active account is configured", f.selectedAccount))
+	}
 	return primarySummary, nil
 }
 
@@ -92,11 +206,14 @@ func (f *Fetcher) fetchMultiAccount(ctx context.Context) (*Summary, error) {
 	if f.initializationNote != "" {
 		out.Warnings = append(out.Warnings, f.initializationNote)
 	}
+	out.Warnings = append(out.Warnings, f.configWarnings...)
 
 	anyAccountSuccess := false
 	anyObservedAvailable := false
 	anyObservedWarming := false
+	var warmingSince *time.Time
 	unavailableObservedCount := 0
+	partialObservedCount := 0
 	totalAccountIdentities := map[string]struct{}{}
 	successfulAccountIdentities := map[string]struct{}{}
 	seenObservedByIdentity := map[string]observedWindowPair{}
@@ -108,23 +225,26 @@ func (f *Fetcher) fetchMultiAccount(ctx context.Context) (*Summary, error) {
 	activeFetchFailed := false
 
 	results := f.fetchAccountsConcurrent(ctx, now)
+	f.recordLastSuccess(results)
+	identities := newIdentityResolver(results)
 	for _, result := range results {
 		accountOut := result.account
-		accountIdentity := accountIdentityOrHomeKey(accountOut, result.codexHome)
+		accountIdentity := identities.key(accountOut)
 		totalAccountIdentities[accountIdentity] = struct{}{}
-		if activeHome != "" && normalizeHome(result.codexHome) == activeHome {
+		isActiveHome := sameCodexHome(result.codexHome, activeHome)
+		if isActiveHome {
 			activeHomeDiscovered = true
 			activeLabel = accountOut.Label
 		}
 		if result.fetchErr != nil {
 			out.Warnings = append(out.Warnings, fmt.Sprintf("account %q fetch failed: %v", accountOut.Label, result.fetchErr))
-			if activeHome != "" && normalizeHome(result.codexHome) == activeHome {
+			if isActiveHome {
 				activeFetchFailed = true
 			}
 		} else if result.snapshot != nil {
 			anyAccountSuccess = true
 			successfulAccountIdentities[accountIdentity] = struct{}{}
-			if activeHome != "" && normalizeHome(result.codexHome) == activeHome {
+			if isActiveHome {
 				activeSuccess = result.snapshot
 				activeLabel = accountOut.Label
 			}
@@ -139,30 +259,61 @@ func (f *Fetcher) fetchMultiAccount(ctx context.Context) (*Summary, error) {
 				pair.WindowWeekly = *accountOut.ObservedWindowWeekly
 			}
 
-			identity := accountIdentityOrHomeKey(accountOut, result.codexHome)
-			prev := seenObservedByIdentity[identity]
-			next := mergeObservedPairMax(prev, pair)
-			seenObservedByIdentity[identity] = next
+			identity := identities.key(accountOut)
+			prev, seen := seenObservedByIdentity[identity]
+			seenObservedByIdentity[identity] = mergeObservedPair(f.observedMerge, prev, pair, seen)
+			out.ObservedContributingAccounts = append(out.ObservedContributingAccounts, accountOut.Label)
 		}
 		if result.observedUnavailable {
 			unavailableObservedCount++
+			out.ObservedMissingAccounts = append(out.ObservedMissingAccounts, accountOut.Label)
+		}
+		if result.observedAvailable && accountOut.ObservedTokensStatus == observedTokensStatusPartial {
+			partialObservedCount++
 		}
 		if result.account.ObservedTokensWarming {
 			anyObservedWarming = true
+			if since := result.account.ObservedTokensWarmingSince; since != nil && (warmingSince == nil || since.Before(*warmingSince)) {
+				warmingSince = since
+			}
 		}
 		out.Warnings = append(out.Warnings, result.warnings...)
 		existing, ok := accountByIdentity[accountIdentity]
 		if !ok || shouldPreferAccountSummary(existing, accountOut, result.codexHome, activeHome) {
+			if ok {
+				fillMissingIdentity(&accountOut, existing.account)
+			}
 			accountByIdentity[accountIdentity] = accountSummaryWithHome{
 				account:   accountOut,
 				codexHome: result.codexHome,
 			}
+		} else {
+			fillMissingIdentity(&existing.account, accountOut)
+			accountByIdentity[accountIdentity] = existing
 		}
 	}
 	out.Accounts = accountSummariesFromIdentityMap(accountByIdentity)
+	sortAccountSummaries(out.Accounts, f.accountSort)
+	// AdditionalLimitCount stays the active account's; this total spans every
+	// reachable identity, counted once each.
+	for _, account := range out.Accounts {
+		if account.Error == "" {
+			out.TotalAdditionalLimitCount += account.AdditionalLimitCount
+		}
+	}
 	out.TotalAccounts = len(totalAccountIdentities)
 	out.SuccessfulAccounts = len(successfulAccountIdentities)
 
+	if f.selectedAccount != "" {
+		selected, label, warning := selectAccountResult(results, f.selectedAccount)
+		if selected != nil {
+			activeSuccess = selected
+			activeLabel = label
+		} else {
+			out.Warnings = append(out.Warnings, warning)
+		}
+	}
+
 	if activeSuccess != nil {
 		out.Source = activeSuccess.Source
 		out.PlanType = activeSuccess.PlanType
@@ -174,6 +325,7 @@ func (f *Fetcher) fetchMultiAccount(ctx context.Context) (*Summary, error) {
 		out.SecondaryWindow = activeSuccess.SecondaryWindow
 		out.WindowAccountLabel = activeLabel
 		out.AdditionalLimitCount = activeSuccess.AdditionalLimitCount
+		out.AdditionalLimits = activeSuccess.AdditionalLimits
 		out.FetchedAt = activeSuccess.FetchedAt
 	} else {
 		out.WindowDataAvailable = false
@@ -182,7 +334,11 @@ func (f *Fetcher) fetchMultiAccount(ctx context.Context) (*Summary, error) {
 		case activeHome == "":
 			out.Warnings = append(out.Warnings, "active account home is unavailable; window cards are unavailable")
 		case !activeHomeDiscovered:
-			out.Warnings = append(out.Warnings, "active account home is not in discovered accounts; window cards are unavailable")
+			homes := make([]string, 0, len(results))
+			for _, result := range results {
+				homes = append(homes, result.codexHome)
+			}
+			out.Warnings = append(out.Warnings, activeHomeMissingWarning(activeHome, homes))
 		case activeFetchFailed:
 			out.Warnings = append(out.Warnings, "active account usage fetch failed; window cards are unavailable")
 		default:
@@ -195,21 +351,29 @@ func (f *Fetcher) fetchMultiAccount(ctx context.Context) (*Summary, error) {
 		for _, pair := range seenObservedByIdentity {
 			observedTotal = addObservedPairs(observedTotal, pair)
 		}
+		if observedTotal.Window5h.Total == math.MaxInt64 || observedTotal.WindowWeekly.Total == math.MaxInt64 {
+			out.Warnings = append(out.Warnings, "observed token totals reached the int64 limit and were capped")
+		}
 		out.ObservedTokensStatus = observedTokensStatusEstimated
 		out.ObservedWindow5h = &observedTotal.Window5h
 		out.ObservedWindowWeekly = &observedTotal.WindowWeekly
 		out.ObservedTokens5h = int64Ptr(observedTotal.Window5h.Total)
 		out.ObservedTokensWeekly = int64Ptr(observedTotal.WindowWeekly.Total)
-		out.ObservedTokensNote = "sum across accounts"
+		noteSuffix := f.sessionsScope.noteSuffix() + f.observedWindows.noteSuffix()
+		out.ObservedTokensNote = "sum across accounts" + noteSuffix
 		out.ObservedTokensWarming = false
 		if unavailableObservedCount > 0 {
 			out.ObservedTokensStatus = observedTokensStatusPartial
-			out.ObservedTokensNote = "partial sum across accounts; some account homes unavailable"
+			out.ObservedTokensNote = "partial sum across accounts; some account homes unavailable" + noteSuffix
+		} else if partialObservedCount > 0 {
+			out.ObservedTokensStatus = observedTokensStatusPartial
+			out.ObservedTokensNote = "partial sum across accounts; archived sessions capped" + noteSuffix
 		}
 	} else if unavailableObservedCount > 0 {
 		out.ObservedTokensStatus = observedTokensStatusUnavailable
 		out.ObservedTokensNote = "token estimate warming or unavailable"
 		out.ObservedTokensWarming = anyObservedWarming
+		out.ObservedTokensWarmingSince = warmingSince
 	}
 
 	out.Warnings = dedupeStrings(out.Warnings)
@@ -220,34 +384,95 @@ func (f *Fetcher) fetchMultiAccount(ctx context.Context) (*Summary, error) {
 	return out, nil
 }
 
-func fetchWithFallback(ctx context.Context, primary Source, fallback Source) (*Summary, error) {
+// selectAccountResult finds the fetched snapshot for an account requested by
+// label or email. It returns a warning instead when the account is unknown or
+// its fetch failed, and the caller keeps the active home's windows.
+func selectAccountResult(results []accountFetchResult, want string) (*Summary, string, string) {
+	matched := false
+	for _, result := range results {
+		account := result.account
+		email := account.AccountEmail
+		if email == "" && result.snapshot != nil {
+			email = result.snapshot.AccountEmail
+		}
+		if !strings.EqualFold(account.Label, want) && !strings.EqualFold(email, want) {
+			continue
+		}
+		if result.snapshot != nil {
+			return result.snapshot, account.Label, ""
+		}
+		matched = true
+	}
+	if matched {
+		return nil, "", fmt.Sprintf("account %q fetch failed; showing the active account's windows", want)
+	}
+	return nil, "", fmt.Sprintf("account %q not found; showing the active account's windows", want)
+}
+
+// recordLastSuccess updates the per-home memory from this poll and stamps it
+// on every result. Homes no longer monitored are forgotten.
+func (f *Fetcher) recordLastSuccess(results []accountFetchResult) {
+	next := make(map[string]time.Time, len(results))
+	for i := range results {
+		result := &results[i]
+		last, ok := f.lastSuccessByHome[result.codexHome]
+		if result.snapshot != nil && result.account.FetchedAt != nil && result.account.FetchedAt.After(last) {
+			last, ok = *result.account.FetchedAt, true
+		}
+		if !ok {
+			continue
+		}
+		next[result.codexHome] = last
+		result.account.LastSuccessAt = &last
+	}
+	f.lastSuccessByHome = next
+}
+
+// fetchWithFallback also returns the primary's error when the fallback
+// produced the summary, so callers can record the degraded path.
+func fetchWithFallback(ctx context.Context, primary Source, fallback Source) (summary *Summary, primaryErr error, err error) {
 	if primary == nil {
-		return nil, fmt.Errorf("missing primary source")
+		return nil, nil, fmt.Errorf("missing primary source")
 	}
 
 	primarySummary, primaryErr := primary.Fetch(ctx)
 	if primaryErr == nil {
-		return primarySummary, nil
+		return primarySummary, nil, nil
 	}
 
 	if fallback == nil {
-		return nil, fmt.Errorf("primary source %q failed: %w", primary.Name(), primaryErr)
+		return nil, nil, fmt.Errorf("primary source %q failed: %w", primary.Name(), primaryErr)
 	}
 
 	fallbackSummary, fallbackErr := fallback.Fetch(ctx)
 	if fallbackErr == nil {
-		fallbackSummary.Warnings = append(fallbackSummary.Warnings, fmt.Sprintf("primary source %q failed: %v", primary.Name(), primaryErr))
-		return fallbackSummary, nil
+		warning := fmt.Sprintf("primary source %q failed: %v", primary.Name(), primaryErr)
+		if errors.Is(primaryErr, ErrAuthRequired) {
+			warning += "; run `codex login` to re-authenticate"
+		}
+		fallbackSummary.Warnings = append(fallbackSummary.Warnings, warning)
+		return fallbackSummary, primaryErr, nil
 	}
 
-	return nil, fmt.Errorf(
+	return nil, nil, fmt.Errorf(
 		"primary source %q failed: %v; fallback source %q failed: %v",
 		primary.Name(), primaryErr, fallback.Name(), fallbackErr,
 	)
 }
 
 func (f *Fetcher) Close() error {
+	// Stop the warm-up first so it cannot start a session after its source
+	// is closed.
+	if f.prestartCancel != nil {
+		f.prestartCancel()
+		f.prestartWG.Wait()
+	}
 	var firstErr error
+	if closer, ok := f.observed.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			firstErr = err
+		}
+	}
 	for _, account := range f.accounts {
 		if account.primary != nil {
 			if err := account.primary.Close(); err != nil && firstErr == nil {
@@ -273,6 +498,103 @@ func (f *Fetcher) Close() error {
 	return firstErr
 }
 
+// Accounts returns the configured monitor accounts, available before the first fetch.
+func (f *Fetcher) Accounts() []MonitorAccount {
+	if len(f.accounts) == 0 {
+		return nil
+	}
+	out := make([]MonitorAccount, 0, len(f.accounts))
+	for _, account := range f.accounts {
+		out = append(out, account.account)
+	}
+	return out
+}
+
+// ClearObservedCache drops cached observed-token estimates for every account.
+func (f *Fetcher) ClearObservedCache() {
+	if clearer, ok := f.observed.(interface{ ClearCache() }); ok {
+		clearer.ClearCache()
+	}
+}
+
+// ResourceStats is a point-in-time count of resources the fetcher holds.
+type ResourceStats struct {
+	AppServerSessions    int
+	ObservedCacheEntries int
+	Fetches              FetchCounters
+}
+
+// FetchCounters are cumulative source fetch outcomes since the fetcher
+// started. Each account fetch counts once, whichever source answered.
+type FetchCounters struct {
+	Fetches           int64 `json:"fetches"`
+	PrimarySuccesses  int64 `json:"primary_successes"`
+	FallbackSuccesses int64 `json:"fallback_successes"`
+	Failures          int64 `json:"failures"`
+}
+
+func (c *FetchCounters) add(primaryErr, err error) {
+	c.Fetches++
+	switch {
+	case err != nil:
+		c.Failures++
+	case primaryErr != nil:
+		c.FallbackSuccesses++
+	default:
+		c.PrimarySuccesses++
+	}
+}
+
+// fetchCounters is safe for the concurrent per-account fetches.
+type fetchCounters struct {
+	mu        sync.Mutex
+	total     FetchCounters
+	byAccount map[string]FetchCounters
+}
+
+func (c *fetchCounters) record(label string, primaryErr, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.byAccount == nil {
+		c.byAccount = map[string]FetchCounters{}
+	}
+	c.total.add(primaryErr, err)
+	account := c.byAccount[label]
+	account.add(primaryErr, err)
+	c.byAccount[label] = account
+}
+
+// Counters returns the cumulative fetch outcomes in total and per account label.
+func (f *Fetcher) Counters() (FetchCounters, map[string]FetchCounters) {
+	f.counters.mu.Lock()
+	defer f.counters.mu.Unlock()
+	byAccount := make(map[string]FetchCounters, len(f.counters.byAccount))
+	for label, counters := range f.counters.byAccount {
+		byAccount[label] = counters
+	}
+	return f.counters.total, byAccount
+}
+
+// Stats reports live app-server sessions and observed-token cache size. It
+// reads the account list unguarded, so call it from the goroutine that calls Fetch.
+func (f *Fetcher) Stats() ResourceStats {
+	var out ResourceStats
+	sources := []Source{f.primary, f.fallback}
+	for _, account := range f.accounts {
+		sources = append(sources, account.primary, account.fallback)
+	}
+	for _, source := range sources {
+		if appServer, ok := source.(*AppServerSource); ok && appServer.sessionRunning() {
+			out.AppServerSessions++
+		}
+	}
+	if estimator, ok := f.observed.(*observedTokenEstimator); ok {
+		out.ObservedCacheEntries = estimator.cacheEntries()
+	}
+	out.Fetches, _ = f.Counters()
+	return out
+}
+
 func (f *Fetcher) Primary() Source {
 	return f.primary
 }
@@ -281,6 +603,14 @@ func (f *Fetcher) Fallback() Source {
 	return f.fallback
 }
 
+func warmingSincePtr(estimate ObservedTokenEstimate) *time.Time {
+	if !estimate.Warming || estimate.WarmingSince.IsZero() {
+		return nil
+	}
+	since := estimate.WarmingSince
+	return &since
+}
+
 func int64Ptr(v int64) *int64 {
 	out := v
 	return &out
@@ -375,6 +705,100 @@ func normalizeHome(home string) string {
 	return filepath.Clean(normalized)
 }
 
+// activeHomeMissingWarning explains why window cards are blank when the active
+// home was not discovered, naming the nearest monitored homes and the likely fix.
+func activeHomeMissingWarning(activeHome string, homes []string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "active account home %s is not in discovered accounts; window cards are unavailable", activeHome)
+	if closest := closestHomes(activeHome, homes, 3); len(closest) > 0 {
+		fmt.Fprintf(&b, "; closest monitored homes: %s", strings.Join(closest, ", "))
+	}
+	if !hasUsageSignals(activeHome) {
+		b.WriteString("; discovery skipped it because it has no auth.json, sessions, or archived_sessions")
+	}
+	if accountsPath, err := resolveAccountsFilePath(); err == nil {
+		fmt.Fprintf(&b, "; add it to %s to monitor it explicitly", accountsPath)
+	} else {
+		b.WriteString("; add it to accounts.json to monitor it explicitly")
+	}
+	return b.String()
+}
+
+// closestHomes returns up to limit homes ordered by edit distance to target.
+func closestHomes(target string, homes []string, limit int) []string {
+	type candidate struct {
+		home     string
+		distance int
+	}
+	seen := map[string]struct{}{}
+	candidates := make([]candidate, 0, len(homes))
+	for _, home := range homes {
+		normalized := normalizeHome(home)
+		if normalized == "" {
+			continue
+		}
+		if _, ok := seen[normalized]; ok {
+			continue
+		}
+		seen[normalized] = struct{}{}
+		candidates = append(candidates, candidate{home: normalized, distance: levenshtein(target, normalized)})
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].distance != candidates[j].distance {
+			return candidates[i].distance < candidates[j].distance
+		}
+		return candidates[i].home < candidates[j].home
+	})
+	out := make([]string, 0, min(limit, len(candidates)))
+	for i := 0; i < len(candidates) && i < limit; i++ {
+		out = append(out, candidates[i].home)
+	}
+	return out
+}
+
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+// caseInsensitiveHomePaths reports whether home paths compare case-insensitively
+// when neither side can be stat'd (the default macOS and Windows filesystems).
+var caseInsensitiveHomePaths = runtime.GOOS == "darwin" || runtime.GOOS == "windows"
+
+// sameCodexHome reports whether two homes are the same directory. Symlink
+// chains and case-only differences can survive normalizeHome, so existing
+// directories are compared by file identity.
+func sameCodexHome(a, b string) bool {
+	left, right := normalizeHome(a), normalizeHome(b)
+	if left == "" || right == "" {
+		return false
+	}
+	if left == right {
+		return true
+	}
+	leftInfo, leftErr := os.Stat(left)
+	rightInfo, rightErr := os.Stat(right)
+	if leftErr == nil && rightErr == nil {
+		return os.SameFile(leftInfo, rightInfo)
+	}
+	return caseInsensitiveHomePaths && strings.EqualFold(left, right)
+}
+
 func resolveActiveCodexHome() string {
 	home, err := defaultCodexHome()
 	if err != nil {
@@ -383,6 +807,10 @@ func resolveActiveCodexHome() string {
 	return normalizeHome(home)
 }
 
+// identitySourceAuthFile marks account identity and plan read from the local
+// auth.json id_token because the live fetch failed.
+const identitySourceAuthFile = "auth.json"
+
 func identityKey(email, accountID, userID string) string {
 	if v := strings.TrimSpace(email); v != "" {
 		return "email:" + strings.ToLower(v)
@@ -403,6 +831,101 @@ func accountIdentityOrHomeKey(account AccountSummary, _ string) string {
 	return unverifiedAccountIdentityKey
 }
 
+// identityResolver treats rows sharing any of email, account_id, or user_id
+// as one account. The app-server reports only an email while OAuth adds the
+// ids, so the same account can arrive with different identifier sets. Sets
+// with different emails are never merged: users in one ChatGPT workspace
+// share an account_id.
+type identityResolver struct {
+	parent map[string]string
+	// emails maps each set root to the email seen in that set, if any.
+	emails map[string]string
+}
+
+func newIdentityResolver(results []accountFetchResult) *identityResolver {
+	r := &identityResolver{parent: map[string]string{}, emails: map[string]string{}}
+	for _, result := range results {
+		parts := identityParts(result.account)
+		for _, part := range parts {
+			r.union(parts[0], part)
+		}
+	}
+	return r
+}
+
+func identityParts(account AccountSummary) []string {
+	var parts []string
+	for _, part := range []struct{ prefix, value string }{
+		{"email:", account.AccountEmail},
+		{"account_id:", account.AccountID},
+		{"user_id:", account.UserID},
+	} {
+		if v := strings.TrimSpace(part.value); v != "" {
+			parts = append(parts, part.prefix+strings.ToLower(v))
+		}
+	}
+	return parts
+}
+
+func (r *identityResolver) find(key string) string {
+	for {
+		parent, ok := r.parent[key]
+		if !ok || parent == key {
+			return key
+		}
+		key = parent
+	}
+}
+
+// union keeps the smaller key as root so keys do not depend on result order.
+// It leaves the sets apart when both carry an email and the emails differ.
+func (r *identityResolver) union(a, b string) {
+	for _, key := range []string{a, b} {
+		if email, ok := strings.CutPrefix(key, "email:"); ok && r.find(key) == key && r.emails[key] == "" {
+			r.emails[key] = email
+		}
+	}
+	rootA, rootB := r.find(a), r.find(b)
+	if rootA == rootB {
+		return
+	}
+	emailA, emailB := r.emails[rootA], r.emails[rootB]
+	if emailA != "" && emailB != "" && emailA != emailB {
+		return
+	}
+	if rootB < rootA {
+		rootA, rootB = rootB, rootA
+	}
+	r.parent[rootB] = rootA
+	if emailA == "" {
+		emailA = emailB
+	}
+	r.emails[rootA] = emailA
+	delete(r.emails, rootB)
+}
+
+func (r *identityResolver) key(account AccountSummary) string {
+	identity := accountIdentityOrHomeKey(account, "")
+	if identity == unverifiedAccountIdentityKey {
+		return identity
+	}
+	return r.find(identity)
+}
+
+// fillMissingIdentity copies identifiers the chosen row lacks from a merged
+// row, so the representative carries the richest identity.
+func fillMissingIdentity(dst *AccountSummary, src AccountSummary) {
+	if strings.TrimSpace(dst.AccountEmail) == "" {
+		dst.AccountEmail = src.AccountEmail
+	}
+	if strings.TrimSpace(dst.AccountID) == "" {
+		dst.AccountID = src.AccountID
+	}
+	if strings.TrimSpace(dst.UserID) == "" {
+		dst.UserID = src.UserID
+	}
+}
+
 type accountSummaryWithHome struct {
 	account   AccountSummary
 	codexHome string
@@ -415,19 +938,22 @@ func shouldPreferAccountSummary(existing accountSummaryWithHome, candidate Accou
 		return candidateOK
 	}
 
-	existingActive := activeHome != "" && normalizeHome(existing.codexHome) == activeHome
-	candidateActive := activeHome != "" && normalizeHome(candidateHome) == activeHome
+	existingActive := sameCodexHome(existing.codexHome, activeHome)
+	candidateActive := sameCodexHome(candidateHome, activeHome)
 	if existingActive != candidateActive {
 		return candidateActive
 	}
 
-	if existing.account.FetchedAt == nil {
-		return candidate.FetchedAt != nil
-	}
-	if candidate.FetchedAt == nil {
+	switch {
+	case existing.account.FetchedAt == nil && candidate.FetchedAt != nil:
+		return true
+	case existing.account.FetchedAt != nil && candidate.FetchedAt == nil:
 		return false
+	case existing.account.FetchedAt != nil && !candidate.FetchedAt.Equal(*existing.account.FetchedAt):
+		return candidate.FetchedAt.After(*existing.account.FetchedAt)
 	}
-	return candidate.FetchedAt.After(*existing.account.FetchedAt)
+	// Full tie: pick by home so the representative does not depend on result order.
+	return normalizeHome(candidateHome) < normalizeHome(existing.codexHome)
 }
 
 func accountSummariesFromIdentityMap(byIdentity map[string]accountSummaryWithHome) []AccountSummary {
@@ -450,6 +976,62 @@ func accountSummariesFromIdentityMap(byIdentity map[string]accountSummaryWithHom
 	return accounts
 }
 
+type AccountSortMode string
+
+const (
+	AccountSortLabel  AccountSortMode = "label"
+	AccountSortUsage  AccountSortMode = "usage"
+	AccountSortTokens AccountSortMode = "tokens"
+)
+
+func ParseAccountSortMode(value string) (AccountSortMode, error) {
+	switch mode := AccountSortMode(strings.ToLower(strings.TrimSpace(value))); mode {
+	case "":
+		return AccountSortLabel, nil
+	case AccountSortLabel, AccountSortUsage, AccountSortTokens:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unsupported sort %q (expected label, usage, or tokens)", value)
+	}
+}
+
+// sortAccountSummaries reorders label-sorted accounts hottest first. The sort
+// is stable so ties keep label order.
+func sortAccountSummaries(accounts []AccountSummary, mode AccountSortMode) {
+	switch mode {
+	case AccountSortUsage:
+		sort.SliceStable(accounts, func(i, j int) bool {
+			return accountUsageRank(accounts[i]) > accountUsageRank(accounts[j])
+		})
+	case AccountSortTokens:
+		sort.SliceStable(accounts, func(i, j int) bool {
+			a, b := accountTokenRank(accounts[i]), accountTokenRank(accounts[j])
+			if a[0] != b[0] {
+				return a[0] > b[0]
+			}
+			return a[1] > b[1]
+		})
+	}
+}
+
+func accountUsageRank(account AccountSummary) int {
+	if strings.TrimSpace(account.Error) != "" || account.FetchedAt == nil {
+		return -1
+	}
+	return max(account.PrimaryWindow.UsedPercent, account.SecondaryWindow.UsedPercent)
+}
+
+func accountTokenRank(account AccountSummary) [2]int64 {
+	rank := [2]int64{-1, -1}
+	if account.ObservedTokens5h != nil {
+		rank[0] = *account.ObservedTokens5h
+	}
+	if account.ObservedTokensWeekly != nil {
+		rank[1] = *account.ObservedTokensWeekly
+	}
+	return rank
+}
+
 func addObservedPairs(a, b observedWindowPair) observedWindowPair {
 	return observedWindowPair{
 		Window5h:     addBreakdowns(a.Window5h, b.Window5h),
@@ -459,14 +1041,85 @@ func addObservedPairs(a, b observedWindowPair) observedWindowPair {
 
 func addBreakdowns(a, b ObservedTokenBreakdown) ObservedTokenBreakdown {
 	return ObservedTokenBreakdown{
-		Total:           a.Total + b.Total,
-		Input:           a.Input + b.Input,
-		CachedInput:     a.CachedInput + b.CachedInput,
-		Output:          a.Output + b.Output,
-		ReasoningOutput: a.ReasoningOutput + b.ReasoningOutput,
-		CachedOutput:    a.CachedOutput + b.CachedOutput,
+		Total:           saturatingAdd(a.Total, b.Total),
+		Input:           saturatingAdd(a.Input, b.Input),
+		CachedInput:     saturatingAdd(a.CachedInput, b.CachedInput),
+		Output:          saturatingAdd(a.Output, b.Output),
+		ReasoningOutput: saturatingAdd(a.ReasoningOutput, b.ReasoningOutput),
+		CachedOutput:    saturatingAdd(a.CachedOutput, b.CachedOutput),
 		HasSplit:        a.HasSplit || b.HasSplit,
 		HasCachedOutput: a.HasCachedOutput || b.HasCachedOutput,
+		LastEventAt:     laterTime(a.LastEventAt, b.LastEventAt),
+		// A total is only priced when every part of it was.
+		EstimatedCostUSD: addCosts(a, b),
+	}
+}
+
+func addCosts(a, b ObservedTokenBreakdown) *float64 {
+	switch {
+	case a.EstimatedCostUSD != nil && b.EstimatedCostUSD != nil:
+		total := *a.EstimatedCostUSD + *b.EstimatedCostUSD
+		return &total
+	case a.EstimatedCostUSD != nil && b.Total == 0:
+		return a.EstimatedCostUSD
+	case b.EstimatedCostUSD != nil && a.Total == 0:
+		return b.EstimatedCostUSD
+	}
+	return nil
+}
+
+func laterTime(a, b *time.Time) *time.Time {
+	if a == nil || (b != nil && b.After(*a)) {
+		return b
+	}
+	return a
+}
+
+// ObservedMergeMode decides how observed totals combine across homes that
+// share an account identity.
+type ObservedMergeMode string
+
+const (
+	// ObservedMergeMax keeps the larger home, so copied homes are not double counted.
+	ObservedMergeMax ObservedMergeMode = "max"
+	// ObservedMergeSum adds every home, for one account used from several homes.
+	ObservedMergeSum ObservedMergeMode = "sum"
+	// ObservedMergeLatest keeps the home with the most recent token event.
+	ObservedMergeLatest ObservedMergeMode = "latest"
+
+	observedMergeEnvVar = "CODEX_USAGE_MONITOR_OBSERVED_MERGE"
+)
+
+// ParseObservedMergeMode falls back to CODEX_USAGE_MONITOR_OBSERVED_MERGE and
+// then to max when value is empty.
+func ParseObservedMergeMode(value string) (ObservedMergeMode, error) {
+	if strings.TrimSpace(value) == "" {
+		value = os.Getenv(observedMergeEnvVar)
+	}
+	switch mode := ObservedMergeMode(strings.ToLower(strings.TrimSpace(value))); mode {
+	case "":
+		return ObservedMergeMax, nil
+	case ObservedMergeMax, ObservedMergeSum, ObservedMergeLatest:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unsupported observed merge %q (expected max, sum, or latest)", value)
+	}
+}
+
+func mergeObservedPair(mode ObservedMergeMode, prev, next observedWindowPair, seen bool) observedWindowPair {
+	if !seen {
+		return next
+	}
+	switch mode {
+	case ObservedMergeSum:
+		return addObservedPairs(prev, next)
+	case ObservedMergeLatest:
+		if laterTime(prev.WindowWeekly.LastEventAt, next.WindowWeekly.LastEventAt) != prev.WindowWeekly.LastEventAt {
+			return next
+		}
+		return prev
+	default:
+		return mergeObservedPairMax(prev, next)
 	}
 }
 
@@ -521,10 +1174,18 @@ func (f *Fetcher) fetchAccountResult(ctx context.Context, account accountFetcher
 		},
 	}
 
-	snapshot, fetchErr := fetchWithFallback(ctx, account.primary, account.fallback)
+	snapshot, primaryErr, fetchErr := fetchWithFallback(ctx, account.primary, account.fallback)
+	f.counters.record(account.account.Label, primaryErr, fetchErr)
 	if fetchErr != nil {
 		result.fetchErr = fetchErr
 		result.account.Error = fetchErr.Error()
+		if identity, plan, err := readAuthIdentity(account.account.CodexHome); err == nil {
+			result.account.PlanType = plan
+			result.account.AccountEmail = identity.Email
+			result.account.AccountID = identity.AccountID
+			result.account.UserID = identity.UserID
+			result.account.IdentitySource = identitySourceAuthFile
+		}
 	} else {
 		result.snapshot = snapshot
 		result.account.Source = snapshot.Source
@@ -535,24 +1196,34 @@ func (f *Fetcher) fetchAccountResult(ctx context.Context, account accountFetcher
 		result.account.PrimaryWindow = snapshot.PrimaryWindow
 		result.account.SecondaryWindow = snapshot.SecondaryWindow
 		result.account.AdditionalLimitCount = snapshot.AdditionalLimitCount
+		result.account.AdditionalLimits = snapshot.AdditionalLimits
+		if primaryErr != nil {
+			result.account.FallbackUsed = true
+			result.account.PrimaryError = primaryErr.Error()
+		}
 		result.account.Warnings = append(result.account.Warnings, snapshot.Warnings...)
 		ts := snapshot.FetchedAt
 		result.account.FetchedAt = &ts
 	}
 
 	if f.observed != nil {
-		estimate, estimateErr := f.observed.Estimate(account.account.CodexHome, now)
+		estimate, estimateErr := f.observed.Estimate(ctx, account.account.CodexHome, now)
 		if estimateErr != nil {
 			result.account.ObservedTokensStatus = observedTokensStatusUnavailable
 			result.account.ObservedTokensNote = estimate.Note
 			result.account.ObservedTokensWarming = estimate.Warming
+			result.account.ObservedTokensWarmingSince = warmingSincePtr(estimate)
 			result.observedUnavailable = true
 			result.warnings = append(result.warnings, fmt.Sprintf("account %q observed tokens unavailable: %v", account.account.Label, estimateErr))
 		} else {
 			result.account.ObservedTokensStatus = estimate.Status
 			result.account.ObservedTokensNote = estimate.Note
 			result.account.ObservedTokensWarming = estimate.Warming
+			result.account.ObservedTokensWarmingSince = warmingSincePtr(estimate)
 			result.account.Warnings = append(result.account.Warnings, estimate.Warnings...)
+			if warning := applyPricing(&estimate, f.pricing); warning != "" {
+				result.warnings = append(result.warnings, fmt.Sprintf("account %q %s", account.account.Label, warning))
+			}
 			result.account.ObservedWindow5h = &estimate.Window5h
 			result.account.ObservedWindowWeekly = &estimate.WindowWeekly
 			result.account.ObservedTokens5h = int64Ptr(estimate.Window5h.Total)
@@ -2,9 +2,12 @@ package usage
 
 import (
 	"context"
+	"encoding/base64"
 	"errors"
 	"fmt"
+	"math"
 	"math/rand"
+	"os"
 	"path/filepath"
 	"strings"
 	"testing"
@@ -44,6 +47,28 @@ func TestFetcherUsesPrimaryOnSuccess(t *testing.T) {
 	}
 }
 
+func TestFetcherPrestartWarmsAppServerSession(t *testing.T) {
+	source := newFakeAppServerSource(t, "ok")
+	f := &Fetcher{primary: source}
+	f.prestartSessions()
+	f.prestartWG.Wait()
+	if !source.sessionRunning() {
+		t.Fatalf("expected the prestart to leave a running session")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if out, err := f.Fetch(ctx); err != nil || out.PrimaryWindow.UsedPercent != 35 {
+		t.Fatalf("expected the first fetch to use the warm session, got %+v, %v", out, err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	if source.sessionRunning() {
+		t.Fatalf("expected close to stop the prestarted session")
+	}
+}
+
 func TestFetcherFallsBackWithWarning(t *testing.T) {
 	primary := &fakeSource{name: "primary", err: errors.New("boom")}
 	fallback := &fakeSource{name: "fallback", out: &Summary{Source: "fallback"}}
@@ -96,7 +121,7 @@ type fakeEstimator struct {
 	errs   map[string]error
 }
 
-func (f fakeEstimator) Estimate(codexHome string, _ time.Time) (ObservedTokenEstimate, error) {
+func (f fakeEstimator) Estimate(_ context.Context, codexHome string, _ time.Time) (ObservedTokenEstimate, error) {
 	if err, ok := f.errs[codexHome]; ok {
 		return ObservedTokenEstimate{
 			Status: observedTokensStatusUnavailable,
@@ -237,6 +262,44 @@ func TestFetcherAllowsObservedOnlyWhenAllSourcesFail(t *testing.T) {
 	}
 }
 
+func TestFetcherRemembersLastSuccessPerAccount(t *testing.T) {
+	first := time.Date(2026, 2, 26, 15, 0, 0, 0, time.UTC)
+	second := first.Add(time.Minute)
+	primaryA := &fakeSource{name: "primary-a", out: &Summary{AccountEmail: "a@example.com", FetchedAt: first}}
+	primaryB := &fakeSource{name: "primary-b", out: &Summary{AccountEmail: "b@example.com", FetchedAt: first}}
+	fallbackB := &fakeSource{name: "fallback-b", err: errors.New("f")}
+	f := &Fetcher{
+		accounts: []accountFetcher{
+			{account: MonitorAccount{Label: "a", CodexHome: "/a"}, primary: primaryA, fallback: &fakeSource{name: "fallback-a"}},
+			{account: MonitorAccount{Label: "b", CodexHome: "/b"}, primary: primaryB, fallback: fallbackB},
+		},
+	}
+	if _, err := f.Fetch(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	primaryA.out.FetchedAt = second
+	primaryB.err = errors.New("p")
+	out, err := f.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	byLabel := map[string]AccountSummary{}
+	for _, account := range out.Accounts {
+		byLabel[account.Label] = account
+	}
+	if a := byLabel["a"]; a.LastSuccessAt == nil || !a.LastSuccessAt.Equal(second) {
+		t.Fatalf("expected a's last success to follow its fetch, got %+v", a.LastSuccessAt)
+	}
+	b := byLabel["b"]
+	if b.Error == "" || b.FetchedAt != nil {
+		t.Fatalf("expected b to be failing in the second poll, got %+v", b)
+	}
+	if b.LastSuccessAt == nil || !b.LastSuccessAt.Equal(first) {
+		t.Fatalf("expected b to keep its last success from the first poll, got %v", b.LastSuccessAt)
+	}
+}
+
 func TestFetcherMarksObservedPartialWhenSomeAccountsUnavailable(t *testing.T) {
 	f := &Fetcher{
 		accounts: []accountFetcher{
@@ -263,6 +326,7 @@ func TestFetcherMarksObservedPartialWhenSomeAccountsUnavailable(t *testing.T) {
 				"/b": errors.New("missing logs"),
 			},
 		},
+		observedWindows: observedWindows{Primary: 2 * time.Hour, Secondary: 24 * time.Hour},
 	}
 
 	out, err := f.Fetch(context.Background())
@@ -272,9 +336,21 @@ func TestFetcherMarksObservedPartialWhenSomeAccountsUnavailable(t *testing.T) {
 	if out.ObservedTokensStatus != observedTokensStatusPartial {
 		t.Fatalf("expected partial observed status, got %q", out.ObservedTokensStatus)
 	}
+	if !strings.HasSuffix(out.ObservedTokensNote, " (windows 2h/1d)") {
+		t.Fatalf("expected the partial note to keep the windows suffix, got %q", out.ObservedTokensNote)
+	}
+	if out.ObservedPrimaryMins != 120 || out.ObservedSecondaryMins != 24*60 {
+		t.Fatalf("expected the summary to carry the 2h/24h lookbacks, got %d/%d", out.ObservedPrimaryMins, out.ObservedSecondaryMins)
+	}
 	if out.ObservedTokens5h == nil || *out.ObservedTokens5h != 10 {
 		t.Fatalf("expected partial observed 5h total from available accounts")
 	}
+	if len(out.ObservedContributingAccounts) != 1 || out.ObservedContributingAccounts[0] != "a" {
+		t.Fatalf("expected account a to be listed as contributing, got %v", out.ObservedContributingAccounts)
+	}
+	if len(out.ObservedMissingAccounts) != 1 || out.ObservedMissingAccounts[0] != "b" {
+		t.Fatalf("expected account b to be listed as missing, got %v", out.ObservedMissingAccounts)
+	}
 }
 
 func TestFetcherMarksObservedWarmingWhenUnavailableEstimateIsWarming(t *testing.T) {
@@ -292,9 +368,10 @@ func TestFetcherMarksObservedWarmingWhenUnavailableEstimateIsWarming(t *testing.
 		observed: fakeEstimator{
 			values: map[string]ObservedTokenEstimate{
 				"/a": {
-					Status:  observedTokensStatusUnavailable,
-					Warming: true,
-					Note:    "warming token estimate",
+					Status:       observedTokensStatusUnavailable,
+					Warming:      true,
+					WarmingSince: time.Date(2026, 2, 26, 20, 0, 0, 0, time.UTC),
+					Note:         "warming token estimate",
 				},
 			},
 		},
@@ -313,6 +390,82 @@ func TestFetcherMarksObservedWarmingWhenUnavailableEstimateIsWarming(t *testing.
 	if len(out.Accounts) != 1 || !out.Accounts[0].ObservedTokensWarming {
 		t.Fatalf("expected per-account warming flag to be set")
 	}
+	if since := out.ObservedTokensWarmingSince; since == nil || !since.Equal(time.Date(2026, 2, 26, 20, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected warmup start to propagate to the summary, got %v", since)
+	}
+}
+
+func TestFetcherObservedMergeModesForDuplicateIdentities(t *testing.T) {
+	older := time.Date(2026, 2, 26, 10, 0, 0, 0, time.UTC)
+	newer := older.Add(time.Hour)
+	newFetcher := func(mode ObservedMergeMode) *Fetcher {
+		accounts := make([]accountFetcher, 0, 2)
+		for _, label := range []string{"a", "b"} {
+			accounts = append(accounts, accountFetcher{
+				account:  MonitorAccount{Label: label, CodexHome: "/" + label},
+				primary:  &fakeSource{name: "primary-" + label, out: &Summary{AccountEmail: "same@example.com"}},
+				fallback: &fakeSource{name: "fallback-" + label},
+			})
+		}
+		return &Fetcher{
+			accounts:      accounts,
+			observedMerge: mode,
+			observed: fakeEstimator{
+				values: map[string]ObservedTokenEstimate{
+					"/a": {
+						Window5h:     ObservedTokenBreakdown{Total: 100},
+						WindowWeekly: ObservedTokenBreakdown{Total: 200, LastEventAt: &older},
+						Status:       observedTokensStatusEstimated,
+					},
+					"/b": {
+						Window5h:     ObservedTokenBreakdown{Total: 50},
+						WindowWeekly: ObservedTokenBreakdown{Total: 60, LastEventAt: &newer},
+						Status:       observedTokensStatusEstimated,
+					},
+				},
+			},
+		}
+	}
+
+	cases := []struct {
+		mode         ObservedMergeMode
+		want5h       int64
+		wantWeekly   int64
+		wantLastSeen time.Time
+	}{
+		{mode: ObservedMergeMax, want5h: 100, wantWeekly: 200, wantLastSeen: older},
+		{mode: ObservedMergeSum, want5h: 150, wantWeekly: 260, wantLastSeen: newer},
+		{mode: ObservedMergeLatest, want5h: 50, wantWeekly: 60, wantLastSeen: newer},
+	}
+	for _, tc := range cases {
+		out, err := newFetcher(tc.mode).Fetch(context.Background())
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.mode, err)
+		}
+		if *out.ObservedTokens5h != tc.want5h || *out.ObservedTokensWeekly != tc.wantWeekly {
+			t.Fatalf("%s: expected %d/%d, got %d/%d", tc.mode, tc.want5h, tc.wantWeekly, *out.ObservedTokens5h, *out.ObservedTokensWeekly)
+		}
+		if out.ObservedWindowWeekly.LastEventAt == nil || !out.ObservedWindowWeekly.LastEventAt.Equal(tc.wantLastSeen) {
+			t.Fatalf("%s: expected last event %v, got %v", tc.mode, tc.wantLastSeen, out.ObservedWindowWeekly.LastEventAt)
+		}
+	}
+}
+
+func TestParseObservedMergeModeFallsBackToEnv(t *testing.T) {
+	t.Setenv(observedMergeEnvVar, "")
+	if mode, err := ParseObservedMergeMode(""); err != nil || mode != ObservedMergeMax {
+		t.Fatalf("expected default max, got %q (%v)", mode, err)
+	}
+	t.Setenv(observedMergeEnvVar, "sum")
+	if mode, err := ParseObservedMergeMode(""); err != nil || mode != ObservedMergeSum {
+		t.Fatalf("expected env sum, got %q (%v)", mode, err)
+	}
+	if mode, err := ParseObservedMergeMode("Latest"); err != nil || mode != ObservedMergeLatest {
+		t.Fatalf("expected flag to win over env, got %q (%v)", mode, err)
+	}
+	if _, err := ParseObservedMergeMode("avg"); err == nil {
+		t.Fatalf("expected unknown mode to fail")
+	}
 }
 
 func TestFetcherDeduplicatesObservedTotalsByIdentity(t *testing.T) {
@@ -371,6 +524,107 @@ func TestFetcherDeduplicatesObservedTotalsByIdentity(t *testing.T) {
 	}
 }
 
+func TestFetcherSaturatesObservedTotalsNearInt64Limit(t *testing.T) {
+	near := int64(math.MaxInt64 - 10)
+	f := &Fetcher{
+		accounts: []accountFetcher{
+			{
+				account: MonitorAccount{Label: "a", CodexHome: "/a"},
+				primary: &fakeSource{name: "primary-a", out: &Summary{Source: "app-server", AccountEmail: "a@example.com"}},
+			},
+			{
+				account: MonitorAccount{Label: "b", CodexHome: "/b"},
+				primary: &fakeSource{name: "primary-b", out: &Summary{Source: "app-server", AccountEmail: "b@example.com"}},
+			},
+		},
+		observed: fakeEstimator{
+			values: map[string]ObservedTokenEstimate{
+				"/a": {
+					Window5h:     ObservedTokenBreakdown{Total: near, Input: near},
+					WindowWeekly: ObservedTokenBreakdown{Total: near},
+					Status:       observedTokensStatusEstimated,
+				},
+				"/b": {
+					Window5h:     ObservedTokenBreakdown{Total: near, Input: near},
+					WindowWeekly: ObservedTokenBreakdown{Total: 5},
+					Status:       observedTokensStatusEstimated,
+				},
+			},
+		},
+	}
+
+	out, err := f.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out.ObservedTokens5h == nil || *out.ObservedTokens5h != math.MaxInt64 {
+		t.Fatalf("expected saturated 5h total, got %+v", out.ObservedTokens5h)
+	}
+	if out.ObservedWindow5h.Input != math.MaxInt64 {
+		t.Fatalf("expected saturated 5h input, got %d", out.ObservedWindow5h.Input)
+	}
+	if out.ObservedTokensWeekly == nil || *out.ObservedTokensWeekly != near+5 {
+		t.Fatalf("expected exact weekly total below the limit, got %+v", out.ObservedTokensWeekly)
+	}
+	found := false
+	for _, warning := range out.Warnings {
+		if strings.Contains(warning, "int64 limit") {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("expected saturation warning, got %v", out.Warnings)
+	}
+}
+
+func TestSortAccountSummariesByUsageAndTokens(t *testing.T) {
+	now := time.Now().UTC()
+	tokens := func(v int64) *int64 { return &v }
+	base := []AccountSummary{
+		{Label: "a", PrimaryWindow: WindowSummary{UsedPercent: 10}, SecondaryWindow: WindowSummary{UsedPercent: 20}, ObservedTokens5h: tokens(500), FetchedAt: &now},
+		{Label: "b", Error: "boom", ObservedTokens5h: tokens(900)},
+		{Label: "c", PrimaryWindow: WindowSummary{UsedPercent: 95}, SecondaryWindow: WindowSummary{UsedPercent: 40}, FetchedAt: &now},
+		{Label: "d", PrimaryWindow: WindowSummary{UsedPercent: 5}, SecondaryWindow: WindowSummary{UsedPercent: 60}, ObservedTokens5h: tokens(500), ObservedTokensWeekly: tokens(800), FetchedAt: &now},
+	}
+	labels := func(accounts []AccountSummary) string {
+		out := make([]string, 0, len(accounts))
+		for _, account := range accounts {
+			out = append(out, account.Label)
+		}
+		return strings.Join(out, ",")
+	}
+
+	byUsage := append([]AccountSummary(nil), base...)
+	sortAccountSummaries(byUsage, AccountSortUsage)
+	if got := labels(byUsage); got != "c,d,a,b" {
+		t.Fatalf("unexpected usage order %q", got)
+	}
+
+	byTokens := append([]AccountSummary(nil), base...)
+	sortAccountSummaries(byTokens, AccountSortTokens)
+	if got := labels(byTokens); got != "b,d,a,c" {
+		t.Fatalf("unexpected tokens order %q", got)
+	}
+
+	byLabel := append([]AccountSummary(nil), base...)
+	sortAccountSummaries(byLabel, AccountSortLabel)
+	if got := labels(byLabel); got != "a,b,c,d" {
+		t.Fatalf("expected label order to be unchanged, got %q", got)
+	}
+}
+
+func TestParseAccountSortMode(t *testing.T) {
+	if mode, err := ParseAccountSortMode(""); err != nil || mode != AccountSortLabel {
+		t.Fatalf("expected default label sort, got %q (%v)", mode, err)
+	}
+	if mode, err := ParseAccountSortMode(" Usage "); err != nil || mode != AccountSortUsage {
+		t.Fatalf("expected usage sort, got %q (%v)", mode, err)
+	}
+	if _, err := ParseAccountSortMode("hotness"); err == nil {
+		t.Fatalf("expected error for unknown sort mode")
+	}
+}
+
 func TestReplaceAccountFetchersClosesRemovedHomes(t *testing.T) {
 	oldPrimary := &fakeSource{name: "old-primary"}
 	oldFallback := &fakeSource{name: "old-fallback"}
@@ -399,6 +653,49 @@ func TestReplaceAccountFetchersClosesRemovedHomes(t *testing.T) {
 	}
 }
 
+func TestFetcherAccountsListsConfiguredAccounts(t *testing.T) {
+	f := &Fetcher{
+		accounts: []accountFetcher{
+			{account: MonitorAccount{Label: "a", CodexHome: "/a"}},
+			{account: MonitorAccount{Label: "b", CodexHome: "/b"}},
+		},
+	}
+
+	accounts := f.Accounts()
+	if len(accounts) != 2 {
+		t.Fatalf("expected 2 accounts, got %d", len(accounts))
+	}
+	if accounts[0].Label != "a" || accounts[1].CodexHome != "/b" {
+		t.Fatalf("unexpected accounts: %+v", accounts)
+	}
+	if (&Fetcher{}).Accounts() != nil {
+		t.Fatalf("expected nil accounts for fetcher without configured accounts")
+	}
+}
+
+func TestFetcherStatsCountsSessionsAndCacheEntries(t *testing.T) {
+	estimator := newObservedTokenEstimator(time.Minute, false, defaultObservedWindows)
+	estimator.cache["/a"] = cachedObservedEstimate{at: time.Now()}
+	estimator.cache["/b"] = cachedObservedEstimate{at: time.Now()}
+	f := &Fetcher{
+		observed: estimator,
+		accounts: []accountFetcher{
+			{account: MonitorAccount{Label: "a", CodexHome: "/a"}, primary: NewAppServerSourceForHome("/a")},
+		},
+	}
+
+	stats := f.Stats()
+	if stats.AppServerSessions != 0 {
+		t.Fatalf("expected no live app-server sessions before any fetch, got %d", stats.AppServerSessions)
+	}
+	if stats.ObservedCacheEntries != 2 {
+		t.Fatalf("expected 2 observed cache entries, got %d", stats.ObservedCacheEntries)
+	}
+	if got := (&Fetcher{observed: fakeEstimator{}}).Stats(); got != (ResourceStats{}) {
+		t.Fatalf("expected zero stats for fake estimator, got %+v", got)
+	}
+}
+
 func TestRefreshAccountsReloadsAndReusesExistingHomes(t *testing.T) {
 	callCount := 0
 	f := &Fetcher{
@@ -590,6 +887,89 @@ func TestFetcherUsesActiveHomeIdentityForCurrentAccount(t *testing.T) {
 	}
 }
 
+func TestFetcherMatchesActiveHomeCaseInsensitively(t *testing.T) {
+	orig := caseInsensitiveHomePaths
+	caseInsensitiveHomePaths = true
+	t.Cleanup(func() { caseInsensitiveHomePaths = orig })
+
+	tmp := t.TempDir()
+	t.Setenv("HOME", tmp)
+	t.Setenv("CODEX_HOME", "/Users/Me/.codex-b")
+
+	f := &Fetcher{
+		accounts: []accountFetcher{
+			{
+				account: MonitorAccount{Label: "a", CodexHome: "/users/me/.codex-a"},
+				primary: &fakeSource{name: "primary-a", out: &Summary{AccountEmail: "a@example.com", PrimaryWindow: WindowSummary{UsedPercent: 10}}},
+			},
+			{
+				account: MonitorAccount{Label: "b", CodexHome: "/users/me/.codex-b"},
+				primary: &fakeSource{name: "primary-b", out: &Summary{AccountEmail: "b@example.com", PrimaryWindow: WindowSummary{UsedPercent: 15}}},
+			},
+		},
+		observed: fakeEstimator{},
+	}
+
+	out, err := f.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out.WindowAccountLabel != "b" || !out.WindowDataAvailable {
+		t.Fatalf("expected case-only path difference to match active home, got label %q available=%v", out.WindowAccountLabel, out.WindowDataAvailable)
+	}
+}
+
+func TestShouldPreferAccountSummaryBreaksTiesByHome(t *testing.T) {
+	at := time.Date(2026, 2, 26, 12, 0, 0, 0, time.UTC)
+	rowA := AccountSummary{Label: "a", AccountEmail: "same@example.com", FetchedAt: &at}
+	rowB := AccountSummary{Label: "b", AccountEmail: "same@example.com", FetchedAt: &at}
+
+	// Both insertion orders must elect /alpha.
+	if !shouldPreferAccountSummary(accountSummaryWithHome{account: rowB, codexHome: "/beta"}, rowA, "/alpha", "") {
+		t.Fatalf("expected /alpha to replace /beta on a full tie")
+	}
+	if shouldPreferAccountSummary(accountSummaryWithHome{account: rowA, codexHome: "/alpha"}, rowB, "/beta", "") {
+		t.Fatalf("expected /alpha to be kept over /beta on a full tie")
+	}
+
+	rowA.FetchedAt, rowB.FetchedAt = nil, nil
+	if !shouldPreferAccountSummary(accountSummaryWithHome{account: rowB, codexHome: "/beta"}, rowA, "/alpha", "") {
+		t.Fatalf("expected home tie-break when neither row has a fetch time")
+	}
+
+	later := at.Add(time.Second)
+	rowB.FetchedAt = &later
+	if !shouldPreferAccountSummary(accountSummaryWithHome{account: rowA, codexHome: "/alpha"}, rowB, "/beta", "") {
+		t.Fatalf("expected newer fetch to win before the home tie-break")
+	}
+}
+
+func TestSameCodexHomeFollowsSymlinkChains(t *testing.T) {
+	tmp := t.TempDir()
+	target := filepath.Join(tmp, "real")
+	if err := os.Mkdir(target, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	hop := filepath.Join(tmp, "hop")
+	link := filepath.Join(tmp, "link")
+	if err := os.Symlink(target, hop); err != nil {
+		t.Fatalf("symlink: %v", err)
+	}
+	if err := os.Symlink(hop, link); err != nil {
+		t.Fatalf("symlink: %v", err)
+	}
+
+	if !sameCodexHome(link, target) {
+		t.Fatalf("expected symlink chain to match its target")
+	}
+	if sameCodexHome(link, tmp) {
+		t.Fatalf("did not expect different directories to match")
+	}
+	if sameCodexHome("", target) {
+		t.Fatalf("did not expect empty home to match")
+	}
+}
+
 func TestFetcherMarksWindowUnavailableWhenActiveFetchFails(t *testing.T) {
 	tmp := t.TempDir()
 	t.Setenv("HOME", tmp)
@@ -725,6 +1105,37 @@ func TestFetcherMarksWindowUnavailableWhenActiveHomeMissing(t *testing.T) {
 	}
 }
 
+func TestActiveHomeMissingWarningSuggestsFixes(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("HOME", tmp)
+	t.Setenv(accountsFileEnvVar, filepath.Join(tmp, "accounts.json"))
+	active := filepath.Join(tmp, ".codex-work")
+
+	warning := activeHomeMissingWarning(active, []string{
+		filepath.Join(tmp, "other", "place"),
+		filepath.Join(tmp, ".codex-wrk"),
+		filepath.Join(tmp, ".codex-home"),
+		filepath.Join(tmp, ".codex-wrk"),
+	})
+	for _, want := range []string{
+		"active account home " + active + " is not in discovered accounts",
+		"closest monitored homes: " + filepath.Join(tmp, ".codex-wrk") + ", " + filepath.Join(tmp, ".codex-home") + ",",
+		"has no auth.json, sessions, or archived_sessions",
+		"add it to " + filepath.Join(tmp, "accounts.json"),
+	} {
+		if !strings.Contains(warning, want) {
+			t.Fatalf("expected warning to contain %q, got %q", want, warning)
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Join(active, "sessions"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if warning := activeHomeMissingWarning(active, nil); strings.Contains(warning, "closest") || strings.Contains(warning, "discovery skipped") {
+		t.Fatalf("expected no closest-home or signals hint, got %q", warning)
+	}
+}
+
 func TestNormalizeHomeConvertsRelativeToAbsolute(t *testing.T) {
 	tmp := t.TempDir()
 	rel := filepath.Join(".", filepath.Base(tmp))
@@ -912,3 +1323,255 @@ func TestFetcherRandomizedSelectionAndCountInvariants(t *testing.T) {
 		}
 	}
 }
+
+func TestFetchAccountResultProbesIdentityWhenFetchFails(t *testing.T) {
+	home := t.TempDir()
+	claims := `{"email":"b@example.com","https://api.openai.com/auth":{"chatgpt_plan_type":"plus","chatgpt_account_id":"acct-b"}}`
+	idToken := "e30." + base64.RawURLEncoding.EncodeToString([]byte(claims)) + ".sig"
+	auth := fmt.Sprintf(`{"tokens":{"access_token":"token","id_token":%q}}`, idToken)
+	if err := os.WriteFile(filepath.Join(home, "auth.json"), []byte(auth), 0o600); err != nil {
+		t.Fatalf("write auth.json: %v", err)
+	}
+
+	f := &Fetcher{}
+	failing := accountFetcher{
+		account:  MonitorAccount{Label: "b", CodexHome: home},
+		primary:  &fakeSource{name: "primary-b", err: errors.New("boom")},
+		fallback: &fakeSource{name: "fallback-b", err: errors.New("fallback boom")},
+	}
+	result := f.fetchAccountResult(context.Background(), failing, time.Now())
+	if result.account.Error == "" {
+		t.Fatalf("expected fetch error to be kept")
+	}
+	if result.account.PlanType != "plus" || result.account.AccountEmail != "b@example.com" || result.account.AccountID != "acct-b" {
+		t.Fatalf("expected plan and identity from auth.json, got %+v", result.account)
+	}
+	if result.account.IdentitySource != identitySourceAuthFile {
+		t.Fatalf("expected identity source %q, got %q", identitySourceAuthFile, result.account.IdentitySource)
+	}
+
+	missing := failing
+	missing.account.CodexHome = t.TempDir()
+	result = f.fetchAccountResult(context.Background(), missing, time.Now())
+	if result.account.PlanType != "" || result.account.IdentitySource != "" {
+		t.Fatalf("expected no plan without auth.json, got %+v", result.account)
+	}
+}
+
+func TestFetchAccountResultRecordsFallbackPath(t *testing.T) {
+	f := &Fetcher{}
+	account := accountFetcher{
+		account:  MonitorAccount{Label: "a", CodexHome: t.TempDir()},
+		primary:  &fakeSource{name: "app-server", err: errors.New("stream closed")},
+		fallback: &fakeSource{name: "oauth", out: &Summary{Source: "oauth"}},
+	}
+	result := f.fetchAccountResult(context.Background(), account, time.Now())
+	if !result.account.FallbackUsed || result.account.PrimaryError != "stream closed" || result.account.Source != "oauth" {
+		t.Fatalf("expected the fallback path to be recorded, got %+v", result.account)
+	}
+
+	account.primary = &fakeSource{name: "app-server", out: &Summary{Source: "app-server"}}
+	result = f.fetchAccountResult(context.Background(), account, time.Now())
+	if result.account.FallbackUsed || result.account.PrimaryError != "" {
+		t.Fatalf("expected no fallback markers on a primary success, got %+v", result.account)
+	}
+}
+
+func TestFetcherTotalsAdditionalLimitsAcrossAccounts(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("HOME", tmp)
+	t.Setenv("CODEX_HOME", "/a")
+
+	summary := func(email string, extra int) *Summary {
+		return &Summary{AccountEmail: email, AdditionalLimitCount: extra, PrimaryWindow: WindowSummary{UsedPercent: 10}, SecondaryWindow: WindowSummary{UsedPercent: 20}}
+	}
+	f := &Fetcher{
+		accounts: []accountFetcher{
+			{account: MonitorAccount{Label: "a", CodexHome: "/a"}, primary: &fakeSource{name: "a", out: summary("a@example.com", 1)}, fallback: &fakeSource{name: "fa"}},
+			{account: MonitorAccount{Label: "b", CodexHome: "/b"}, primary: &fakeSource{name: "b", out: summary("b@example.com", 2)}, fallback: &fakeSource{name: "fb"}},
+			{account: MonitorAccount{Label: "b-copy", CodexHome: "/b-copy"}, primary: &fakeSource{name: "b2", out: summary("b@example.com", 2)}, fallback: &fakeSource{name: "fb2"}},
+			{account: MonitorAccount{Label: "c", CodexHome: "/c"}, primary: &fakeSource{name: "c", err: errors.New("boom")}, fallback: &fakeSource{name: "fc", err: errors.New("boom")}},
+		},
+	}
+
+	out, err := f.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out.AdditionalLimitCount != 1 {
+		t.Fatalf("expected active account count 1, got %d", out.AdditionalLimitCount)
+	}
+	if out.TotalAdditionalLimitCount != 3 {
+		t.Fatalf("expected total of 3 across distinct reachable identities, got %d", out.TotalAdditionalLimitCount)
+	}
+}
+
+func TestFetcherMergesAppServerAndOAuthIdentitiesForOneAccount(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("HOME", tmp)
+	t.Setenv("CODEX_HOME", "/a")
+
+	windows := func(s *Summary) *Summary {
+		s.PrimaryWindow = WindowSummary{UsedPercent: 10}
+		s.SecondaryWindow = WindowSummary{UsedPercent: 20}
+		return s
+	}
+	f := &Fetcher{
+		accounts: []accountFetcher{
+			{
+				account:  MonitorAccount{Label: "a", CodexHome: "/a"},
+				primary:  &fakeSource{name: "app-server", out: windows(&Summary{Source: "app-server", AccountEmail: "dev@example.com"})},
+				fallback: &fakeSource{name: "oauth"},
+			},
+			{
+				account:  MonitorAccount{Label: "a-oauth", CodexHome: "/a-oauth"},
+				primary:  &fakeSource{name: "app-server", err: errors.New("stream closed")},
+				fallback: &fakeSource{name: "oauth", out: windows(&Summary{Source: "oauth", AccountEmail: "Dev@Example.com", AccountID: "acct-1", UserID: "user-1"})},
+			},
+			{
+				account:  MonitorAccount{Label: "a-ids", CodexHome: "/a-ids"},
+				primary:  &fakeSource{name: "app-server", err: errors.New("stream closed")},
+				fallback: &fakeSource{name: "oauth", out: windows(&Summary{Source: "oauth", AccountID: "acct-1"})},
+			},
+		},
+	}
+
+	out, err := f.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out.TotalAccounts != 1 || len(out.Accounts) != 1 {
+		t.Fatalf("expected one account across both sources, got total=%d rows=%+v", out.TotalAccounts, out.Accounts)
+	}
+	row := out.Accounts[0]
+	if row.Label != "a" || row.Source != "app-server" {
+		t.Fatalf("expected the active app-server row to represent the account, got %+v", row)
+	}
+	if row.AccountEmail != "dev@example.com" || row.AccountID != "acct-1" || row.UserID != "user-1" {
+		t.Fatalf("expected the richest identity on the merged row, got %+v", row)
+	}
+}
+
+func TestFetcherKeepsDifferentEmailsInOneWorkspaceApart(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("HOME", tmp)
+	t.Setenv("CODEX_HOME", "/a")
+
+	windows := func(s *Summary, used int) *Summary {
+		s.PrimaryWindow = WindowSummary{UsedPercent: used}
+		s.SecondaryWindow = WindowSummary{UsedPercent: used}
+		return s
+	}
+	f := &Fetcher{
+		accounts: []accountFetcher{
+			{
+				account:  MonitorAccount{Label: "a", CodexHome: "/a"},
+				primary:  &fakeSource{name: "oauth", out: windows(&Summary{Source: "oauth", AccountEmail: "a@example.com", AccountID: "workspace-1", UserID: "user-a"}, 10)},
+				fallback: &fakeSource{name: "f"},
+			},
+			{
+				account:  MonitorAccount{Label: "b", CodexHome: "/b"},
+				primary:  &fakeSource{name: "oauth", out: windows(&Summary{Source: "oauth", AccountEmail: "b@example.com", AccountID: "workspace-1", UserID: "user-b"}, 70)},
+				fallback: &fakeSource{name: "f"},
+			},
+		},
+		observed: fakeEstimator{values: map[string]ObservedTokenEstimate{
+			"/a": {Window5h: ObservedTokenBreakdown{Total: 10}, WindowWeekly: ObservedTokenBreakdown{Total: 10}, Status: observedTokensStatusEstimated},
+			"/b": {Window5h: ObservedTokenBreakdown{Total: 30}, WindowWeekly: ObservedTokenBreakdown{Total: 30}, Status: observedTokensStatusEstimated},
+		}},
+	}
+
+	out, err := f.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out.TotalAccounts != 2 || len(out.Accounts) != 2 {
+		t.Fatalf("expected two users sharing a workspace to stay separate, got total=%d rows=%+v", out.TotalAccounts, out.Accounts)
+	}
+	if out.ObservedTokens5h == nil || *out.ObservedTokens5h != 40 {
+		t.Fatalf("expected both users' observed tokens in the sum, got %v", out.ObservedTokens5h)
+	}
+}
+
+func TestFetcherCountsFetchOutcomesPerAccount(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("HOME", tmp)
+	t.Setenv("CODEX_HOME", "/a")
+
+	ok := &Summary{AccountEmail: "a@example.com", PrimaryWindow: WindowSummary{UsedPercent: 10}, SecondaryWindow: WindowSummary{UsedPercent: 20}}
+	f := &Fetcher{
+		accounts: []accountFetcher{
+			{account: MonitorAccount{Label: "a", CodexHome: "/a"}, primary: &fakeSource{name: "p", out: ok}, fallback: &fakeSource{name: "f"}},
+			{account: MonitorAccount{Label: "b", CodexHome: "/b"}, primary: &fakeSource{name: "p", err: errors.New("down")}, fallback: &fakeSource{name: "f", out: &Summary{AccountEmail: "b@example.com"}}},
+			{account: MonitorAccount{Label: "c", CodexHome: "/c"}, primary: &fakeSource{name: "p", err: errors.New("down")}, fallback: &fakeSource{name: "f", err: errors.New("down")}},
+		},
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := f.Fetch(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	total, byAccount := f.Counters()
+	if total != (FetchCounters{Fetches: 6, PrimarySuccesses: 2, FallbackSuccesses: 2, Failures: 2}) {
+		t.Fatalf("unexpected totals: %+v", total)
+	}
+	if byAccount["b"] != (FetchCounters{Fetches: 2, FallbackSuccesses: 2}) || byAccount["c"].Failures != 2 {
+		t.Fatalf("unexpected per-account counters: %+v", byAccount)
+	}
+	if f.Stats().Fetches != total {
+		t.Fatalf("expected Stats to carry the totals")
+	}
+}
+
+func TestFetcherSelectedAccountDrivesWindowCards(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("HOME", tmp)
+	t.Setenv("CODEX_HOME", "/a")
+
+	newFetcher := func(selected string) *Fetcher {
+		return &Fetcher{
+			selectedAccount: selected,
+			accounts: []accountFetcher{
+				{
+					account: MonitorAccount{Label: "a", CodexHome: "/a"},
+					primary: &fakeSource{name: "app-server", out: &Summary{Source: "app-server", AccountEmail: "a@example.com", PrimaryWindow: WindowSummary{UsedPercent: 10}}},
+				},
+				{
+					account: MonitorAccount{Label: "b", CodexHome: "/b"},
+					primary: &fakeSource{name: "app-server", out: &Summary{Source: "app-server", AccountEmail: "b@example.com", PrimaryWindow: WindowSummary{UsedPercent: 80}}},
+				},
+				{
+					account: MonitorAccount{Label: "c", CodexHome: "/c"},
+					primary: &fakeSource{name: "app-server", err: errors.New("stream closed")},
+				},
+			},
+		}
+	}
+
+	for _, selected := range []string{"b", "B@Example.com"} {
+		out, err := newFetcher(selected).Fetch(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if out.WindowAccountLabel != "b" || out.PrimaryWindow.UsedPercent != 80 || out.AccountEmail != "b@example.com" {
+			t.Fatalf("expected %q to select account b, got label=%q window=%+v", selected, out.WindowAccountLabel, out.PrimaryWindow)
+		}
+	}
+
+	for selected, warning := range map[string]string{
+		"c":       `account "c" fetch failed; showing the active account's windows`,
+		"missing": `account "missing" not found; showing the active account's windows`,
+	} {
+		out, err := newFetcher(selected).Fetch(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if out.WindowAccountLabel != "a" || out.PrimaryWindow.UsedPercent != 10 {
+			t.Fatalf("expected %q to fall back to the active account, got label=%q", selected, out.WindowAccountLabel)
+		}
+		if !strings.Contains(strings.Join(out.Warnings, " | "), warning) {
+			t.Fatalf("expected warning %q, got %v", warning, out.Warnings)
+		}
+	}
+}
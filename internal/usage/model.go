@@ -4,28 +4,51 @@ import "time"
 
 // Summary is the normalized subscription usage snapshot used by CLI and TUI.
 type Summary struct {
-	Source                string                  `json:"source"`
-	PlanType              string                  `json:"plan_type"`
-	AccountEmail          string                  `json:"account_email,omitempty"`
-	AccountID             string                  `json:"account_id,omitempty"`
-	UserID                string                  `json:"user_id,omitempty"`
-	WindowDataAvailable   bool                    `json:"window_data_available"`
-	PrimaryWindow         WindowSummary           `json:"primary_window"`
-	SecondaryWindow       WindowSummary           `json:"secondary_window"`
-	WindowAccountLabel    string                  `json:"window_account_label,omitempty"`
-	AdditionalLimitCount  int                     `json:"additional_limit_count,omitempty"`
-	TotalAccounts         int                     `json:"total_accounts,omitempty"`
-	SuccessfulAccounts    int                     `json:"successful_accounts,omitempty"`
-	Accounts              []AccountSummary        `json:"accounts,omitempty"`
-	ObservedTokens5h      *int64                  `json:"observed_tokens_5h,omitempty"`
-	ObservedTokensWeekly  *int64                  `json:"observed_tokens_weekly,omitempty"`
-	ObservedWindow5h      *ObservedTokenBreakdown `json:"observed_window_5h,omitempty"`
-	ObservedWindowWeekly  *ObservedTokenBreakdown `json:"observed_window_weekly,omitempty"`
-	ObservedTokensStatus  string                  `json:"observed_tokens_status,omitempty"`
-	ObservedTokensWarming bool                    `json:"observed_tokens_warming,omitempty"`
-	ObservedTokensNote    string                  `json:"observed_tokens_note,omitempty"`
-	Warnings              []string                `json:"warnings,omitempty"`
-	FetchedAt             time.Time               `json:"fetched_at"`
+	Source                       string                  `json:"source"`
+	PlanType                     string                  `json:"plan_type"`
+	AccountEmail                 string                  `json:"account_email,omitempty"`
+	AccountID                    string                  `json:"account_id,omitempty"`
+	UserID                       string                  `json:"user_id,omitempty"`
+	WindowDataAvailable          bool                    `json:"window_data_available"`
+	PrimaryWindow                WindowSummary           `json:"primary_window"`
+	SecondaryWindow              WindowSummary           `json:"secondary_window"`
+	WindowAccountLabel           string                  `json:"window_account_label,omitempty"`
+	AdditionalLimitCount         int                     `json:"additional_limit_count,omitempty"`
+	AdditionalLimits             []AdditionalLimit       `json:"additional_limits,omitempty"`
+	TotalAdditionalLimitCount    int                     `json:"total_additional_limit_count,omitempty"`
+	Credits                      *CreditsSummary         `json:"credits,omitempty"`
+	TotalAccounts                int                     `json:"total_accounts,omitempty"`
+	SuccessfulAccounts           int                     `json:"successful_accounts,omitempty"`
+	Accounts                     []AccountSummary        `json:"accounts,omitempty"`
+	ObservedTokens5h             *int64                  `json:"observed_tokens_5h,omitempty"`
+	ObservedTokensWeekly         *int64                  `json:"observed_tokens_weekly,omitempty"`
+	ObservedWindow5h             *ObservedTokenBreakdown `json:"observed_window_5h,omitempty"`
+	ObservedWindowWeekly         *ObservedTokenBreakdown `json:"observed_window_weekly,omitempty"`
+	ObservedTokensStatus         string                  `json:"observed_tokens_status,omitempty"`
+	ObservedTokensWarming        bool                    `json:"observed_tokens_warming,omitempty"`
+	ObservedTokensWarmingSince   *time.Time              `json:"observed_tokens_warming_since,omitempty"`
+	ObservedTokensNote           string                  `json:"observed_tokens_note,omitempty"`
+	ObservedContributingAccounts []string                `json:"observed_contributing_accounts,omitempty"`
+	ObservedMissingAccounts      []string                `json:"observed_missing_accounts,omitempty"`
+	// ObservedPrimaryMins and ObservedSecondaryMins are the lookbacks behind
+	// the five-hour and weekly observed totals (300 and 10080 by default).
+	ObservedPrimaryMins   int `json:"observed_primary_mins,omitempty"`
+	ObservedSecondaryMins int `json:"observed_secondary_mins,omitempty"`
+	// BurnRatePerMin is observed five-hour tokens per minute of the lookback.
+	BurnRatePerMin int64 `json:"burn_rate_per_min,omitempty"`
+	// ProjectedExhaustionAt is when the primary window reaches 100% at its
+	// pace so far; it may fall after the reset.
+	ProjectedExhaustionAt *time.Time `json:"projected_exhaustion_at,omitempty"`
+	Warnings              []string   `json:"warnings,omitempty"`
+	FetchedAt             time.Time  `json:"fetched_at"`
+}
+
+// CreditsSummary is the pay-as-you-go credit state; Balance is passed through
+// as reported.
+type CreditsSummary struct {
+	HasCredits bool   `json:"has_credits"`
+	Unlimited  bool   `json:"unlimited,omitempty"`
+	Balance    string `json:"balance,omitempty"`
 }
 
 type WindowSummary struct {
@@ -33,32 +56,54 @@ type WindowSummary struct {
 	WindowDurationMins *int       `json:"window_duration_mins,omitempty"`
 	ResetsAt           *time.Time `json:"resets_at,omitempty"`
 	SecondsUntilReset  *int64     `json:"seconds_until_reset,omitempty"`
+	Limit              *int64     `json:"limit,omitempty"`
+	Used               *int64     `json:"used,omitempty"`
+}
+
+// AdditionalLimit is a named rate limit reported beside the main Codex limit.
+// Either window may be missing when the source omits it.
+type AdditionalLimit struct {
+	Name            string         `json:"name"`
+	LimitReached    bool           `json:"limit_reached,omitempty"`
+	PrimaryWindow   *WindowSummary `json:"primary_window,omitempty"`
+	SecondaryWindow *WindowSummary `json:"secondary_window,omitempty"`
 }
 
 type AccountSummary struct {
-	Label                 string                  `json:"label"`
-	Source                string                  `json:"source,omitempty"`
-	PlanType              string                  `json:"plan_type,omitempty"`
-	AccountEmail          string                  `json:"account_email,omitempty"`
-	AccountID             string                  `json:"account_id,omitempty"`
-	UserID                string                  `json:"user_id,omitempty"`
-	PrimaryWindow         WindowSummary           `json:"primary_window,omitempty"`
-	SecondaryWindow       WindowSummary           `json:"secondary_window,omitempty"`
-	AdditionalLimitCount  int                     `json:"additional_limit_count,omitempty"`
-	ObservedTokens5h      *int64                  `json:"observed_tokens_5h,omitempty"`
-	ObservedTokensWeekly  *int64                  `json:"observed_tokens_weekly,omitempty"`
-	ObservedWindow5h      *ObservedTokenBreakdown `json:"observed_window_5h,omitempty"`
-	ObservedWindowWeekly  *ObservedTokenBreakdown `json:"observed_window_weekly,omitempty"`
-	ObservedTokensStatus  string                  `json:"observed_tokens_status,omitempty"`
-	ObservedTokensWarming bool                    `json:"observed_tokens_warming,omitempty"`
-	ObservedTokensNote    string                  `json:"observed_tokens_note,omitempty"`
-	Warnings              []string                `json:"warnings,omitempty"`
-	Error                 string                  `json:"error,omitempty"`
-	FetchedAt             *time.Time              `json:"fetched_at,omitempty"`
+	Label                      string                  `json:"label"`
+	Source                     string                  `json:"source,omitempty"`
+	PlanType                   string                  `json:"plan_type,omitempty"`
+	AccountEmail               string                  `json:"account_email,omitempty"`
+	AccountID                  string                  `json:"account_id,omitempty"`
+	UserID                     string                  `json:"user_id,omitempty"`
+	PrimaryWindow              WindowSummary           `json:"primary_window,omitempty"`
+	SecondaryWindow            WindowSummary           `json:"secondary_window,omitempty"`
+	AdditionalLimitCount       int                     `json:"additional_limit_count,omitempty"`
+	AdditionalLimits           []AdditionalLimit       `json:"additional_limits,omitempty"`
+	ObservedTokens5h           *int64                  `json:"observed_tokens_5h,omitempty"`
+	ObservedTokensWeekly       *int64                  `json:"observed_tokens_weekly,omitempty"`
+	ObservedWindow5h           *ObservedTokenBreakdown `json:"observed_window_5h,omitempty"`
+	ObservedWindowWeekly       *ObservedTokenBreakdown `json:"observed_window_weekly,omitempty"`
+	ObservedTokensStatus       string                  `json:"observed_tokens_status,omitempty"`
+	ObservedTokensWarming      bool                    `json:"observed_tokens_warming,omitempty"`
+	ObservedTokensWarmingSince *time.Time              `json:"observed_tokens_warming_since,omitempty"`
+	ObservedTokensNote         string                  `json:"observed_tokens_note,omitempty"`
+	Warnings                   []string                `json:"warnings,omitempty"`
+	IdentitySource             string                  `json:"identity_source,omitempty"`
+	FallbackUsed               bool                    `json:"fallback_used,omitempty"`
+	PrimaryError               string                  `json:"primary_error,omitempty"`
+	Error                      string                  `json:"error,omitempty"`
+	FetchedAt                  *time.Time              `json:"fetched_at,omitempty"`
+	// LastSuccessAt is the newest successful FetchedAt for this home across
+	// polls, so a failing account still shows when it last worked.
+	LastSuccessAt *time.Time `json:"last_success_at,omitempty"`
 }
 
 type DoctorCheck struct {
 	Name    string `json:"name"`
 	OK      bool   `json:"ok"`
 	Details string `json:"details"`
+	// Failure classifies failed source checks: "authentication" or "connectivity".
+	Failure    string `json:"failure,omitempty"`
+	DurationMS int64  `json:"duration_ms"`
 }
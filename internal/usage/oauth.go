@@ -2,6 +2,7 @@ package usage
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -15,8 +16,14 @@ import (
 
 const (
 	chatGPTOAuthUsageEndpoint = "https://chatgpt.com/backend-api/wham/usage"
+
+	// minAccessTokenLength rejects obviously truncated tokens; real access
+	// tokens are JWTs several hundred characters long.
+	minAccessTokenLength = 20
 )
 
+var errAuthTokenInvalid = errors.New("auth token looks invalid")
+
 type OAuthSource struct {
 	httpClient *http.Client
 	codexHome  string
@@ -45,7 +52,7 @@ func (s *OAuthSource) Fetch(ctx context.Context) (*Summary, error) {
 	}
 	token, err := readAccessToken(authPath)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("%w: %w", ErrAuthRequired, err)
 	}
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, chatGPTOAuthUsageEndpoint, nil)
@@ -67,6 +74,9 @@ func (s *OAuthSource) Fetch(ctx context.Context) (*Summary, error) {
 		return nil, fmt.Errorf("read oauth response: %w", err)
 	}
 
+	if res.StatusCode == http.StatusUnauthorized || res.StatusCode == http.StatusForbidden {
+		return nil, fmt.Errorf("oauth endpoint returned HTTP %d: %s: %w", res.StatusCode, summarizeBody(body), ErrAuthRequired)
+	}
 	if res.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("oauth endpoint returned HTTP %d: %s", res.StatusCode, summarizeBody(body))
 	}
@@ -86,21 +96,13 @@ func (s *OAuthSource) Fetch(ctx context.Context) (*Summary, error) {
 	}
 
 	snapshot := rateLimitSnapshotRaw{
-		LimitID:  "codex",
-		PlanType: payload.PlanType,
-		Primary: &rateLimitWindowRaw{
-			UsedPercent:        payload.RateLimit.PrimaryWindow.UsedPercent,
-			WindowDurationMins: toMins(payload.RateLimit.PrimaryWindow.LimitWindowSeconds),
-			ResetsAt:           toInt64Ptr(payload.RateLimit.PrimaryWindow.ResetAt),
-		},
-		Secondary: &rateLimitWindowRaw{
-			UsedPercent:        payload.RateLimit.SecondaryWindow.UsedPercent,
-			WindowDurationMins: toMins(payload.RateLimit.SecondaryWindow.LimitWindowSeconds),
-			ResetsAt:           toInt64Ptr(payload.RateLimit.SecondaryWindow.ResetAt),
-		},
+		LimitID:   "codex",
+		PlanType:  payload.PlanType,
+		Primary:   payload.RateLimit.PrimaryWindow.toRaw(),
+		Secondary: payload.RateLimit.SecondaryWindow.toRaw(),
 	}
 
-	return normalizeSummary(
+	summary, err := normalizeSummary(
 		s.Name(),
 		snapshot,
 		len(payload.AdditionalRateLimits),
@@ -111,6 +113,49 @@ func (s *OAuthSource) Fetch(ctx context.Context) (*Summary, error) {
 		},
 		nil,
 	)
+	if err != nil {
+		return nil, err
+	}
+	summary.AdditionalLimits = oauthAdditionalLimits(payload.AdditionalRateLimits)
+	return summary, nil
+}
+
+// oauthAdditionalLimits keeps every entry, even one without windows, so the
+// list always matches AdditionalLimitCount. Unnamed limits get their position.
+func oauthAdditionalLimits(limits []oauthAdditionalRateLimit) []AdditionalLimit {
+	if len(limits) == 0 {
+		return nil
+	}
+	out := make([]AdditionalLimit, 0, len(limits))
+	for i, limit := range limits {
+		entry := AdditionalLimit{Name: strings.TrimSpace(limit.LimitName)}
+		if entry.Name == "" {
+			entry.Name = fmt.Sprintf("limit %d", i+1)
+		}
+		if details := limit.RateLimit; details != nil {
+			entry.LimitReached = details.LimitReached
+			if details.PrimaryWindow != nil {
+				win := toWindowSummary(details.PrimaryWindow.toRaw())
+				entry.PrimaryWindow = &win
+			}
+			if details.SecondaryWindow != nil {
+				win := toWindowSummary(details.SecondaryWindow.toRaw())
+				entry.SecondaryWindow = &win
+			}
+		}
+		out = append(out, entry)
+	}
+	return out
+}
+
+func (w *oauthWindowSnapshot) toRaw() *rateLimitWindowRaw {
+	return &rateLimitWindowRaw{
+		UsedPercent:        w.UsedPercent,
+		WindowDurationMins: toMins(w.LimitWindowSeconds),
+		ResetsAt:           toInt64Ptr(w.ResetAt),
+		Limit:              w.Limit,
+		Used:               w.Used,
+	}
 }
 
 func (s *OAuthSource) Close() error {
@@ -139,19 +184,98 @@ type oauthRateLimitDetails struct {
 }
 
 type oauthWindowSnapshot struct {
-	UsedPercent        int `json:"used_percent"`
-	LimitWindowSeconds int `json:"limit_window_seconds"`
-	ResetAfterSeconds  int `json:"reset_after_seconds"`
-	ResetAt            int `json:"reset_at"`
+	UsedPercent        int    `json:"used_percent"`
+	LimitWindowSeconds int    `json:"limit_window_seconds"`
+	ResetAfterSeconds  int    `json:"reset_after_seconds"`
+	ResetAt            int    `json:"reset_at"`
+	Limit              *int64 `json:"limit"`
+	Used               *int64 `json:"used"`
 }
 
 type authFilePayload struct {
-	AuthMode string `json:"auth_mode"`
-	Tokens   struct {
+	AuthMode    string `json:"auth_mode"`
+	AccessToken string `json:"access_token"`
+	IDToken     string `json:"id_token"`
+	Tokens      struct {
 		AccessToken string `json:"access_token"`
+		IDToken     string `json:"id_token"`
 	} `json:"tokens"`
 }
 
+type authTokenField struct {
+	path  string
+	value string
+}
+
+// bearerCandidates lists the known token locations in preference order.
+// Codex versions have moved the token between the nested and top-level shapes;
+// id_token is only a last resort for ChatGPT logins.
+func (p authFilePayload) bearerCandidates() []authTokenField {
+	candidates := []authTokenField{
+		{path: "tokens.access_token", value: p.Tokens.AccessToken},
+		{path: "access_token", value: p.AccessToken},
+	}
+	if mode := strings.ToLower(strings.TrimSpace(p.AuthMode)); mode == "" || mode == "chatgpt" {
+		candidates = append(candidates,
+			authTokenField{path: "tokens.id_token", value: p.Tokens.IDToken},
+			authTokenField{path: "id_token", value: p.IDToken},
+		)
+	}
+	return candidates
+}
+
+func (p authFilePayload) idToken() string {
+	if token := strings.TrimSpace(p.Tokens.IDToken); token != "" {
+		return token
+	}
+	return strings.TrimSpace(p.IDToken)
+}
+
+type idTokenClaims struct {
+	Email string `json:"email"`
+	Auth  struct {
+		PlanType  string `json:"chatgpt_plan_type"`
+		AccountID string `json:"chatgpt_account_id"`
+		UserID    string `json:"chatgpt_user_id"`
+	} `json:"https://api.openai.com/auth"`
+}
+
+// readAuthIdentity is the identity-only probe used when a fetch fails: it
+// reads plan and identity from the id_token claims in auth.json without any
+// network call. The token signature is not verified; the result is for display.
+func readAuthIdentity(codexHome string) (identityInfo, string, error) {
+	path, err := findAuthJSONPathForHome(codexHome)
+	if err != nil {
+		return identityInfo{}, "", err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return identityInfo{}, "", fmt.Errorf("read auth file: %w", err)
+	}
+	var payload authFilePayload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return identityInfo{}, "", fmt.Errorf("decode auth file: %w", err)
+	}
+	parts := strings.Split(payload.idToken(), ".")
+	if len(parts) != 3 {
+		return identityInfo{}, "", errors.New("auth.json missing tokens.id_token")
+	}
+	rawClaims, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return identityInfo{}, "", fmt.Errorf("decode id_token claims: %w", err)
+	}
+	var claims idTokenClaims
+	if err := json.Unmarshal(rawClaims, &claims); err != nil {
+		return identityInfo{}, "", fmt.Errorf("decode id_token claims: %w", err)
+	}
+	identity := identityInfo{
+		Email:     strings.TrimSpace(claims.Email),
+		AccountID: strings.TrimSpace(claims.Auth.AccountID),
+		UserID:    strings.TrimSpace(claims.Auth.UserID),
+	}
+	return identity, strings.TrimSpace(claims.Auth.PlanType), nil
+}
+
 func findAuthJSONPath() (string, error) {
 	home, err := defaultCodexHome()
 	if err != nil {
@@ -181,11 +305,37 @@ func readAccessToken(path string) (string, error) {
 	if err := json.Unmarshal(data, &payload); err != nil {
 		return "", fmt.Errorf("decode auth file: %w", err)
 	}
-	token := strings.TrimSpace(payload.Tokens.AccessToken)
-	if token == "" {
-		return "", errors.New("auth.json missing tokens.access_token")
+	var blank string
+	for _, field := range payload.bearerCandidates() {
+		token := strings.TrimSpace(field.value)
+		if token == "" {
+			if field.value != "" && blank == "" {
+				blank = field.path
+			}
+			continue
+		}
+		if err := validateAccessToken(field.path, token); err != nil {
+			return "", err
+		}
+		return token, nil
+	}
+	if blank != "" {
+		return "", fmt.Errorf("%w: %s is blank", errAuthTokenInvalid, blank)
 	}
-	return token, nil
+	if mode := strings.TrimSpace(payload.AuthMode); mode != "" && !strings.EqualFold(mode, "chatgpt") {
+		return "", fmt.Errorf("auth.json missing tokens.access_token (auth_mode %q has no ChatGPT login token)", mode)
+	}
+	return "", errors.New("auth.json missing tokens.access_token")
+}
+
+func validateAccessToken(path, token string) error {
+	if len(token) < minAccessTokenLength {
+		return fmt.Errorf("%w: %s is only %d characters", errAuthTokenInvalid, path, len(token))
+	}
+	if strings.ContainsAny(token, " \t\r\n") {
+		return fmt.Errorf("%w: %s contains whitespace", errAuthTokenInvalid, path)
+	}
+	return nil
 }
 
 func fileExists(path string) bool {
@@ -0,0 +1,129 @@
+package usage
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeAuthJSON(t *testing.T, dir, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, "auth.json")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write auth.json: %v", err)
+	}
+	return path
+}
+
+func TestReadAccessTokenValidatesShape(t *testing.T) {
+	cases := []struct {
+		name    string
+		content string
+		invalid bool
+		wantErr bool
+	}{
+		{name: "valid", content: `{"tokens":{"access_token":"  eyJhbGciOiJSUzI1NiJ9.payload.signature  "}}`},
+		{name: "whitespace only", content: `{"tokens":{"access_token":"   \t "}}`, invalid: true, wantErr: true},
+		{name: "short", content: `{"tokens":{"access_token":"abc123"}}`, invalid: true, wantErr: true},
+		{name: "embedded whitespace", content: `{"tokens":{"access_token":"eyJhbGciOiJSUzI1NiJ9 truncated.payload"}}`, invalid: true, wantErr: true},
+		{name: "missing", content: `{"tokens":{}}`, wantErr: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := writeAuthJSON(t, t.TempDir(), tc.content)
+			token, err := readAccessToken(path)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("readAccessToken error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if errors.Is(err, errAuthTokenInvalid) != tc.invalid {
+				t.Fatalf("expected invalid-token error %v, got %v", tc.invalid, err)
+			}
+			if err == nil && token != strings.TrimSpace(token) {
+				t.Fatalf("expected trimmed token, got %q", token)
+			}
+		})
+	}
+}
+
+func TestCheckAuthJSONSuggestsReloginForInvalidToken(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("CODEX_HOME", home)
+	writeAuthJSON(t, home, `{"tokens":{"access_token":"short"}}`)
+
+	check := checkAuthJSON()
+	if check.OK {
+		t.Fatalf("expected auth file check to fail, got %+v", check)
+	}
+	if !strings.Contains(check.Details, "auth token looks invalid") || !strings.Contains(check.Details, "codex login") {
+		t.Fatalf("expected invalid-token details with login remediation, got %q", check.Details)
+	}
+}
+
+func TestReadAccessTokenTriesKnownFieldPaths(t *testing.T) {
+	const token = "eyJhbGciOiJSUzI1NiJ9.payload.signature"
+	cases := []struct {
+		name    string
+		content string
+		want    string
+		wantErr string
+	}{
+		{name: "nested access token wins", content: `{"access_token":"top-level-token-value-xyz","tokens":{"access_token":"` + token + `"}}`, want: token},
+		{name: "top-level access token", content: `{"access_token":"` + token + `"}`, want: token},
+		{name: "blank nested falls through", content: `{"access_token":"` + token + `","tokens":{"access_token":"  "}}`, want: token},
+		{name: "chatgpt id token last resort", content: `{"auth_mode":"chatgpt","tokens":{"id_token":"` + token + `"}}`, want: token},
+		{name: "top-level id token", content: `{"id_token":"` + token + `"}`, want: token},
+		{name: "api key mode skips id token", content: `{"auth_mode":"apikey","tokens":{"id_token":"` + token + `"}}`, wantErr: `auth_mode "apikey"`},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := writeAuthJSON(t, t.TempDir(), tc.content)
+			got, err := readAccessToken(path)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tc.want {
+				t.Fatalf("expected %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestOAuthAdditionalLimitsKeepNamedWindows(t *testing.T) {
+	var payload oauthUsagePayload
+	raw := `{"additional_rate_limits": [
+		{"limit_name": "gpt-5-codex-high", "rate_limit": {"limit_reached": true,
+			"primary_window": {"used_percent": 100, "limit_window_seconds": 18000, "reset_at": 1772150400},
+			"secondary_window": {"used_percent": 35, "limit_window_seconds": 604800}}},
+		{"limit_name": " ", "rate_limit": null}
+	]}`
+	if err := json.Unmarshal([]byte(raw), &payload); err != nil {
+		t.Fatalf("decode payload: %v", err)
+	}
+
+	limits := oauthAdditionalLimits(payload.AdditionalRateLimits)
+	if len(limits) != 2 {
+		t.Fatalf("expected one entry per reported limit, got %+v", limits)
+	}
+	named := limits[0]
+	if named.Name != "gpt-5-codex-high" || !named.LimitReached {
+		t.Fatalf("unexpected named limit: %+v", named)
+	}
+	if named.PrimaryWindow == nil || named.PrimaryWindow.UsedPercent != 100 || *named.PrimaryWindow.WindowDurationMins != 300 {
+		t.Fatalf("unexpected primary window: %+v", named.PrimaryWindow)
+	}
+	if named.SecondaryWindow == nil || named.SecondaryWindow.UsedPercent != 35 {
+		t.Fatalf("unexpected secondary window: %+v", named.SecondaryWindow)
+	}
+	if limits[1].Name != "limit 2" || limits[1].PrimaryWindow != nil {
+		t.Fatalf("expected an unnamed placeholder without windows, got %+v", limits[1])
+	}
+}
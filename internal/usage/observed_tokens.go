@@ -2,12 +2,17 @@ package usage
 
 import (
 	"bufio"
+	"compress/gzip"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"math"
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -24,6 +29,8 @@ type ObservedTokenEstimate struct {
 	WindowWeekly ObservedTokenBreakdown
 	Status       string
 	Warming      bool
+	// WarmingSince is when the first background scan for the home was queued.
+	WarmingSince time.Time
 	Note         string
 	Warnings     []string
 }
@@ -33,7 +40,139 @@ type observedTokenEstimator struct {
 	cache    map[string]cachedObservedEstimate
 	ttl      time.Duration
 	async    bool
-	inflight map[string]struct{}
+	inflight map[string]time.Time
+
+	// Async refreshes run on at most maxWorkers goroutines that drain pending
+	// and exit when it is empty. Close cancels ctx and waits for them.
+	ctx        context.Context
+	cancel     context.CancelFunc
+	pending    []string
+	workers    int
+	maxWorkers int
+	closed     bool
+	wg         sync.WaitGroup
+	// generation bumps on ClearCache so refreshes started earlier are dropped.
+	generation int
+	scope      SessionsScope
+	windows    observedWindows
+	// warmHome is scanned synchronously on its first uncached Estimate so
+	// the first frame has totals; later refreshes go through the workers.
+	warmHome string
+}
+
+// SessionsScope picks which session directories feed observed totals.
+type SessionsScope string
+
+const (
+	SessionsScopeAll      SessionsScope = "all"
+	SessionsScopeLive     SessionsScope = "live"
+	SessionsScopeArchived SessionsScope = "archived"
+)
+
+func ParseSessionsScope(value string) (SessionsScope, error) {
+	switch scope := SessionsScope(strings.ToLower(strings.TrimSpace(value))); scope {
+	case "":
+		return SessionsScopeAll, nil
+	case SessionsScopeAll, SessionsScopeLive, SessionsScopeArchived:
+		return scope, nil
+	default:
+		return "", fmt.Errorf("unsupported sessions scope %q (expected all, live, or archived)", value)
+	}
+}
+
+func (s SessionsScope) noteSuffix() string {
+	switch s {
+	case SessionsScopeLive:
+		return " (live sessions only)"
+	case SessionsScopeArchived:
+		return " (archived sessions only)"
+	default:
+		return ""
+	}
+}
+
+const maxAsyncObservedRefreshes = 2
+
+const (
+	maxArchivedFilesEnvVar  = "CODEX_USAGE_MONITOR_MAX_ARCHIVED_FILES"
+	defaultMaxArchivedFiles = 500
+	// sessionGlobEnvVar replaces the sessions/archived_sessions layout with a
+	// glob, relative to each codex home unless absolute.
+	sessionGlobEnvVar = "CODEX_USAGE_MONITOR_SESSION_GLOB"
+
+	primaryWindowEnvVar   = "CODEX_USAGE_MONITOR_PRIMARY_WINDOW"
+	secondaryWindowEnvVar = "CODEX_USAGE_MONITOR_SECONDARY_WINDOW"
+)
+
+// observedWindows are the lookbacks behind the two observed-token totals,
+// reported as the five-hour and weekly windows.
+type observedWindows struct {
+	Primary   time.Duration
+	Secondary time.Duration
+}
+
+var defaultObservedWindows = observedWindows{Primary: 5 * time.Hour, Secondary: 7 * 24 * time.Hour}
+
+// withDefaults fills unset durations from defaultObservedWindows.
+func (w observedWindows) withDefaults() observedWindows {
+	if w.Primary <= 0 {
+		w.Primary = defaultObservedWindows.Primary
+	}
+	if w.Secondary <= 0 {
+		w.Secondary = defaultObservedWindows.Secondary
+	}
+	return w
+}
+
+// observedWindowsFromEnv reads the window overrides as Go durations; invalid
+// or non-positive values keep the default and return a warning.
+func observedWindowsFromEnv() (observedWindows, []string) {
+	windows := defaultObservedWindows
+	var warnings []string
+	for _, env := range []struct {
+		name   string
+		target *time.Duration
+	}{
+		{primaryWindowEnvVar, &windows.Primary},
+		{secondaryWindowEnvVar, &windows.Secondary},
+	} {
+		raw := strings.TrimSpace(os.Getenv(env.name))
+		if raw == "" {
+			continue
+		}
+		parsed, err := time.ParseDuration(raw)
+		if err != nil || parsed <= 0 {
+			warnings = append(warnings, fmt.Sprintf("ignoring invalid %s=%q; using %s", env.name, raw, compactDuration(*env.target)))
+			continue
+		}
+		*env.target = parsed
+	}
+	return windows, warnings
+}
+
+// fileCutoff is the oldest modification time worth scanning: the longer
+// window plus a day for sessions that started before it.
+func (w observedWindows) fileCutoff(now time.Time) time.Time {
+	return now.Add(-(max(w.Primary, w.Secondary) + 24*time.Hour))
+}
+
+func (w observedWindows) noteSuffix() string {
+	if w == defaultObservedWindows {
+		return ""
+	}
+	return fmt.Sprintf(" (windows %s/%s)", compactDuration(w.Primary), compactDuration(w.Secondary))
+}
+
+// compactDuration prints whole days as "7d" and whole hours as "5h".
+func compactDuration(d time.Duration) string {
+	switch {
+	case d%(24*time.Hour) == 0:
+		return fmt.Sprintf("%dd", d/(24*time.Hour))
+	case d%time.Hour == 0:
+		return fmt.Sprintf("%dh", d/time.Hour)
+	default:
+		return d.String()
+	}
 }
 
 type cachedObservedEstimate struct {
@@ -50,6 +189,8 @@ type tokenCountLine struct {
 type tokenCountLinePayload struct {
 	Type string          `json:"type"`
 	Info *tokenCountInfo `json:"info"`
+	// Model is set on turn_context records.
+	Model string `json:"model"`
 }
 
 type tokenCountInfo struct {
@@ -67,14 +208,19 @@ type tokenUsageTotal struct {
 }
 
 type ObservedTokenBreakdown struct {
-	Total           int64 `json:"total"`
-	Input           int64 `json:"input,omitempty"`
-	CachedInput     int64 `json:"cached_input,omitempty"`
-	Output          int64 `json:"output,omitempty"`
-	ReasoningOutput int64 `json:"reasoning_output,omitempty"`
-	CachedOutput    int64 `json:"cached_output,omitempty"`
-	HasSplit        bool  `json:"has_split,omitempty"`
-	HasCachedOutput bool  `json:"has_cached_output,omitempty"`
+	Total           int64      `json:"total"`
+	Input           int64      `json:"input,omitempty"`
+	CachedInput     int64      `json:"cached_input,omitempty"`
+	Output          int64      `json:"output,omitempty"`
+	ReasoningOutput int64      `json:"reasoning_output,omitempty"`
+	CachedOutput    int64      `json:"cached_output,omitempty"`
+	HasSplit        bool       `json:"has_split,omitempty"`
+	HasCachedOutput bool       `json:"has_cached_output,omitempty"`
+	LastEventAt     *time.Time `json:"last_event_at,omitempty"`
+	// EstimatedCostUSD is set only when a pricing file prices every model seen.
+	EstimatedCostUSD *float64 `json:"estimated_cost_usd,omitempty"`
+
+	models map[string]tokenUsageTotal
 }
 
 type tokenAccumulator struct {
@@ -86,6 +232,10 @@ type tokenAccumulator struct {
 	CachedOutput    int64
 	HasSplit        bool
 	HasCachedOutput bool
+	Events          int
+	LastEventAt     time.Time
+	// Models splits the counts by the turn_context model in effect.
+	Models map[string]tokenUsageTotal
 }
 
 type observedWindowPair struct {
@@ -93,19 +243,26 @@ type observedWindowPair struct {
 	WindowWeekly ObservedTokenBreakdown
 }
 
-func newObservedTokenEstimator(ttl time.Duration, async bool) *observedTokenEstimator {
+func newObservedTokenEstimator(ttl time.Duration, async bool, windows observedWindows) *observedTokenEstimator {
 	if ttl <= 0 {
 		ttl = 60 * time.Second
 	}
+	ctx, cancel := context.WithCancel(context.Background())
 	return &observedTokenEstimator{
-		cache:    map[string]cachedObservedEstimate{},
-		ttl:      ttl,
-		async:    async,
-		inflight: map[string]struct{}{},
+		cache:      map[string]cachedObservedEstimate{},
+		ttl:        ttl,
+		async:      async,
+		inflight:   map[string]time.Time{},
+		ctx:        ctx,
+		cancel:     cancel,
+		maxWorkers: maxAsyncObservedRefreshes,
+		windows:    windows.withDefaults(),
 	}
 }
 
-func (e *observedTokenEstimator) Estimate(codexHome string, now time.Time) (ObservedTokenEstimate, error) {
+// Estimate honors ctx for synchronous scans. Async refreshes deliberately run
+// on the estimator's own context so they can outlive a single fetch.
+func (e *observedTokenEstimator) Estimate(ctx context.Context, codexHome string, now time.Time) (ObservedTokenEstimate, error) {
 	trimmedHome := strings.TrimSpace(codexHome)
 	if trimmedHome == "" {
 		return ObservedTokenEstimate{
@@ -135,12 +292,18 @@ func (e *observedTokenEstimator) Estimate(codexHome string, now time.Time) (Obse
 	if hasCached && now.Sub(cached.at) <= e.ttl {
 		e.mu.Unlock()
 		out := cached.estimate
-		out.Note = "local estimate (updated " + humanDuration(now.Sub(cached.at)) + " ago)"
+		out.Note = cached.estimate.Note + " (updated " + humanDuration(now.Sub(cached.at)) + " ago)"
 		return out, nil
 	}
 	if !e.async {
 		e.mu.Unlock()
-		estimate, err := computeObservedTokenEstimate(home, now)
+		estimate, err := computeObservedTokenEstimate(ctx, home, e.scope, e.windows, now)
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ObservedTokenEstimate{
+				Status: observedTokensStatusUnavailable,
+				Note:   "estimation cancelled",
+			}, fmt.Errorf("estimation cancelled: %w", ctxErr)
+		}
 		if err != nil {
 			return ObservedTokenEstimate{
 				Status: observedTokensStatusUnavailable,
@@ -152,59 +315,155 @@ func (e *observedTokenEstimator) Estimate(codexHome string, now time.Time) (Obse
 		e.mu.Unlock()
 		return estimate, nil
 	}
-	if _, running := e.inflight[home]; !running {
-		e.inflight[home] = struct{}{}
-		go e.refreshAsync(home)
+	if !hasCached && e.warmHome != "" && sameCodexHome(home, e.warmHome) {
+		e.warmHome = ""
+		e.mu.Unlock()
+		if estimate, ok := e.warmNow(ctx, home, now); ok {
+			return estimate, nil
+		}
+		e.mu.Lock()
 	}
+	e.enqueueRefreshLocked(home, now)
+	warmingSince := e.inflight[home]
 	e.mu.Unlock()
 
 	if hasCached {
 		out := cached.estimate
-		out.Note = "local estimate (refreshing)"
+		out.Note = cached.estimate.Note + " (refreshing)"
 		return out, nil
 	}
 
 	return ObservedTokenEstimate{
-		Status:  observedTokensStatusUnavailable,
-		Warming: true,
-		Note:    "warming token estimate",
+		Status:       observedTokensStatusUnavailable,
+		Warming:      true,
+		WarmingSince: warmingSince,
+		Note:         "warming token estimate",
 	}, nil
 }
 
+// warmNow runs the one-off startup scan on the caller's context, which bounds
+// it by the fetch timeout. Failures fall back to the async path.
+func (e *observedTokenEstimator) warmNow(ctx context.Context, home string, now time.Time) (ObservedTokenEstimate, bool) {
+	estimate, err := computeObservedTokenEstimate(ctx, home, e.scope, e.windows, now)
+	if err != nil || ctx.Err() != nil {
+		return ObservedTokenEstimate{}, false
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.closed {
+		return ObservedTokenEstimate{}, false
+	}
+	e.cache[home] = cachedObservedEstimate{at: now, estimate: estimate}
+	return estimate, true
+}
+
+func (e *observedTokenEstimator) cacheEntries() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return len(e.cache)
+}
+
+func (e *observedTokenEstimator) enqueueRefreshLocked(home string, now time.Time) {
+	if e.closed {
+		return
+	}
+	if _, queued := e.inflight[home]; queued {
+		return
+	}
+	e.inflight[home] = now
+	e.pending = append(e.pending, home)
+	if e.workers < max(1, e.maxWorkers) {
+		e.workers++
+		e.wg.Add(1)
+		go e.refreshWorker()
+	}
+}
+
+func (e *observedTokenEstimator) refreshWorker() {
+	defer e.wg.Done()
+	for {
+		e.mu.Lock()
+		if len(e.pending) == 0 || e.closed {
+			e.workers--
+			e.mu.Unlock()
+			return
+		}
+		home := e.pending[0]
+		e.pending = e.pending[1:]
+		e.mu.Unlock()
+
+		e.refreshAsync(home)
+	}
+}
+
 func (e *observedTokenEstimator) refreshAsync(codexHome string) {
+	e.mu.Lock()
+	generation := e.generation
+	e.mu.Unlock()
+
 	now := time.Now().UTC()
-	estimate, err := computeObservedTokenEstimate(codexHome, now)
+	estimate, err := computeObservedTokenEstimate(e.ctx, codexHome, e.scope, e.windows, now)
 	e.mu.Lock()
 	defer e.mu.Unlock()
 	delete(e.inflight, codexHome)
-	if err != nil {
+	if err != nil || e.closed || generation != e.generation {
 		return
 	}
 	e.cache[codexHome] = cachedObservedEstimate{at: now, estimate: estimate}
 }
 
-func computeObservedTokenEstimate(codexHome string, now time.Time) (ObservedTokenEstimate, error) {
-	files, warnings, err := discoverRecentUsageFiles(codexHome, now)
+// ClearCache forgets every cached estimate so the next Estimate recomputes.
+func (e *observedTokenEstimator) ClearCache() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.cache = map[string]cachedObservedEstimate{}
+	e.generation++
+}
+
+// Close drops queued refreshes, cancels the running ones, and waits for the
+// workers to exit.
+func (e *observedTokenEstimator) Close() error {
+	e.mu.Lock()
+	e.closed = true
+	for _, home := range e.pending {
+		delete(e.inflight, home)
+	}
+	e.pending = nil
+	e.mu.Unlock()
+
+	e.cancel()
+	e.wg.Wait()
+	return nil
+}
+
+func computeObservedTokenEstimate(ctx context.Context, codexHome string, scope SessionsScope, windows observedWindows, now time.Time) (ObservedTokenEstimate, error) {
+	windows = windows.withDefaults()
+	files, warnings, partial, err := discoverRecentUsageFiles(ctx, codexHome, scope, windows, now)
 	if err != nil {
 		return ObservedTokenEstimate{}, err
 	}
 
-	cutoff5h := now.Add(-5 * time.Hour)
-	cutoff1w := now.Add(-7 * 24 * time.Hour)
+	cutoff5h := now.Add(-windows.Primary)
+	cutoff1w := now.Add(-windows.Secondary)
 
-	total5h, totalWeekly, fileWarnings, err := estimateTokensAcrossFiles(files, cutoff5h, cutoff1w)
+	total5h, totalWeekly, fileWarnings, err := estimateTokensAcrossFiles(ctx, files, cutoff5h, cutoff1w)
 	if err != nil {
 		return ObservedTokenEstimate{}, err
 	}
 	warnings = append(warnings, fileWarnings...)
 
-	return ObservedTokenEstimate{
+	estimate := ObservedTokenEstimate{
 		Window5h:     total5h.toBreakdown(),
 		WindowWeekly: totalWeekly.toBreakdown(),
 		Status:       observedTokensStatusEstimated,
-		Note:         "local estimate",
+		Note:         "local estimate" + scope.noteSuffix() + windows.noteSuffix(),
 		Warnings:     dedupeStrings(warnings),
-	}, nil
+	}
+	if partial {
+		estimate.Status = observedTokensStatusPartial
+		estimate.Note += "; archived sessions capped"
+	}
+	return estimate, nil
 }
 
 type fileEstimateResult struct {
@@ -214,7 +473,7 @@ type fileEstimateResult struct {
 	err          error
 }
 
-func estimateTokensAcrossFiles(files []string, cutoff5h, cutoff1w time.Time) (tokenAccumulator, tokenAccumulator, []string, error) {
+func estimateTokensAcrossFiles(ctx context.Context, files []string, cutoff5h, cutoff1w time.Time) (tokenAccumulator, tokenAccumulator, []string, error) {
 	if len(files) == 0 {
 		return tokenAccumulator{}, tokenAccumulator{}, nil, nil
 	}
@@ -248,8 +507,16 @@ func estimateTokensAcrossFiles(files []string, cutoff5h, cutoff1w time.Time) (to
 	}
 
 	go func() {
+	feed:
 		for _, file := range files {
-			jobs <- file
+			if ctx.Err() != nil {
+				break
+			}
+			select {
+			case jobs <- file:
+			case <-ctx.Done():
+				break feed
+			}
 		}
 		close(jobs)
 		wg.Wait()
@@ -272,18 +539,39 @@ func estimateTokensAcrossFiles(files []string, cutoff5h, cutoff1w time.Time) (to
 		totalWeekly.add(result.windowWeekly)
 		warnings = append(warnings, result.warnings...)
 	}
+	if err := ctx.Err(); err != nil {
+		return tokenAccumulator{}, tokenAccumulator{}, nil, err
+	}
 	if firstErr != nil {
 		return tokenAccumulator{}, tokenAccumulator{}, nil, firstErr
 	}
 	return total5h, totalWeekly, warnings, nil
 }
 
-func discoverRecentUsageFiles(codexHome string, now time.Time) ([]string, []string, error) {
-	var files []string
-	var warnings []string
-	cutoff := now.Add(-8 * 24 * time.Hour)
+// discoverRecentUsageFiles lists session logs in scope that may hold events
+// inside the longer window. partial reports that the archived-file cap
+// dropped older files.
+func discoverRecentUsageFiles(ctx context.Context, codexHome string, scope SessionsScope, windows observedWindows, now time.Time) (files []string, warnings []string, partial bool, err error) {
+	cutoff := windows.withDefaults().fileCutoff(now)
+	days := int(now.Sub(cutoff).Hours()/24 + 0.5)
 
-	for day := 0; day <= 8; day++ {
+	if pattern := strings.TrimSpace(os.Getenv(sessionGlobEnvVar)); pattern != "" {
+		return discoverUsageFilesByGlob(ctx, codexHome, pattern, cutoff)
+	}
+
+	liveRoot, liveWarning := usableSessionsDir(filepath.Join(codexHome, "sessions"))
+	if liveWarning != "" && scope != SessionsScopeArchived {
+		warnings = append(warnings, liveWarning)
+	}
+	archivedRoot, archivedWarning := usableSessionsDir(filepath.Join(codexHome, "archived_sessions"))
+	if archivedWarning != "" && scope != SessionsScopeLive {
+		warnings = append(warnings, archivedWarning)
+	}
+
+	for day := 0; day <= days && liveRoot && scope != SessionsScopeArchived; day++ {
+		if err := ctx.Err(); err != nil {
+			return nil, nil, false, err
+		}
 		d := now.AddDate(0, 0, -day)
 		dir := filepath.Join(codexHome, "sessions", d.Format("2006"), d.Format("01"), d.Format("02"))
 		entries, err := os.ReadDir(dir)
@@ -291,25 +579,41 @@ func discoverRecentUsageFiles(codexHome string, now time.Time) ([]string, []stri
 			if errors.Is(err, os.ErrNotExist) {
 				continue
 			}
-			return nil, nil, fmt.Errorf("read sessions dir %s: %w", dir, err)
+			return nil, nil, false, fmt.Errorf("read sessions dir %s: %w", dir, err)
 		}
 		for _, entry := range entries {
-			if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".jsonl") {
+			if entry.IsDir() || !isSessionLogName(entry.Name()) {
 				continue
 			}
 			files = append(files, filepath.Join(dir, entry.Name()))
 		}
 	}
 
+	if err := ctx.Err(); err != nil {
+		return nil, nil, false, err
+	}
+	if scope == SessionsScopeLive {
+		sort.Strings(files)
+		return files, warnings, false, nil
+	}
+	if !archivedRoot {
+		sort.Strings(files)
+		return files, warnings, false, nil
+	}
 	archivedDir := filepath.Join(codexHome, "archived_sessions")
 	entries, err := os.ReadDir(archivedDir)
 	if err != nil {
 		if !errors.Is(err, os.ErrNotExist) {
-			return nil, nil, fmt.Errorf("read archived sessions dir %s: %w", archivedDir, err)
+			return nil, nil, false, fmt.Errorf("read archived sessions dir %s: %w", archivedDir, err)
 		}
 	} else {
+		type archivedFile struct {
+			path    string
+			modTime time.Time
+		}
+		var archived []archivedFile
 		for _, entry := range entries {
-			if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".jsonl") {
+			if entry.IsDir() || !isSessionLogName(entry.Name()) {
 				continue
 			}
 			fullPath := filepath.Join(archivedDir, entry.Name())
@@ -321,26 +625,155 @@ func discoverRecentUsageFiles(codexHome string, now time.Time) ([]string, []stri
 			if info.ModTime().UTC().Before(cutoff) {
 				continue
 			}
-			files = append(files, fullPath)
+			archived = append(archived, archivedFile{path: fullPath, modTime: info.ModTime()})
+		}
+
+		limit, limitWarning := maxArchivedFiles()
+		if limitWarning != "" {
+			warnings = append(warnings, limitWarning)
+		}
+		if limit > 0 && len(archived) > limit {
+			sort.Slice(archived, func(i, j int) bool {
+				return archived[i].modTime.After(archived[j].modTime)
+			})
+			warnings = append(warnings, fmt.Sprintf(
+				"scanned the newest %d of %d archived session files; observed totals are partial (raise %s to scan more)",
+				limit, len(archived), maxArchivedFilesEnvVar,
+			))
+			archived = archived[:limit]
+			partial = true
+		}
+		for _, file := range archived {
+			files = append(files, file.path)
+		}
+	}
+
+	sort.Strings(files)
+	return files, warnings, partial, nil
+}
+
+// isSessionLogName matches session logs, plain or gzipped by log rotation.
+func isSessionLogName(name string) bool {
+	return strings.HasSuffix(name, ".jsonl") || strings.HasSuffix(name, ".jsonl.gz")
+}
+
+// discoverUsageFilesByGlob keeps regular files matching pattern that were
+// modified after cutoff, newest first up to the archived-file cap; sessions
+// scope does not apply. An absolute pattern is not tied to any home, so only
+// the active home reads it and other accounts do not count the same files.
+func discoverUsageFilesByGlob(ctx context.Context, codexHome, pattern string, cutoff time.Time) (files []string, warnings []string, partial bool, err error) {
+	if !filepath.IsAbs(pattern) {
+		pattern = filepath.Join(codexHome, pattern)
+	} else if normalizeHome(codexHome) != resolveActiveCodexHome() {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return nil, nil, false, fmt.Errorf("invalid %s %q: %w", sessionGlobEnvVar, pattern, err)
+		}
+		return nil, nil, false, nil
+	}
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, nil, false, fmt.Errorf("invalid %s %q: %w", sessionGlobEnvVar, pattern, err)
+	}
+	type matchedFile struct {
+		path    string
+		modTime time.Time
+	}
+	var matched []matchedFile
+	for _, match := range matches {
+		if err := ctx.Err(); err != nil {
+			return nil, nil, false, err
+		}
+		info, err := os.Stat(match)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("skip %s: %v", match, err))
+			continue
+		}
+		if !info.Mode().IsRegular() || info.ModTime().UTC().Before(cutoff) {
+			continue
 		}
+		matched = append(matched, matchedFile{path: match, modTime: info.ModTime()})
 	}
 
+	limit, limitWarning := maxArchivedFiles()
+	if limitWarning != "" {
+		warnings = append(warnings, limitWarning)
+	}
+	if limit > 0 && len(matched) > limit {
+		sort.Slice(matched, func(i, j int) bool {
+			return matched[i].modTime.After(matched[j].modTime)
+		})
+		warnings = append(warnings, fmt.Sprintf(
+			"scanned the newest %d of %d session files matching %s; observed totals are partial (raise %s to scan more)",
+			limit, len(matched), sessionGlobEnvVar, maxArchivedFilesEnvVar,
+		))
+		matched = matched[:limit]
+		partial = true
+	}
+	for _, file := range matched {
+		files = append(files, file.path)
+	}
 	sort.Strings(files)
-	return files, warnings, nil
+	return files, warnings, partial, nil
+}
+
+// usableSessionsDir reports whether a sessions root can be listed. A missing
+// root is silently empty; a file or dangling symlink there is empty with a
+// warning instead of failing the whole estimate.
+func usableSessionsDir(path string) (bool, string) {
+	if _, err := os.Lstat(path); err != nil {
+		return false, ""
+	}
+	info, err := os.Stat(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return false, fmt.Sprintf("sessions path %s is a broken symlink; no usage files found there", path)
+	case err != nil:
+		return false, fmt.Sprintf("sessions path %s is not readable: %v", path, err)
+	case !info.IsDir():
+		return false, fmt.Sprintf("sessions path %s is not a directory; no usage files found there", path)
+	}
+	return true, ""
+}
+
+// maxArchivedFiles bounds how many archived logs one estimate reads, newest
+// first. 0 disables the cap.
+func maxArchivedFiles() (int, string) {
+	raw := strings.TrimSpace(os.Getenv(maxArchivedFilesEnvVar))
+	if raw == "" {
+		return defaultMaxArchivedFiles, ""
+	}
+	limit, err := strconv.Atoi(raw)
+	if err != nil || limit < 0 {
+		return defaultMaxArchivedFiles, fmt.Sprintf("ignoring invalid %s=%q; using %d", maxArchivedFilesEnvVar, raw, defaultMaxArchivedFiles)
+	}
+	return limit, ""
 }
 
 func estimateTokensFromFile(path string, cutoff5h, cutoff1w time.Time) (tokenAccumulator, tokenAccumulator, []string, error) {
 	f, err := os.Open(path)
 	if err != nil {
-		return tokenAccumulator{}, tokenAccumulator{}, nil, fmt.Errorf("open usage file %s: %w", path, err)
+		// One locked or vanished file should not zero out the whole account.
+		return tokenAccumulator{}, tokenAccumulator{}, []string{fmt.Sprintf("skip %s: %v", path, err)}, nil
 	}
 	defer f.Close()
 
-	scanner := bufio.NewScanner(f)
+	var r io.Reader = f
+	compressed := strings.HasSuffix(path, ".gz")
+	if compressed {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return tokenAccumulator{}, tokenAccumulator{}, []string{fmt.Sprintf("skip %s: %v", path, err)}, nil
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	scanner := bufio.NewScanner(r)
 	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
 
 	var warnings []string
 	var prevTotal *tokenUsageTotal
+	model := ""
 	var sum5h tokenAccumulator
 	var sum1w tokenAccumulator
 	parseErrCount := 0
@@ -352,6 +785,10 @@ func estimateTokensFromFile(path string, cutoff5h, cutoff1w time.Time) (tokenAcc
 			parseErrCount++
 			continue
 		}
+		if rec.Type == "turn_context" && strings.TrimSpace(rec.Payload.Model) != "" {
+			model = strings.TrimSpace(rec.Payload.Model)
+			continue
+		}
 		if rec.Type != "event_msg" || rec.Payload.Type != "token_count" || rec.Payload.Info == nil {
 			continue
 		}
@@ -362,20 +799,26 @@ func estimateTokensFromFile(path string, cutoff5h, cutoff1w time.Time) (tokenAcc
 			continue
 		}
 		eventTime = eventTime.UTC()
+		total := rec.Payload.Info.Total.nonNegative()
+		last := rec.Payload.Info.Last.nonNegative()
 		if !eventTime.Before(cutoff1w) {
-			usage, ok := usageForEvent(rec.Payload.Info.Total, rec.Payload.Info.Last, prevTotal)
+			usage, ok := usageForEvent(total, last, prevTotal)
 			if ok {
-				sum1w.addTokenUsage(usage)
+				sum1w.addTokenUsage(usage, eventTime, model)
 				if !eventTime.Before(cutoff5h) {
-					sum5h.addTokenUsage(usage)
+					sum5h.addTokenUsage(usage, eventTime, model)
 				}
 			}
 		}
-		current := rec.Payload.Info.Total
+		current := total
 		prevTotal = &current
 	}
 
 	if err := scanner.Err(); err != nil {
+		// A corrupt or half-rotated archive is skipped like an unreadable file.
+		if compressed && !errors.Is(err, bufio.ErrTooLong) {
+			return tokenAccumulator{}, tokenAccumulator{}, []string{fmt.Sprintf("skip %s: %v", path, err)}, nil
+		}
 		return tokenAccumulator{}, tokenAccumulator{}, nil, fmt.Errorf("scan usage file %s: %w", path, err)
 	}
 
@@ -427,37 +870,69 @@ func nonNegativeDelta(prev, current int64) int64 {
 }
 
 func (a *tokenAccumulator) add(other tokenAccumulator) {
-	a.Total += other.Total
-	a.Input += other.Input
-	a.CachedInput += other.CachedInput
-	a.Output += other.Output
-	a.ReasoningOutput += other.ReasoningOutput
-	a.CachedOutput += other.CachedOutput
+	a.Total = saturatingAdd(a.Total, other.Total)
+	a.Input = saturatingAdd(a.Input, other.Input)
+	a.CachedInput = saturatingAdd(a.CachedInput, other.CachedInput)
+	a.Output = saturatingAdd(a.Output, other.Output)
+	a.ReasoningOutput = saturatingAdd(a.ReasoningOutput, other.ReasoningOutput)
+	a.CachedOutput = saturatingAdd(a.CachedOutput, other.CachedOutput)
 	a.HasSplit = a.HasSplit || other.HasSplit
 	a.HasCachedOutput = a.HasCachedOutput || other.HasCachedOutput
+	a.Events += other.Events
+	if other.LastEventAt.After(a.LastEventAt) {
+		a.LastEventAt = other.LastEventAt
+	}
+	for model, usage := range other.Models {
+		a.addModelUsage(model, usage)
+	}
 }
 
 func (a *tokenAccumulator) addTotalOnly(total int64) {
 	a.Total += total
 }
 
-func (a *tokenAccumulator) addTokenUsage(usage tokenUsageTotal) {
+func (a *tokenAccumulator) addTokenUsage(usage tokenUsageTotal, at time.Time, model string) {
 	if usage.TotalTokens <= 0 {
 		return
 	}
-	a.Total += usage.TotalTokens
-	a.Input += usage.InputTokens
-	a.CachedInput += usage.CachedInputTokens
-	a.Output += usage.OutputTokens
-	a.ReasoningOutput += usage.ReasoningOutputTokens
-	a.CachedOutput += usage.CachedOutputTokens
+	a.Total = saturatingAdd(a.Total, usage.TotalTokens)
+	a.Input = saturatingAdd(a.Input, usage.InputTokens)
+	a.CachedInput = saturatingAdd(a.CachedInput, usage.CachedInputTokens)
+	a.Output = saturatingAdd(a.Output, usage.OutputTokens)
+	a.ReasoningOutput = saturatingAdd(a.ReasoningOutput, usage.ReasoningOutputTokens)
+	a.CachedOutput = saturatingAdd(a.CachedOutput, usage.CachedOutputTokens)
 	a.HasSplit = true
+	a.Events++
+	if at.After(a.LastEventAt) {
+		a.LastEventAt = at
+	}
 	if usage.CachedOutputTokens != 0 {
 		a.HasCachedOutput = true
 	}
+	a.addModelUsage(model, usage)
+}
+
+func (a *tokenAccumulator) addModelUsage(model string, usage tokenUsageTotal) {
+	if a.Models == nil {
+		a.Models = map[string]tokenUsageTotal{}
+	}
+	prev := a.Models[model]
+	a.Models[model] = tokenUsageTotal{
+		TotalTokens:           saturatingAdd(prev.TotalTokens, usage.TotalTokens),
+		InputTokens:           saturatingAdd(prev.InputTokens, usage.InputTokens),
+		CachedInputTokens:     saturatingAdd(prev.CachedInputTokens, usage.CachedInputTokens),
+		OutputTokens:          saturatingAdd(prev.OutputTokens, usage.OutputTokens),
+		ReasoningOutputTokens: saturatingAdd(prev.ReasoningOutputTokens, usage.ReasoningOutputTokens),
+		CachedOutputTokens:    saturatingAdd(prev.CachedOutputTokens, usage.CachedOutputTokens),
+	}
 }
 
 func (a tokenAccumulator) toBreakdown() ObservedTokenBreakdown {
+	var lastEventAt *time.Time
+	if !a.LastEventAt.IsZero() {
+		at := a.LastEventAt
+		lastEventAt = &at
+	}
 	return ObservedTokenBreakdown{
 		Total:           a.Total,
 		Input:           a.Input,
@@ -467,7 +942,32 @@ func (a tokenAccumulator) toBreakdown() ObservedTokenBreakdown {
 		CachedOutput:    a.CachedOutput,
 		HasSplit:        a.HasSplit,
 		HasCachedOutput: a.HasCachedOutput,
+		LastEventAt:     lastEventAt,
+		models:          a.Models,
+	}
+}
+
+// nonNegative clamps negative counters from malformed logs to zero so deltas
+// between two sanitized totals cannot overflow.
+func (t tokenUsageTotal) nonNegative() tokenUsageTotal {
+	return tokenUsageTotal{
+		TotalTokens:           max(t.TotalTokens, 0),
+		InputTokens:           max(t.InputTokens, 0),
+		CachedInputTokens:     max(t.CachedInputTokens, 0),
+		OutputTokens:          max(t.OutputTokens, 0),
+		ReasoningOutputTokens: max(t.ReasoningOutputTokens, 0),
+		CachedOutputTokens:    max(t.CachedOutputTokens, 0),
+	}
+}
+
+func saturatingAdd(a, b int64) int64 {
+	if b > 0 && a > math.MaxInt64-b {
+		return math.MaxInt64
+	}
+	if b < 0 && a < math.MinInt64-b {
+		return math.MinInt64
 	}
+	return a + b
 }
 
 func (t tokenUsageTotal) hasUsage() bool {
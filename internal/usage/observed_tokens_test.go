@@ -1,9 +1,16 @@
 package usage
 
 import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
 	"fmt"
+	"math"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 )
@@ -42,7 +49,7 @@ func TestComputeObservedTokenEstimate(t *testing.T) {
 		t.Fatalf("chtimes archived file: %v", err)
 	}
 
-	estimate, err := computeObservedTokenEstimate(home, now)
+	estimate, err := computeObservedTokenEstimate(context.Background(), home, SessionsScopeAll, defaultObservedWindows, now)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -58,19 +65,306 @@ func TestComputeObservedTokenEstimate(t *testing.T) {
 	if len(estimate.Warnings) == 0 {
 		t.Fatalf("expected parse warning for invalid json line")
 	}
+	if last := estimate.WindowWeekly.LastEventAt; last == nil || !last.Equal(now.Add(-30*time.Minute)) {
+		t.Fatalf("expected newest counted event time, got %v", last)
+	}
+}
+
+func TestComputeObservedTokenEstimateHonorsWindowOverrides(t *testing.T) {
+	now := time.Date(2026, 2, 26, 20, 0, 0, 0, time.UTC)
+	home := t.TempDir()
+
+	todayDir := filepath.Join(home, "sessions", now.Format("2006"), now.Format("01"), now.Format("02"))
+	if err := os.MkdirAll(todayDir, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	sessionContent := tokenCountJSONLine(now.Add(-6*time.Hour), 100) + "\n" +
+		tokenCountJSONLine(now.Add(-4*time.Hour), 140) + "\n" +
+		tokenCountJSONLine(now.Add(-150*time.Minute), 200) + "\n" +
+		tokenCountJSONLine(now.Add(-30*time.Minute), 260) + "\n"
+	if err := os.WriteFile(filepath.Join(todayDir, "session-a.jsonl"), []byte(sessionContent), 0o600); err != nil {
+		t.Fatalf("write session file: %v", err)
+	}
+	archivedDir := filepath.Join(home, "archived_sessions")
+	if err := os.MkdirAll(archivedDir, 0o755); err != nil {
+		t.Fatalf("mkdir archived: %v", err)
+	}
+	archivedPath := filepath.Join(archivedDir, "archived-a.jsonl")
+	archivedContent := tokenCountJSONLine(now.Add(-3*24*time.Hour), 20) + "\n" +
+		tokenCountJSONLine(now.Add(-2*24*time.Hour), 50) + "\n"
+	if err := os.WriteFile(archivedPath, []byte(archivedContent), 0o600); err != nil {
+		t.Fatalf("write archived file: %v", err)
+	}
+	if err := os.Chtimes(archivedPath, now, now); err != nil {
+		t.Fatalf("chtimes archived file: %v", err)
+	}
+
+	t.Setenv(primaryWindowEnvVar, "2h")
+	t.Setenv(secondaryWindowEnvVar, "24h")
+	windows, warnings := observedWindowsFromEnv()
+	if len(warnings) != 0 {
+		t.Fatalf("expected no warnings for valid durations, got %v", warnings)
+	}
+	if got := windows.noteSuffix(); got != " (windows 2h/1d)" {
+		t.Fatalf("expected custom windows in the note, got %q", got)
+	}
+	estimate, err := computeObservedTokenEstimate(context.Background(), home, SessionsScopeAll, windows, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Only the last turn lands inside two hours.
+	if estimate.Window5h.Total != 60 {
+		t.Fatalf("expected 60 tokens in the 2h window, got %d", estimate.Window5h.Total)
+	}
+	if estimate.WindowWeekly.Total != 160 {
+		t.Fatalf("expected 160 tokens in the 1d window, got %d", estimate.WindowWeekly.Total)
+	}
+
+	t.Setenv(primaryWindowEnvVar, "soon")
+	t.Setenv(secondaryWindowEnvVar, "-1h")
+	windows, warnings = observedWindowsFromEnv()
+	if windows != defaultObservedWindows {
+		t.Fatalf("expected invalid durations to fall back to defaults, got %+v", windows)
+	}
+	if len(warnings) != 2 || !strings.Contains(warnings[0], primaryWindowEnvVar) || !strings.Contains(warnings[1], "using 7d") {
+		t.Fatalf("expected a warning per invalid duration, got %v", warnings)
+	}
+}
+
+func TestComputeObservedTokenEstimateReadsGzippedLogs(t *testing.T) {
+	now := time.Now().UTC()
+	fixture := newSessionFixture(t, now.Add(-3*time.Hour), "gpt-5").
+		turn(t, now.Add(-3*time.Hour), tokenUsageTotal{InputTokens: 300, CachedInputTokens: 100, OutputTokens: 50}).
+		turn(t, now.Add(-2*24*time.Hour+time.Hour), tokenUsageTotal{InputTokens: 700, OutputTokens: 90, ReasoningOutputTokens: 40})
+
+	plainHome := t.TempDir()
+	fixture.writeArchived(t, plainHome, "rollout-a.jsonl", now)
+
+	gzHome := t.TempDir()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write([]byte(fixture.String())); err != nil {
+		t.Fatalf("gzip fixture: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("close gzip: %v", err)
+	}
+	archivedDir := filepath.Join(gzHome, "archived_sessions")
+	if err := os.MkdirAll(archivedDir, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	for name, content := range map[string][]byte{
+		"rollout-a.jsonl.gz":  buf.Bytes(),
+		"rollout-b.jsonl.gz":  []byte("not gzip"),
+		"rollout-c.jsonl.zst": buf.Bytes(),
+	} {
+		if err := os.WriteFile(filepath.Join(archivedDir, name), content, 0o600); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+
+	plain, err := computeObservedTokenEstimate(context.Background(), plainHome, SessionsScopeAll, defaultObservedWindows, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	compressed, err := computeObservedTokenEstimate(context.Background(), gzHome, SessionsScopeAll, defaultObservedWindows, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if plain.WindowWeekly.Total == 0 || !reflect.DeepEqual(compressed.Window5h, plain.Window5h) || !reflect.DeepEqual(compressed.WindowWeekly, plain.WindowWeekly) {
+		t.Fatalf("expected gzipped totals to match plain ones, got %+v / %+v vs %+v / %+v", compressed.Window5h, compressed.WindowWeekly, plain.Window5h, plain.WindowWeekly)
+	}
+	if len(compressed.Warnings) != 1 || !strings.Contains(compressed.Warnings[0], "rollout-b.jsonl.gz") {
+		t.Fatalf("expected only the corrupt archive to be skipped with a warning, got %v", compressed.Warnings)
+	}
+}
+
+func TestComputeObservedTokenEstimateSkipsUnreadableFiles(t *testing.T) {
+	now := time.Date(2026, 2, 26, 20, 0, 0, 0, time.UTC)
+	home := t.TempDir()
+	todayDir := filepath.Join(home, "sessions", now.Format("2006"), now.Format("01"), now.Format("02"))
+	if err := os.MkdirAll(todayDir, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	readable := tokenCountJSONLine(now.Add(-2*time.Hour), 100) + "\n" + tokenCountJSONLine(now.Add(-1*time.Hour), 150) + "\n"
+	if err := os.WriteFile(filepath.Join(todayDir, "session-a.jsonl"), []byte(readable), 0o600); err != nil {
+		t.Fatalf("write session file: %v", err)
+	}
+	lockedPath := filepath.Join(todayDir, "session-b.jsonl")
+	if err := os.WriteFile(lockedPath, []byte(readable), 0o000); err != nil {
+		t.Fatalf("write locked file: %v", err)
+	}
+	if f, err := os.Open(lockedPath); err == nil {
+		// Running as root ignores permission bits; a dangling symlink still
+		// exercises the per-file open failure.
+		_ = f.Close()
+		if err := os.Remove(lockedPath); err != nil {
+			t.Fatalf("remove locked file: %v", err)
+		}
+		if err := os.Symlink(filepath.Join(home, "missing.jsonl"), lockedPath); err != nil {
+			t.Fatalf("symlink: %v", err)
+		}
+	}
+
+	estimate, err := computeObservedTokenEstimate(context.Background(), home, SessionsScopeAll, defaultObservedWindows, now)
+	if err != nil {
+		t.Fatalf("expected unreadable file to be skipped, got error: %v", err)
+	}
+	if estimate.Window5h.Total != 50 {
+		t.Fatalf("expected readable file tokens 50, got %d", estimate.Window5h.Total)
+	}
+	found := false
+	for _, warning := range estimate.Warnings {
+		if strings.Contains(warning, "skip "+lockedPath) {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("expected skip warning for unreadable file, got %v", estimate.Warnings)
+	}
+}
+
+func TestComputeObservedTokenEstimateToleratesNonDirectorySessionsPaths(t *testing.T) {
+	now := time.Date(2026, 2, 26, 20, 0, 0, 0, time.UTC)
+	cases := map[string]func(t *testing.T, path string){
+		"file": func(t *testing.T, path string) {
+			if err := os.WriteFile(path, []byte("not a directory"), 0o600); err != nil {
+				t.Fatalf("write file: %v", err)
+			}
+		},
+		"dangling symlink": func(t *testing.T, path string) {
+			if err := os.Symlink(filepath.Join(filepath.Dir(path), "gone"), path); err != nil {
+				t.Fatalf("symlink: %v", err)
+			}
+		},
+	}
+	for name, setup := range cases {
+		t.Run(name, func(t *testing.T) {
+			home := t.TempDir()
+			setup(t, filepath.Join(home, "sessions"))
+			setup(t, filepath.Join(home, "archived_sessions"))
+
+			estimate, err := computeObservedTokenEstimate(context.Background(), home, SessionsScopeAll, defaultObservedWindows, now)
+			if err != nil {
+				t.Fatalf("expected a graceful empty estimate, got error: %v", err)
+			}
+			if estimate.Window5h.Total != 0 || estimate.WindowWeekly.Total != 0 {
+				t.Fatalf("expected no tokens, got %+v", estimate)
+			}
+			want := "is not a directory"
+			if name == "dangling symlink" {
+				want = "is a broken symlink"
+			}
+			matches := 0
+			for _, warning := range estimate.Warnings {
+				if strings.Contains(warning, want) {
+					matches++
+				}
+			}
+			if matches != 2 {
+				t.Fatalf("expected a %q warning for both roots, got %v", want, estimate.Warnings)
+			}
+		})
+	}
+}
+
+func TestComputeObservedTokenEstimateUsesSessionGlob(t *testing.T) {
+	now := time.Now().UTC()
+	home := t.TempDir()
+	t.Setenv("CODEX_HOME", home)
+	logs := filepath.Join(home, "central-logs")
+	newSessionFixture(t, now.Add(-time.Hour), "gpt-5-codex").
+		turn(t, now.Add(-time.Hour), tokenUsageTotal{InputTokens: 300, OutputTokens: 100}).
+		writeTo(t, logs, "host-a.jsonl")
+	stale := newSessionFixture(t, now.Add(-20*24*time.Hour), "gpt-5").
+		turn(t, now.Add(-20*24*time.Hour), tokenUsageTotal{InputTokens: 999}).
+		writeTo(t, logs, "host-old.jsonl")
+	if err := os.Chtimes(stale, now.Add(-20*24*time.Hour), now.Add(-20*24*time.Hour)); err != nil {
+		t.Fatalf("chtimes: %v", err)
+	}
+	// The standard layout is ignored once the glob is set.
+	newSessionFixture(t, now.Add(-time.Hour), "gpt-5").
+		turn(t, now.Add(-time.Hour), tokenUsageTotal{InputTokens: 5000}).
+		writeLive(t, home, now, "rollout-live.jsonl")
+
+	t.Setenv(sessionGlobEnvVar, "central-logs/*.jsonl")
+	files, _, _, err := discoverRecentUsageFiles(context.Background(), home, SessionsScopeAll, defaultObservedWindows, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(files) != 1 || filepath.Base(files[0]) != "host-a.jsonl" {
+		t.Fatalf("expected only the recent globbed file, got %v", files)
+	}
+
+	t.Setenv(sessionGlobEnvVar, filepath.Join(logs, "*.jsonl"))
+	estimate, err := computeObservedTokenEstimate(context.Background(), home, SessionsScopeAll, defaultObservedWindows, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if estimate.Window5h.Total != 400 {
+		t.Fatalf("expected 400 tokens from the absolute glob, got %d", estimate.Window5h.Total)
+	}
+	// Other account homes must not count the same absolute-glob files again.
+	otherHome := t.TempDir()
+	if files, _, _, err := discoverRecentUsageFiles(context.Background(), otherHome, SessionsScopeAll, defaultObservedWindows, now); err != nil || len(files) != 0 {
+		t.Fatalf("expected the absolute glob to belong to the active home only, got %v, %v", files, err)
+	}
+
+	newSessionFixture(t, now.Add(-2*time.Hour), "gpt-5").
+		turn(t, now.Add(-2*time.Hour), tokenUsageTotal{InputTokens: 50}).
+		writeTo(t, logs, "host-b.jsonl")
+	older := filepath.Join(logs, "host-b.jsonl")
+	if err := os.Chtimes(older, now.Add(-2*time.Hour), now.Add(-2*time.Hour)); err != nil {
+		t.Fatalf("chtimes: %v", err)
+	}
+	t.Setenv(maxArchivedFilesEnvVar, "1")
+	files, warnings, partial, err := discoverRecentUsageFiles(context.Background(), home, SessionsScopeAll, defaultObservedWindows, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !partial || len(files) != 1 || filepath.Base(files[0]) != "host-a.jsonl" || len(warnings) != 1 {
+		t.Fatalf("expected the cap to keep the newest globbed file and mark totals partial, got %v, %v, %v", files, warnings, partial)
+	}
+	t.Setenv(maxArchivedFilesEnvVar, "")
+
+	t.Setenv(sessionGlobEnvVar, "[")
+	if _, _, _, err := discoverRecentUsageFiles(context.Background(), home, SessionsScopeAll, defaultObservedWindows, now); err == nil {
+		t.Fatalf("expected a malformed glob to fail")
+	}
+}
+
+func TestEstimateTokensFromFileCountsEventsPerWindow(t *testing.T) {
+	now := time.Date(2026, 2, 26, 20, 0, 0, 0, time.UTC)
+	path := filepath.Join(t.TempDir(), "session.jsonl")
+	content := tokenCountJSONLineWithLast(now.Add(-3*24*time.Hour), 40, 40) + "\n" +
+		tokenCountJSONLine(now.Add(-2*time.Hour), 100) + "\n" +
+		tokenCountJSONLine(now.Add(-1*time.Hour), 100) + "\n" +
+		tokenCountJSONLine(now.Add(-30*time.Minute), 180) + "\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write session file: %v", err)
+	}
+
+	sum5h, sum1w, _, err := estimateTokensFromFile(path, now.Add(-5*time.Hour), now.Add(-7*24*time.Hour))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// The unchanged cumulative total at -1h is not a counted event.
+	if sum5h.Events != 2 || sum1w.Events != 3 {
+		t.Fatalf("expected 2 five-hour and 3 weekly events, got %d and %d", sum5h.Events, sum1w.Events)
+	}
 }
 
 func TestObservedEstimatorReturnsUnavailableForMissingHome(t *testing.T) {
-	estimator := newObservedTokenEstimator(0, true)
-	_, err := estimator.Estimate("", time.Now().UTC())
+	estimator := newObservedTokenEstimator(0, true, defaultObservedWindows)
+	_, err := estimator.Estimate(context.Background(), "", time.Now().UTC())
 	if err == nil {
 		t.Fatalf("expected error for missing codex home")
 	}
 }
 
 func TestObservedEstimatorReturnsUnavailableForInvalidHome(t *testing.T) {
-	estimator := newObservedTokenEstimator(0, true)
-	_, err := estimator.Estimate(filepath.Join(t.TempDir(), "missing"), time.Now().UTC())
+	estimator := newObservedTokenEstimator(0, true, defaultObservedWindows)
+	_, err := estimator.Estimate(context.Background(), filepath.Join(t.TempDir(), "missing"), time.Now().UTC())
 	if err == nil {
 		t.Fatalf("expected error for invalid codex home path")
 	}
@@ -79,9 +373,9 @@ func TestObservedEstimatorReturnsUnavailableForInvalidHome(t *testing.T) {
 func TestObservedEstimatorAsyncWarmupSetsWarmingFlag(t *testing.T) {
 	now := time.Now().UTC()
 	home := t.TempDir()
-	estimator := newObservedTokenEstimator(0, true)
+	estimator := newObservedTokenEstimator(0, true, defaultObservedWindows)
 
-	estimate, err := estimator.Estimate(home, now)
+	estimate, err := estimator.Estimate(context.Background(), home, now)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -91,6 +385,166 @@ func TestObservedEstimatorAsyncWarmupSetsWarmingFlag(t *testing.T) {
 	if !estimate.Warming {
 		t.Fatalf("expected warming flag during async warmup")
 	}
+	if !estimate.WarmingSince.Equal(now) {
+		t.Fatalf("expected warmup start %v, got %v", now, estimate.WarmingSince)
+	}
+}
+
+func TestObservedEstimatorWarmHomeScansSynchronouslyOnce(t *testing.T) {
+	now := time.Now().UTC()
+	home := t.TempDir()
+	newSessionFixture(t, now.Add(-time.Hour), "gpt-5-codex").
+		turn(t, now.Add(-time.Hour), tokenUsageTotal{InputTokens: 400, OutputTokens: 100}).
+		writeLive(t, home, now, "rollout-warm.jsonl")
+
+	estimator := newObservedTokenEstimator(time.Minute, true, defaultObservedWindows)
+	defer estimator.Close()
+	estimator.warmHome = home
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	other := t.TempDir()
+	if estimate, err := estimator.Estimate(cancelled, other, now); err != nil || !estimate.Warming {
+		t.Fatalf("expected other homes to stay async, got %+v err=%v", estimate, err)
+	}
+
+	estimate, err := estimator.Estimate(context.Background(), home, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if estimate.Warming || estimate.Status != observedTokensStatusEstimated || estimate.Window5h.Total != 500 {
+		t.Fatalf("expected a synchronous estimate on the first fetch, got %+v", estimate)
+	}
+	if estimator.warmHome != "" {
+		t.Fatalf("expected the warm home to be consumed after one scan")
+	}
+}
+
+func TestObservedEstimatorWarmHomeFallsBackToAsyncOnTimeout(t *testing.T) {
+	now := time.Now().UTC()
+	home := t.TempDir()
+	estimator := newObservedTokenEstimator(time.Minute, true, defaultObservedWindows)
+	defer estimator.Close()
+	estimator.warmHome = home
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	estimate, err := estimator.Estimate(ctx, home, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !estimate.Warming {
+		t.Fatalf("expected a timed-out warm start to fall back to warming, got %+v", estimate)
+	}
+}
+
+func TestObservedEstimatorBoundsAsyncWorkers(t *testing.T) {
+	estimator := newObservedTokenEstimator(time.Minute, true, defaultObservedWindows)
+	defer estimator.Close()
+
+	homes := make([]string, 8)
+	for i := range homes {
+		homes[i] = t.TempDir()
+		if _, err := estimator.Estimate(context.Background(), homes[i], time.Now().UTC()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		estimator.mu.Lock()
+		workers := estimator.workers
+		estimator.mu.Unlock()
+		if workers > maxAsyncObservedRefreshes {
+			t.Fatalf("expected at most %d workers, got %d", maxAsyncObservedRefreshes, workers)
+		}
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		estimator.mu.Lock()
+		done := len(estimator.cache) == len(homes) && estimator.workers == 0
+		estimator.mu.Unlock()
+		if done {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for async refreshes to drain")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestObservedEstimatorCloseStopsPendingRefreshes(t *testing.T) {
+	estimator := newObservedTokenEstimator(time.Minute, true, defaultObservedWindows)
+	for i := 0; i < 6; i++ {
+		_, _ = estimator.Estimate(context.Background(), t.TempDir(), time.Now().UTC())
+	}
+	if err := estimator.Close(); err != nil {
+		t.Fatalf("unexpected close error: %v", err)
+	}
+
+	estimator.mu.Lock()
+	workers, pending := estimator.workers, len(estimator.pending)
+	estimator.mu.Unlock()
+	if workers != 0 || pending != 0 {
+		t.Fatalf("expected no workers or pending refreshes after close, got workers=%d pending=%d", workers, pending)
+	}
+
+	estimate, err := estimator.Estimate(context.Background(), t.TempDir(), time.Now().UTC())
+	if err != nil || !estimate.Warming {
+		t.Fatalf("expected warming estimate after close, got %+v err=%v", estimate, err)
+	}
+	estimator.mu.Lock()
+	defer estimator.mu.Unlock()
+	if len(estimator.pending) != 0 || estimator.workers != 0 {
+		t.Fatalf("expected closed estimator to ignore new refreshes")
+	}
+}
+
+func TestObservedEstimatorReturnsCancelledWhenContextDone(t *testing.T) {
+	estimator := newObservedTokenEstimator(time.Minute, false, defaultObservedWindows)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	estimate, err := estimator.Estimate(ctx, t.TempDir(), time.Now().UTC())
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if estimate.Status != observedTokensStatusUnavailable || estimate.Note != "estimation cancelled" {
+		t.Fatalf("expected cancelled estimate, got %+v", estimate)
+	}
+	if estimator.cacheEntries() != 0 {
+		t.Fatalf("expected cancelled estimate not to be cached")
+	}
+}
+
+func TestObservedEstimatorClearCacheForcesRecompute(t *testing.T) {
+	home := t.TempDir()
+	estimator := newObservedTokenEstimator(time.Hour, false, defaultObservedWindows)
+	now := time.Now().UTC()
+	if _, err := estimator.Estimate(context.Background(), home, now); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cached, err := estimator.Estimate(context.Background(), home, now.Add(time.Second))
+	if err != nil || !strings.Contains(cached.Note, "updated") {
+		t.Fatalf("expected cached estimate before clear, got %+v err=%v", cached, err)
+	}
+
+	estimator.ClearCache()
+	if estimator.cacheEntries() != 0 {
+		t.Fatalf("expected empty cache after clear")
+	}
+	fresh, err := estimator.Estimate(context.Background(), home, now.Add(2*time.Second))
+	if err != nil || fresh.Note != "local estimate" {
+		t.Fatalf("expected recomputed estimate after clear, got %+v err=%v", fresh, err)
+	}
+}
+
+func TestEstimateTokensAcrossFilesStopsWhenCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	now := time.Now().UTC()
+	_, _, _, err := estimateTokensAcrossFiles(ctx, []string{"a.jsonl", "b.jsonl"}, now.Add(-5*time.Hour), now.Add(-7*24*time.Hour))
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
 }
 
 func TestEstimateTokensFromFileDoesNotDoubleCountDuplicateTotals(t *testing.T) {
@@ -135,3 +589,230 @@ func tokenCountJSONLineWithLast(ts time.Time, total int64, last int64) string {
 		last,
 	)
 }
+
+func FuzzEstimateTokensFromFile(f *testing.F) {
+	now := time.Date(2026, 2, 26, 20, 0, 0, 0, time.UTC)
+	f.Add([]byte(tokenCountJSONLine(now.Add(-time.Hour), 100) + "\n" + tokenCountJSONLine(now.Add(-30*time.Minute), 160) + "\n"))
+	f.Add([]byte(tokenCountJSONLine(now.Add(-time.Hour), 500) + "\n" + tokenCountJSONLine(now.Add(-2*time.Hour), 100) + "\n"))
+	f.Add([]byte(tokenCountJSONLineWithLast(now.Add(-time.Hour), -50, -10) + "\n"))
+	f.Add([]byte(tokenCountJSONLine(now.Add(-time.Hour), math.MaxInt64) + "\n" + tokenCountJSONLine(now.Add(-time.Minute), math.MaxInt64) + "\n"))
+	f.Add([]byte(`{"timestamp":"2026-02-26T19:00:00Z","type":"event_msg","payload":{"type":"token_count","info":{"total_token_usage":{"total_tokens":-9223372036854775808,"input_tokens":-9223372036854775808},"last_token_usage":{"total_tokens":10,"input_tokens":9223372036854775807}}}}` + "\n" + `{"timestamp":"2026-02-26T19:30:00Z","type":"event_msg","payload":{"type":"token_count","info":{"total_token_usage":{"total_tokens":9223372036854775807,"input_tokens":9223372036854775807}}}}` + "\n"))
+	f.Add([]byte("not-json\n{}\n"))
+
+	cutoff5h := now.Add(-5 * time.Hour)
+	cutoff1w := now.Add(-7 * 24 * time.Hour)
+
+	f.Fuzz(func(t *testing.T, content []byte) {
+		path := filepath.Join(t.TempDir(), "session.jsonl")
+		if err := os.WriteFile(path, content, 0o600); err != nil {
+			t.Fatalf("write usage file: %v", err)
+		}
+		sum5h, sum1w, _, err := estimateTokensFromFile(path, cutoff5h, cutoff1w)
+		if err != nil {
+			// Oversized lines are a legitimate scan error, not a parser bug.
+			return
+		}
+		for _, acc := range []tokenAccumulator{sum5h, sum1w} {
+			for _, v := range []int64{acc.Total, acc.Input, acc.CachedInput, acc.Output, acc.ReasoningOutput, acc.CachedOutput} {
+				if v < 0 {
+					t.Fatalf("negative token value in %+v", acc)
+				}
+			}
+		}
+		if sum5h.Total > sum1w.Total {
+			t.Fatalf("five-hour total %d exceeds weekly total %d", sum5h.Total, sum1w.Total)
+		}
+	})
+}
+
+func TestDiscoverRecentUsageFilesCapsArchivedNewestFirst(t *testing.T) {
+	now := time.Date(2026, 2, 26, 20, 0, 0, 0, time.UTC)
+	home := t.TempDir()
+	archivedDir := filepath.Join(home, "archived_sessions")
+	if err := os.MkdirAll(archivedDir, 0o755); err != nil {
+		t.Fatalf("mkdir archived: %v", err)
+	}
+	for i := 0; i < 4; i++ {
+		path := filepath.Join(archivedDir, fmt.Sprintf("archived-%d.jsonl", i))
+		if err := os.WriteFile(path, []byte(tokenCountJSONLine(now.Add(-time.Hour), 10)+"\n"), 0o600); err != nil {
+			t.Fatalf("write archived file: %v", err)
+		}
+		modTime := now.Add(-time.Duration(i) * time.Hour)
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatalf("chtimes archived file: %v", err)
+		}
+	}
+
+	t.Setenv(maxArchivedFilesEnvVar, "2")
+	files, warnings, partial, err := discoverRecentUsageFiles(context.Background(), home, SessionsScopeAll, defaultObservedWindows, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !partial || len(files) != 2 {
+		t.Fatalf("expected 2 capped files, got partial=%v files=%v", partial, files)
+	}
+	if filepath.Base(files[0]) != "archived-0.jsonl" || filepath.Base(files[1]) != "archived-1.jsonl" {
+		t.Fatalf("expected newest archived files to be kept, got %v", files)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "newest 2 of 4") {
+		t.Fatalf("expected truncation warning, got %v", warnings)
+	}
+
+	estimate, err := computeObservedTokenEstimate(context.Background(), home, SessionsScopeAll, defaultObservedWindows, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if estimate.Status != observedTokensStatusPartial {
+		t.Fatalf("expected partial estimate, got %+v", estimate)
+	}
+
+	t.Setenv(maxArchivedFilesEnvVar, "0")
+	if files, _, partial, _ := discoverRecentUsageFiles(context.Background(), home, SessionsScopeAll, defaultObservedWindows, now); partial || len(files) != 4 {
+		t.Fatalf("expected cap of 0 to disable truncation, got partial=%v files=%d", partial, len(files))
+	}
+
+	t.Setenv(maxArchivedFilesEnvVar, "lots")
+	if _, warnings, _, _ := discoverRecentUsageFiles(context.Background(), home, SessionsScopeAll, defaultObservedWindows, now); len(warnings) != 1 || !strings.Contains(warnings[0], "ignoring invalid") {
+		t.Fatalf("expected invalid cap warning, got %v", warnings)
+	}
+}
+
+func TestDiscoverRecentUsageFilesHonorsSessionsScope(t *testing.T) {
+	now := time.Date(2026, 2, 26, 20, 0, 0, 0, time.UTC)
+	home := t.TempDir()
+	liveDir := filepath.Join(home, "sessions", now.Format("2006"), now.Format("01"), now.Format("02"))
+	archivedDir := filepath.Join(home, "archived_sessions")
+	for _, dir := range []string{liveDir, archivedDir} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+	}
+	livePath := filepath.Join(liveDir, "live.jsonl")
+	archivedPath := filepath.Join(archivedDir, "archived.jsonl")
+	for _, path := range []string{livePath, archivedPath} {
+		if err := os.WriteFile(path, []byte(tokenCountJSONLine(now.Add(-time.Hour), 10)+"\n"), 0o600); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+		if err := os.Chtimes(path, now, now); err != nil {
+			t.Fatalf("chtimes: %v", err)
+		}
+	}
+
+	cases := map[SessionsScope][]string{
+		SessionsScopeAll:      {archivedPath, livePath},
+		SessionsScopeLive:     {livePath},
+		SessionsScopeArchived: {archivedPath},
+	}
+	for scope, want := range cases {
+		files, _, _, err := discoverRecentUsageFiles(context.Background(), home, scope, defaultObservedWindows, now)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", scope, err)
+		}
+		if strings.Join(files, ",") != strings.Join(want, ",") {
+			t.Fatalf("%s: expected %v, got %v", scope, want, files)
+		}
+	}
+
+	estimate, err := computeObservedTokenEstimate(context.Background(), home, SessionsScopeLive, defaultObservedWindows, now)
+	if err != nil || estimate.Note != "local estimate (live sessions only)" {
+		t.Fatalf("expected scope in note, got %q (%v)", estimate.Note, err)
+	}
+}
+
+func TestParseSessionsScope(t *testing.T) {
+	if scope, err := ParseSessionsScope(""); err != nil || scope != SessionsScopeAll {
+		t.Fatalf("expected empty scope to default to all, got %q (%v)", scope, err)
+	}
+	if scope, err := ParseSessionsScope(" Live "); err != nil || scope != SessionsScopeLive {
+		t.Fatalf("expected live scope, got %q (%v)", scope, err)
+	}
+	if _, err := ParseSessionsScope("recent"); err == nil {
+		t.Fatalf("expected unknown scope to fail")
+	}
+}
+
+func TestComputeObservedTokenEstimateSplitsReasoningAndCachedOutput(t *testing.T) {
+	now := time.Date(2026, 2, 26, 20, 0, 0, 0, time.UTC)
+	home := t.TempDir()
+
+	newSessionFixture(t, now.Add(-3*time.Hour), "gpt-5-codex").
+		turn(t, now.Add(-3*time.Hour), tokenUsageTotal{InputTokens: 1000, CachedInputTokens: 600, OutputTokens: 200, ReasoningOutputTokens: 120}).
+		turn(t, now.Add(-1*time.Hour), tokenUsageTotal{InputTokens: 500, CachedInputTokens: 400, OutputTokens: 100, ReasoningOutputTokens: 0, CachedOutputTokens: 30}).
+		writeLive(t, home, now, "rollout-reasoning.jsonl")
+
+	newSessionFixture(t, now.Add(-26*time.Hour), "gpt-5").
+		turn(t, now.Add(-26*time.Hour), tokenUsageTotal{InputTokens: 300, OutputTokens: 50}).
+		writeLive(t, home, now.Add(-26*time.Hour), "rollout-yesterday.jsonl")
+
+	newSessionFixture(t, now.Add(-4*24*time.Hour), "gpt-5").
+		turn(t, now.Add(-4*24*time.Hour), tokenUsageTotal{InputTokens: 40, OutputTokens: 10, ReasoningOutputTokens: 5}).
+		raw("not-json").
+		writeArchived(t, home, "rollout-archived.jsonl", now)
+
+	estimate, err := computeObservedTokenEstimate(context.Background(), home, SessionsScopeAll, defaultObservedWindows, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	five := estimate.Window5h
+	if five.Total != 1800 || five.Input != 1500 || five.CachedInput != 1000 || five.Output != 300 {
+		t.Fatalf("unexpected five-hour split: %+v", five)
+	}
+	if five.ReasoningOutput != 120 || five.CachedOutput != 30 || !five.HasSplit || !five.HasCachedOutput {
+		t.Fatalf("expected reasoning and cached output in five-hour split: %+v", five)
+	}
+
+	weekly := estimate.WindowWeekly
+	if weekly.Total != 1800+350+50 || weekly.ReasoningOutput != 125 || weekly.Output != 360 {
+		t.Fatalf("unexpected weekly split across sessions: %+v", weekly)
+	}
+	if !strings.Contains(strings.Join(estimate.Warnings, " | "), "unparsable") {
+		t.Fatalf("expected warning for malformed archived line, got %v", estimate.Warnings)
+	}
+}
+
+func TestComputeObservedTokenEstimateScansDaysForLongerWindow(t *testing.T) {
+	now := time.Date(2026, 2, 26, 20, 0, 0, 0, time.UTC)
+	home := t.TempDir()
+	tenDaysAgo := now.Add(-10 * 24 * time.Hour)
+
+	newSessionFixture(t, tenDaysAgo, "gpt-5").
+		turn(t, tenDaysAgo, tokenUsageTotal{InputTokens: 300, OutputTokens: 50}).
+		writeLive(t, home, tenDaysAgo, "rollout-old.jsonl")
+
+	estimate, err := computeObservedTokenEstimate(context.Background(), home, SessionsScopeAll, defaultObservedWindows, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if estimate.WindowWeekly.Total != 0 {
+		t.Fatalf("expected the default week to skip a ten-day-old session, got %+v", estimate.WindowWeekly)
+	}
+
+	windows := observedWindows{Primary: 5 * time.Hour, Secondary: 14 * 24 * time.Hour}
+	estimate, err = computeObservedTokenEstimate(context.Background(), home, SessionsScopeAll, windows, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if estimate.WindowWeekly.Total != 350 {
+		t.Fatalf("expected a 14d window to count the ten-day-old session, got %+v", estimate.WindowWeekly)
+	}
+}
+
+func TestSessionFixtureFirstTurnUsesLastUsageOutsideWindow(t *testing.T) {
+	now := time.Date(2026, 2, 26, 20, 0, 0, 0, time.UTC)
+	path := newSessionFixture(t, now.Add(-8*time.Hour), "gpt-5-codex").
+		turn(t, now.Add(-8*time.Hour), tokenUsageTotal{InputTokens: 700, OutputTokens: 300}).
+		turn(t, now.Add(-2*time.Hour), tokenUsageTotal{InputTokens: 90, OutputTokens: 10}).
+		writeTo(t, t.TempDir(), "rollout.jsonl")
+
+	sum5h, sum1w, warnings, err := estimateTokensFromFile(path, now.Add(-5*time.Hour), now.Add(-7*24*time.Hour))
+	if err != nil || len(warnings) != 0 {
+		t.Fatalf("unexpected error %v or warnings %v", err, warnings)
+	}
+	if sum5h.Total != 100 || sum5h.Events != 1 {
+		t.Fatalf("expected only the in-window turn delta, got %+v", sum5h)
+	}
+	if sum1w.Total != 1100 || sum1w.Events != 2 {
+		t.Fatalf("expected both turns in the weekly window, got %+v", sum1w)
+	}
+}
@@ -0,0 +1,165 @@
+package usage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	defaultPricingFileName = "pricing.json"
+	pricingFileEnvVar      = "CODEX_USAGE_MONITOR_PRICING_FILE"
+	// defaultPricingModel prices models the file does not list by name.
+	defaultPricingModel = "default"
+)
+
+// ModelPrice is USD per million tokens. Cached input is part of input and
+// reasoning is part of output, as codex reports them, so the cached and
+// reasoning prices apply to that share only. Either one falls back to the
+// base price when omitted.
+type ModelPrice struct {
+	Input           float64  `json:"input"`
+	CachedInput     *float64 `json:"cached_input,omitempty"`
+	Output          float64  `json:"output"`
+	ReasoningOutput *float64 `json:"reasoning_output,omitempty"`
+}
+
+type pricingFile struct {
+	Models map[string]ModelPrice `json:"models"`
+}
+
+// pricingTable is keyed by lowercased model name.
+type pricingTable map[string]ModelPrice
+
+// loadPricing reads the optional pricing file; a missing file yields a nil
+// table and no error.
+func loadPricing() (pricingTable, error) {
+	path, err := resolvePricingFilePath()
+	if err != nil {
+		return nil, fmt.Errorf("resolve pricing file: %w", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		// Only the default locations are optional; a missing file at an
+		// explicit path is usually a typo that would hide costs silently.
+		if errors.Is(err, os.ErrNotExist) && strings.TrimSpace(os.Getenv(pricingFileEnvVar)) == "" {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read pricing file %s: %w", path, err)
+	}
+	var raw pricingFile
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("decode pricing file %s: %w", path, err)
+	}
+	table := pricingTable{}
+	for model, price := range raw.Models {
+		if price.Input < 0 || price.Output < 0 || (price.CachedInput != nil && *price.CachedInput < 0) || (price.ReasoningOutput != nil && *price.ReasoningOutput < 0) {
+			return nil, fmt.Errorf("pricing file %s: negative price for model %q", path, model)
+		}
+		table[strings.ToLower(strings.TrimSpace(model))] = price
+	}
+	return table, nil
+}
+
+// resolvePricingFilePath mirrors the accounts file: the env var, then the
+// monitor data dir, then the legacy dot directory.
+func resolvePricingFilePath() (string, error) {
+	if explicit := strings.TrimSpace(os.Getenv(pricingFileEnvVar)); explicit != "" {
+		return expandPath(explicit)
+	}
+	dir, err := monitorDataDir()
+	if err != nil {
+		return "", err
+	}
+	defaultPath := filepath.Join(dir, defaultPricingFileName)
+	if fileExists(defaultPath) {
+		return defaultPath, nil
+	}
+	home, err := userHomeDir()
+	if err != nil {
+		return "", err
+	}
+	legacyPath := filepath.Join(home, legacyMonitorDirName, defaultPricingFileName)
+	if fileExists(legacyPath) {
+		return legacyPath, nil
+	}
+	return defaultPath, nil
+}
+
+func (p pricingTable) price(model string) (ModelPrice, bool) {
+	if price, ok := p[strings.ToLower(strings.TrimSpace(model))]; ok {
+		return price, true
+	}
+	price, ok := p[defaultPricingModel]
+	return price, ok
+}
+
+// cost prices per-model usage. It returns nil and the unpriced model names
+// when any model with tokens has no price, so a partial sum is never shown
+// as the total.
+func (p pricingTable) cost(models map[string]tokenUsageTotal) (*float64, []string) {
+	total := 0.0
+	var missing []string
+	for model, usage := range models {
+		if !usage.hasUsage() {
+			continue
+		}
+		price, ok := p.price(model)
+		if !ok {
+			missing = append(missing, model)
+			continue
+		}
+		total += price.usd(usage)
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return nil, missing
+	}
+	return &total, nil
+}
+
+func (price ModelPrice) usd(usage tokenUsageTotal) float64 {
+	cachedInputPrice := price.Input
+	if price.CachedInput != nil {
+		cachedInputPrice = *price.CachedInput
+	}
+	reasoningPrice := price.Output
+	if price.ReasoningOutput != nil {
+		reasoningPrice = *price.ReasoningOutput
+	}
+	cachedInput := min(usage.CachedInputTokens, usage.InputTokens)
+	reasoning := min(usage.ReasoningOutputTokens, usage.OutputTokens)
+	millionths := float64(usage.InputTokens-cachedInput)*price.Input +
+		float64(cachedInput)*cachedInputPrice +
+		float64(usage.OutputTokens-reasoning)*price.Output +
+		float64(reasoning)*reasoningPrice
+	return millionths / 1_000_000
+}
+
+// applyPricing fills EstimatedCostUSD on both windows. Unpriced models leave
+// the field unset and come back as one warning.
+func applyPricing(estimate *ObservedTokenEstimate, pricing pricingTable) string {
+	if pricing == nil {
+		return ""
+	}
+	var missing []string
+	for _, window := range []*ObservedTokenBreakdown{&estimate.Window5h, &estimate.WindowWeekly} {
+		cost, unpriced := pricing.cost(window.models)
+		window.EstimatedCostUSD = cost
+		for _, model := range unpriced {
+			if model == "" {
+				model = "(unknown model)"
+			}
+			missing = append(missing, model)
+		}
+	}
+	missing = dedupeStrings(missing)
+	if len(missing) == 0 {
+		return ""
+	}
+	return "estimated cost omitted; no price for " + strings.Join(missing, ", ")
+}
@@ -0,0 +1,78 @@
+package usage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestApplyPricingEstimatesCostPerModel(t *testing.T) {
+	now := time.Now().UTC()
+	home := t.TempDir()
+	newSessionFixture(t, now.Add(-time.Hour), "gpt-5").
+		turn(t, now.Add(-time.Hour), tokenUsageTotal{InputTokens: 2_000_000, CachedInputTokens: 1_000_000, OutputTokens: 500_000, ReasoningOutputTokens: 100_000}).
+		writeLive(t, home, now, "rollout-a.jsonl")
+	newSessionFixture(t, now.Add(-2*24*time.Hour), "gpt-5-mini").
+		turn(t, now.Add(-2*24*time.Hour), tokenUsageTotal{InputTokens: 1_000_000, OutputTokens: 1_000_000}).
+		writeLive(t, home, now.Add(-2*24*time.Hour), "rollout-b.jsonl")
+
+	pricingPath := filepath.Join(t.TempDir(), "pricing.json")
+	writePricing := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(pricingPath, []byte(content), 0o600); err != nil {
+			t.Fatalf("write pricing: %v", err)
+		}
+	}
+	t.Setenv(pricingFileEnvVar, pricingPath)
+	writePricing(`{"models":{"GPT-5":{"input":1.25,"cached_input":0.125,"output":10},"gpt-5-mini":{"input":0.25,"output":2}}}`)
+	pricing, err := loadPricing()
+	if err != nil {
+		t.Fatalf("load pricing: %v", err)
+	}
+
+	estimate, err := computeObservedTokenEstimate(context.Background(), home, SessionsScopeAll, defaultObservedWindows, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if warning := applyPricing(&estimate, pricing); warning != "" {
+		t.Fatalf("expected every model priced, got %q", warning)
+	}
+	// gpt-5: 1M uncached input, 1M cached, 400k output, 100k reasoning at the output price.
+	if cost := estimate.Window5h.EstimatedCostUSD; cost == nil || *cost != 6.375 {
+		t.Fatalf("expected $6.375 for the 5h window, got %v", cost)
+	}
+	if cost := estimate.WindowWeekly.EstimatedCostUSD; cost == nil || *cost != 8.625 {
+		t.Fatalf("expected $8.625 for the weekly window, got %v", cost)
+	}
+
+	writePricing(`{"models":{"gpt-5":{"input":1.25,"output":10}}}`)
+	if pricing, err = loadPricing(); err != nil {
+		t.Fatalf("load pricing: %v", err)
+	}
+	warning := applyPricing(&estimate, pricing)
+	if estimate.WindowWeekly.EstimatedCostUSD != nil || !strings.Contains(warning, "no price for gpt-5-mini") {
+		t.Fatalf("expected an unpriced model to drop the weekly cost with a warning, got %v / %q", estimate.WindowWeekly.EstimatedCostUSD, warning)
+	}
+	if estimate.Window5h.EstimatedCostUSD == nil {
+		t.Fatalf("expected the 5h window, which only saw gpt-5, to stay priced")
+	}
+
+	writePricing(`{"models":{"gpt-5":{"input":-1,"output":10}}}`)
+	if _, err := loadPricing(); err == nil {
+		t.Fatalf("expected a negative price to be rejected")
+	}
+
+	t.Setenv(pricingFileEnvVar, filepath.Join(t.TempDir(), "missing.json"))
+	if _, err := loadPricing(); err == nil || !strings.Contains(err.Error(), "missing.json") {
+		t.Fatalf("expected a missing explicit pricing file to be an error, got %v", err)
+	}
+
+	t.Setenv(pricingFileEnvVar, "")
+	t.Setenv("HOME", t.TempDir())
+	if pricing, err := loadPricing(); err != nil || pricing != nil {
+		t.Fatalf("expected no pricing file at the default paths to disable costs quietly, got %v, %v", pricing, err)
+	}
+}
@@ -0,0 +1,37 @@
+package usage
+
+import "time"
+
+// applyProjection fills BurnRatePerMin from the observed five-hour tokens and
+// ProjectedExhaustionAt from the primary window's average pace since it
+// started. Either stays unset when its inputs are missing or zero.
+func applyProjection(summary *Summary, lookback time.Duration, now time.Time) {
+	summary.BurnRatePerMin = 0
+	summary.ProjectedExhaustionAt = nil
+
+	if minutes := int64(lookback / time.Minute); summary.ObservedTokens5h != nil && minutes > 0 {
+		summary.BurnRatePerMin = *summary.ObservedTokens5h / minutes
+	}
+	if summary.WindowDataAvailable {
+		summary.ProjectedExhaustionAt = projectExhaustion(summary.PrimaryWindow, now)
+	}
+}
+
+// projectExhaustion extrapolates the used percent linearly from the window
+// start. A window with nothing used yet, or without a known start, has no
+// projection; one already at 100% is exhausted now.
+func projectExhaustion(win WindowSummary, now time.Time) *time.Time {
+	if win.UsedPercent <= 0 || win.WindowDurationMins == nil || win.SecondsUntilReset == nil {
+		return nil
+	}
+	elapsed := time.Duration(*win.WindowDurationMins)*time.Minute - time.Duration(*win.SecondsUntilReset)*time.Second
+	if elapsed <= 0 {
+		return nil
+	}
+	if win.UsedPercent >= 100 {
+		return &now
+	}
+	remaining := elapsed * time.Duration(100-win.UsedPercent) / time.Duration(win.UsedPercent)
+	at := now.Add(remaining)
+	return &at
+}
@@ -0,0 +1,46 @@
+package usage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestApplyProjectionExtrapolatesPrimaryWindow(t *testing.T) {
+	now := time.Date(2026, 2, 26, 20, 0, 0, 0, time.UTC)
+	duration := 300
+	window := func(used int, untilReset int64) WindowSummary {
+		return WindowSummary{UsedPercent: used, WindowDurationMins: &duration, SecondsUntilReset: &untilReset}
+	}
+	tokens := int64(600)
+
+	// Half the budget in the first 150 minutes runs out 150 minutes from now.
+	summary := &Summary{WindowDataAvailable: true, PrimaryWindow: window(50, 150*60), ObservedTokens5h: &tokens}
+	applyProjection(summary, 5*time.Hour, now)
+	if at := summary.ProjectedExhaustionAt; at == nil || !at.Equal(now.Add(150*time.Minute)) {
+		t.Fatalf("expected exhaustion in 150m, got %v", at)
+	}
+	if summary.BurnRatePerMin != 2 {
+		t.Fatalf("expected 600 tokens over 300 minutes to burn 2/min, got %d", summary.BurnRatePerMin)
+	}
+
+	cases := map[string]struct {
+		win  WindowSummary
+		want *time.Time
+	}{
+		"nothing used":   {window(0, 150*60), nil},
+		"just reset":     {window(10, 300*60), nil},
+		"already full":   {window(100, 60*60), &now},
+		"unknown length": {WindowSummary{UsedPercent: 40}, nil},
+	}
+	for name, tc := range cases {
+		summary := &Summary{WindowDataAvailable: true, PrimaryWindow: tc.win}
+		applyProjection(summary, 5*time.Hour, now)
+		got := summary.ProjectedExhaustionAt
+		if (got == nil) != (tc.want == nil) || (got != nil && !got.Equal(*tc.want)) {
+			t.Fatalf("%s: expected %v, got %v", name, tc.want, got)
+		}
+		if summary.BurnRatePerMin != 0 {
+			t.Fatalf("%s: expected no burn rate without observed tokens, got %d", name, summary.BurnRatePerMin)
+		}
+	}
+}
@@ -1,7 +1,10 @@
 package usage
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 )
 
@@ -9,6 +12,9 @@ type rateLimitWindowRaw struct {
 	UsedPercent        int    `json:"usedPercent"`
 	WindowDurationMins *int   `json:"windowDurationMins"`
 	ResetsAt           *int64 `json:"resetsAt"`
+	// Limit and Used are absolute caps; most responses only carry percentages.
+	Limit *int64 `json:"limit"`
+	Used  *int64 `json:"used"`
 }
 
 type creditsSnapshotRaw struct {
@@ -29,6 +35,53 @@ type rateLimitSnapshotRaw struct {
 type rateLimitsReadResultRaw struct {
 	RateLimits          rateLimitSnapshotRaw            `json:"rateLimits"`
 	RateLimitsByLimitID map[string]rateLimitSnapshotRaw `json:"rateLimitsByLimitId"`
+	RequiresOpenAIAuth  bool                            `json:"requiresOpenaiAuth"`
+
+	// UsedAlternateKeys reports that a known alternate key spelling was decoded.
+	UsedAlternateKeys bool `json:"-"`
+}
+
+// Known key spellings, canonical first. Older or newer codex builds may emit
+// snake_case variants; decoding them avoids silently empty windows.
+var (
+	rateLimitsKeys          = []string{"rateLimits", "rate_limits"}
+	rateLimitsByLimitIDKeys = []string{"rateLimitsByLimitId", "rate_limits_by_limit_id"}
+)
+
+func (r *rateLimitsReadResultRaw) UnmarshalJSON(data []byte) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	*r = rateLimitsReadResultRaw{}
+
+	if raw, alternate, ok := lookupAlternateKey(fields, rateLimitsKeys); ok {
+		if err := json.Unmarshal(raw, &r.RateLimits); err != nil {
+			return fmt.Errorf("decode rate limits: %w", err)
+		}
+		r.UsedAlternateKeys = r.UsedAlternateKeys || alternate
+	}
+	if raw, alternate, ok := lookupAlternateKey(fields, rateLimitsByLimitIDKeys); ok {
+		if err := json.Unmarshal(raw, &r.RateLimitsByLimitID); err != nil {
+			return fmt.Errorf("decode rate limits by limit id: %w", err)
+		}
+		r.UsedAlternateKeys = r.UsedAlternateKeys || alternate
+	}
+	if raw, ok := fields["requiresOpenaiAuth"]; ok {
+		_ = json.Unmarshal(raw, &r.RequiresOpenAIAuth)
+	}
+	return nil
+}
+
+func lookupAlternateKey(fields map[string]json.RawMessage, keys []string) (json.RawMessage, bool, bool) {
+	for i, key := range keys {
+		raw, ok := fields[key]
+		if !ok || string(raw) == "null" {
+			continue
+		}
+		return raw, i > 0, true
+	}
+	return nil, false, false
 }
 
 type identityInfo struct {
@@ -45,6 +98,15 @@ func normalizeSummary(source string, snapshot rateLimitSnapshotRaw, additionalLi
 		return nil, errors.New("missing secondary window")
 	}
 
+	for _, win := range []struct {
+		name string
+		raw  *rateLimitWindowRaw
+	}{{"primary", snapshot.Primary}, {"secondary", snapshot.Secondary}} {
+		if _, clamped := clampUsedPercent(win.raw.UsedPercent); clamped {
+			warnings = append(warnings, fmt.Sprintf("%s window used_percent %d out of range; clamped to 0-100", win.name, win.raw.UsedPercent))
+		}
+	}
+
 	now := time.Now().UTC()
 	out := &Summary{
 		Source:               source,
@@ -56,6 +118,15 @@ func normalizeSummary(source string, snapshot rateLimitSnapshotRaw, additionalLi
 		Warnings:             warnings,
 		FetchedAt:            now,
 	}
+	if snapshot.Credits != nil {
+		out.Credits = &CreditsSummary{
+			HasCredits: snapshot.Credits.HasCredits,
+			Unlimited:  snapshot.Credits.Unlimited,
+		}
+		if snapshot.Credits.Balance != nil {
+			out.Credits.Balance = strings.TrimSpace(*snapshot.Credits.Balance)
+		}
+	}
 	if identity != nil {
 		out.AccountEmail = identity.Email
 		out.AccountID = identity.AccountID
@@ -65,8 +136,9 @@ func normalizeSummary(source string, snapshot rateLimitSnapshotRaw, additionalLi
 }
 
 func toWindowSummary(win *rateLimitWindowRaw) WindowSummary {
+	usedPercent, _ := clampUsedPercent(win.UsedPercent)
 	out := WindowSummary{
-		UsedPercent:        win.UsedPercent,
+		UsedPercent:        usedPercent,
 		WindowDurationMins: win.WindowDurationMins,
 	}
 	if win.ResetsAt != nil {
@@ -75,5 +147,24 @@ func toWindowSummary(win *rateLimitWindowRaw) WindowSummary {
 		seconds := int64(time.Until(reset).Seconds())
 		out.SecondsUntilReset = &seconds
 	}
+	if win.Limit != nil && win.Used != nil && *win.Limit > 0 {
+		limit := *win.Limit
+		used := max(0, *win.Used)
+		out.Limit = &limit
+		out.Used = &used
+	}
 	return out
 }
+
+// clampUsedPercent keeps API glitches (negative or >100 values around resets)
+// from breaking bars and thresholds.
+func clampUsedPercent(v int) (int, bool) {
+	switch {
+	case v < 0:
+		return 0, true
+	case v > 100:
+		return 100, true
+	default:
+		return v, false
+	}
+}
@@ -0,0 +1,129 @@
+package usage
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// sessionFixture builds codex session JSONL the way the CLI writes it: a
+// session_meta header, a turn_context naming the model, and per turn a user
+// message followed by a token_count event carrying cumulative and last usage.
+type sessionFixture struct {
+	lines []string
+	total tokenUsageTotal
+}
+
+func newSessionFixture(t *testing.T, start time.Time, model string) *sessionFixture {
+	t.Helper()
+	f := &sessionFixture{}
+	f.add(t, start, "session_meta", map[string]any{
+		"id":         "fixture-" + start.UTC().Format("20060102T150405"),
+		"timestamp":  start.UTC().Format(time.RFC3339Nano),
+		"cwd":        "/workspace/project",
+		"originator": "codex_cli_rs",
+	})
+	f.add(t, start, "turn_context", map[string]any{"model": model, "cwd": "/workspace/project"})
+	return f
+}
+
+// turn appends one exchange. A zero TotalTokens is filled in as input + output,
+// matching codex, where cached input and reasoning output are subsets.
+func (f *sessionFixture) turn(t *testing.T, at time.Time, usage tokenUsageTotal) *sessionFixture {
+	t.Helper()
+	if usage.TotalTokens == 0 {
+		usage.TotalTokens = usage.InputTokens + usage.OutputTokens
+	}
+	f.total = tokenUsageTotal{
+		TotalTokens:           f.total.TotalTokens + usage.TotalTokens,
+		InputTokens:           f.total.InputTokens + usage.InputTokens,
+		CachedInputTokens:     f.total.CachedInputTokens + usage.CachedInputTokens,
+		OutputTokens:          f.total.OutputTokens + usage.OutputTokens,
+		ReasoningOutputTokens: f.total.ReasoningOutputTokens + usage.ReasoningOutputTokens,
+		CachedOutputTokens:    f.total.CachedOutputTokens + usage.CachedOutputTokens,
+	}
+	f.add(t, at, "event_msg", map[string]any{"type": "user_message", "message": "next step"})
+	f.add(t, at, "event_msg", map[string]any{
+		"type": "token_count",
+		"info": map[string]any{
+			"total_token_usage":    fixtureUsageFields(f.total),
+			"last_token_usage":     fixtureUsageFields(usage),
+			"model_context_window": 272000,
+		},
+	})
+	return f
+}
+
+// raw appends a line verbatim, for malformed or unusual records.
+func (f *sessionFixture) raw(line string) *sessionFixture {
+	f.lines = append(f.lines, line)
+	return f
+}
+
+func (f *sessionFixture) add(t *testing.T, at time.Time, kind string, payload map[string]any) {
+	t.Helper()
+	line, err := json.Marshal(map[string]any{
+		"timestamp": at.UTC().Format(time.RFC3339Nano),
+		"type":      kind,
+		"payload":   payload,
+	})
+	if err != nil {
+		t.Fatalf("marshal fixture line: %v", err)
+	}
+	f.lines = append(f.lines, string(line))
+}
+
+func (f *sessionFixture) String() string {
+	return strings.Join(f.lines, "\n") + "\n"
+}
+
+// writeLive stores the fixture under sessions/YYYY/MM/DD like codex does.
+func (f *sessionFixture) writeLive(t *testing.T, home string, day time.Time, name string) string {
+	t.Helper()
+	dir := filepath.Join(home, "sessions", day.Format("2006"), day.Format("01"), day.Format("02"))
+	return f.writeTo(t, dir, name)
+}
+
+// writeArchived stores the fixture under archived_sessions with modTime set so
+// the recency filter keeps it.
+func (f *sessionFixture) writeArchived(t *testing.T, home, name string, modTime time.Time) string {
+	t.Helper()
+	path := f.writeTo(t, filepath.Join(home, "archived_sessions"), name)
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatalf("chtimes fixture: %v", err)
+	}
+	return path
+}
+
+func (f *sessionFixture) writeTo(t *testing.T, dir, name string) string {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatalf("mkdir fixture dir: %v", err)
+	}
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(f.String()), 0o600); err != nil {
+		t.Fatalf("write fixture: %v", err)
+	}
+	return path
+}
+
+// fixtureUsageFields omits zero split fields, so fixtures also cover logs that
+// do not report them.
+func fixtureUsageFields(u tokenUsageTotal) map[string]int64 {
+	out := map[string]int64{"total_tokens": u.TotalTokens}
+	for key, value := range map[string]int64{
+		"input_tokens":            u.InputTokens,
+		"cached_input_tokens":     u.CachedInputTokens,
+		"output_tokens":           u.OutputTokens,
+		"reasoning_output_tokens": u.ReasoningOutputTokens,
+		"cached_output_tokens":    u.CachedOutputTokens,
+	} {
+		if value != 0 {
+			out[key] = value
+		}
+	}
+	return out
+}
@@ -1,9 +1,31 @@
 package usage
 
-import "context"
+import (
+	"context"
+	"errors"
+	"strings"
+)
 
 type Source interface {
 	Name() string
 	Fetch(context.Context) (*Summary, error)
 	Close() error
 }
+
+// ErrAuthRequired marks source failures caused by missing or rejected
+// credentials, as opposed to connectivity or protocol failures.
+var ErrAuthRequired = errors.New("authentication required")
+
+// authRPCErrorHints match app-server error messages that mean the codex login
+// is missing or expired; the RPC error codes are not stable across builds.
+var authRPCErrorHints = []string{"unauthorized", "not logged in", "login required", "authentication", "requires openai auth"}
+
+func isAuthRPCErrorMessage(message string) bool {
+	lower := strings.ToLower(message)
+	for _, hint := range authRPCErrorHints {
+		if strings.Contains(lower, hint) {
+			return true
+		}
+	}
+	return false
+}